@@ -0,0 +1,44 @@
+package airtable
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+)
+
+// maxBatchSize is the maximum number of records per create request accepted by
+// the Airtable API.
+const maxBatchSize = 10
+
+// createRecords creates the records (pointers to airtablemodel records) with a
+// single API call, and sets their ID and CreatedTime from the response.
+func createRecords(opts Options, tableName string, records []interface{}) error {
+	type recordFields struct {
+		Fields interface{} `json:"fields"`
+	}
+	in := struct {
+		Records []recordFields `json:"records"`
+	}{}
+	for _, record := range records {
+		fields := reflect.ValueOf(record).Elem().FieldByName("Fields").Interface()
+		in.Records = append(in.Records, recordFields{Fields: fields})
+	}
+
+	var out struct {
+		Records []json.RawMessage `json:"records"`
+	}
+	path := fmt.Sprintf("/%s/%s", opts.BaseID, url.PathEscape(tableName))
+	if err := airtableDo(opts, "POST", path, in, &out); err != nil {
+		return err
+	}
+	if len(out.Records) != len(records) {
+		return fmt.Errorf("airtable created %d records instead of %d", len(out.Records), len(records))
+	}
+	for idx, raw := range out.Records {
+		if err := json.Unmarshal(raw, records[idx]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
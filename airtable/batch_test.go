@@ -0,0 +1,105 @@
+package airtable
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"moul.io/depviz/airtabledb"
+	"moul.io/depviz/airtablemodel"
+)
+
+// fakeRecordCreator creates the records one by one, except the failing ones.
+type fakeRecordCreator struct {
+	failing map[string]bool // depviz IDs
+	created int
+}
+
+func (c *fakeRecordCreator) Create(recordPtr interface{}) error {
+	record := recordPtr.(*airtablemodel.LabelRecord)
+	if c.failing[record.Fields.ID] {
+		return fmt.Errorf("422 Unprocessable Entity")
+	}
+	c.created++
+	record.ID = "rec" + record.Fields.Name
+	return nil
+}
+
+func TestCreateCachedRecordsFallback(t *testing.T) {
+	cache := airtablemodel.NewDB()
+	unmatched := airtablemodel.NewDB()
+	ut := unmatched.Tables[airtablemodel.LabelIndex]
+	ct := cache.Tables[airtablemodel.LabelIndex]
+	for i := 1; i <= 12; i++ { // two batches: 10 and 2 records
+		record := airtablemodel.LabelRecord{}
+		record.Fields.ID = fmt.Sprintf("https://github.com/moul/depviz/labels/%d", i)
+		record.Fields.Name = fmt.Sprintf("%d", i)
+		ut.Append(record)
+	}
+
+	batches := 0
+	createBatch := func(batch []interface{}) error {
+		batches++
+		if batches == 1 { // not retried
+			return fmt.Errorf("422 Unprocessable Entity")
+		}
+		for _, recordPtr := range batch {
+			record := recordPtr.(*airtablemodel.LabelRecord)
+			record.ID = "rec" + record.Fields.Name
+		}
+		return nil
+	}
+	creator := &fakeRecordCreator{failing: map[string]bool{"https://github.com/moul/depviz/labels/3": true}}
+	failed := createCachedRecords(&SyncOptions{}, "Label", ut, ct, creator, createBatch)
+
+	if expected := []string{"https://github.com/moul/depviz/labels/3"}; !reflect.DeepEqual(failed, expected) {
+		t.Errorf("expected the failed records %v, got %v", expected, failed)
+	}
+	if batches != 2 || creator.created != 9 {
+		t.Errorf("expected 2 batches and 9 single creations, got %d and %d", batches, creator.created)
+	}
+	// only the created records are cached, with their Airtable ID
+	if ct.Len() != 11 {
+		t.Fatalf("expected 11 cached records, got %d", ct.Len())
+	}
+	for i := 0; i < ct.Len(); i++ {
+		if ct.GetFieldID(i) == "https://github.com/moul/depviz/labels/3" {
+			t.Errorf("the failed record should not be cached")
+		}
+		if ct.GetID(i) == "" {
+			t.Errorf("record %s: expected an Airtable ID", ct.GetFieldID(i))
+		}
+		if ct.GetState(i) != airtabledb.StateNew {
+			t.Errorf("record %s: expected the new state", ct.GetFieldID(i))
+		}
+	}
+}
+
+func TestCreateCachedRecordsDryRun(t *testing.T) {
+	cache := airtablemodel.NewDB()
+	unmatched := airtablemodel.NewDB()
+	ut := unmatched.Tables[airtablemodel.LabelIndex]
+	ct := cache.Tables[airtablemodel.LabelIndex]
+	record := airtablemodel.LabelRecord{}
+	record.Fields.ID = "https://github.com/moul/depviz/labels/bug"
+	ut.Append(record)
+
+	createBatch := func(batch []interface{}) error {
+		t.Fatal("unexpected batch creation in dry-run mode")
+		return nil
+	}
+	failed := createCachedRecords(&SyncOptions{DryRun: true}, "Label", ut, ct, &fakeRecordCreator{}, createBatch)
+	if len(failed) != 0 || ct.Len() != 1 {
+		t.Errorf("expected the record to be cached without failure, got %d cached and %v failed", ct.Len(), failed)
+	}
+}
+
+func TestSharedLimiter(t *testing.T) {
+	opts := Options{RateLimit: 5}
+	if opts.client().Limiter != opts.limiter() {
+		t.Error("expected the client and the raw calls to share the limiter")
+	}
+	if (Options{RateLimit: 10}).limiter() == opts.limiter() {
+		t.Error("expected a limiter per rate")
+	}
+}
@@ -2,6 +2,10 @@ package airtable // import "moul.io/depviz/airtable"
 
 import (
 	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/brianloveswords/airtable"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -24,7 +28,10 @@ type Options struct {
 	AccountsTableName     string `mapstructure:"airtable-accounts-table-name"`
 	BaseID                string `mapstructure:"airtable-base-id"`
 	Token                 string `mapstructure:"airtable-token"`
-	RateLimiter           int    `mapstructure:"airtable-ratelimiter"`
+	RateLimit             int    `mapstructure:"airtable-rate-limit"`
+	RateLimiter           int    `mapstructure:"airtable-ratelimiter"` // deprecated, see RateLimit
+	MaxRetries            int    `mapstructure:"airtable-max-retries"`
+	PageSize              int    `mapstructure:"airtable-page-size"`
 }
 
 func (opts Options) String() string {
@@ -32,6 +39,66 @@ func (opts Options) String() string {
 	return string(out)
 }
 
+func (opts Options) Validate() error {
+	if opts.BaseID == "" || opts.Token == "" {
+		return fmt.Errorf("missing token or baseid, check '-h'")
+	}
+	if opts.RateLimiter != 0 {
+		zap.L().Warn("'airtable-ratelimiter' is deprecated, use 'airtable-rate-limit' instead")
+	}
+	if rateLimit := opts.rateLimit(); rateLimit < 1 || rateLimit > 50 {
+		return fmt.Errorf("invalid airtable rate limit %d, expected a number of requests per second between 1 and 50", rateLimit)
+	}
+	if opts.MaxRetries < 0 {
+		return fmt.Errorf("invalid airtable max retries %d", opts.MaxRetries)
+	}
+	if opts.PageSize < 1 || opts.PageSize > maxPageSize {
+		return fmt.Errorf("invalid airtable page size %d, expected a number of records between 1 and %d", opts.PageSize, maxPageSize)
+	}
+	return nil
+}
+
+// rateLimit returns the --airtable-rate-limit, or the deprecated
+// --airtable-ratelimiter if set.
+func (opts Options) rateLimit() int {
+	if opts.RateLimiter != 0 {
+		return opts.RateLimiter
+	}
+	return opts.RateLimit
+}
+
+// defaultRateLimit is the default --airtable-rate-limit, the limit of the
+// Airtable API per base.
+const defaultRateLimit = 5
+
+var (
+	limiters   = map[int]airtable.Limiter{}
+	limitersMu sync.Mutex
+)
+
+// limiter returns the rate limiter of the Airtable API calls, shared by the
+// client and the raw calls (see airtableDo) so they are throttled together.
+func (opts Options) limiter() airtable.Limiter {
+	rateLimit := opts.rateLimit()
+	if rateLimit < 1 { // not validated, i.e., in the tests
+		rateLimit = defaultRateLimit
+	}
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	if _, found := limiters[rateLimit]; !found {
+		limiters[rateLimit] = airtable.RateLimiter(rateLimit)
+	}
+	return limiters[rateLimit]
+}
+
+func (opts Options) client() airtable.Client {
+	return airtable.Client{
+		APIKey:  opts.Token,
+		BaseID:  opts.BaseID,
+		Limiter: opts.limiter(),
+	}
+}
+
 func (opts *Options) tableNames() []string {
 	tableNames := make([]string, airtablemodel.NumTables)
 	tableNames[airtablemodel.AccountIndex] = opts.AccountsTableName
@@ -71,7 +138,12 @@ func (cmd *airtableCommand) ParseFlags(flags *pflag.FlagSet) {
 	flags.StringVarP(&cmd.opts.MilestonesTableName, "airtable-milestones-table-name", "", "Milestones", "Airtable milestones table nfame")
 	flags.StringVarP(&cmd.opts.ProvidersTableName, "airtable-providers-table-name", "", "Providers", "Airtable providers table name")
 	flags.StringVarP(&cmd.opts.BaseID, "airtable-base-id", "", "", "Airtable base ID")
-	flags.StringVarP(&cmd.opts.Token, "airtable-token", "", "", "Airtable token")
+	flags.IntVarP(&cmd.opts.RateLimit, "airtable-rate-limit", "", defaultRateLimit, "maximum number of Airtable API requests per second (1-50)")
+	flags.IntVarP(&cmd.opts.RateLimiter, "airtable-ratelimiter", "", 0, "deprecated alias of --airtable-rate-limit")
+	_ = flags.MarkDeprecated("airtable-ratelimiter", "use --airtable-rate-limit instead")
+	flags.IntVarP(&cmd.opts.MaxRetries, "airtable-max-retries", "", 3, "number of retries of the Airtable API calls failing with a rate-limit or server error")
+	flags.IntVarP(&cmd.opts.PageSize, "airtable-page-size", "", maxPageSize, "number of records per page when fetching the tables (1-100)")
+	flags.StringVarP(&cmd.opts.Token, "airtable-token", "", "", "Airtable personal access token (scopes: data.records:read, data.records:write, schema.bases:read)")
 
 	if err := viper.BindPFlags(flags); err != nil {
 		zap.L().Warn("failed to bind viper flags", zap.Error(err))
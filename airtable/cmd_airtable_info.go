@@ -3,7 +3,6 @@ package airtable
 import (
 	"fmt"
 
-	"github.com/brianloveswords/airtable"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -42,24 +41,17 @@ func (cmd *infoCommand) ParseFlags(flags *pflag.FlagSet) {
 }
 
 func Info(opts *InfoOptions) error {
-	if opts.Airtable.BaseID == "" || opts.Airtable.Token == "" {
-		return fmt.Errorf("missing token or baseid, check '-h'")
+	if err := opts.Airtable.Validate(); err != nil {
+		return err
 	}
-
-	if opts.Airtable.RateLimiter == 0 {
-		opts.Airtable.RateLimiter = 5
-	}
-	client := airtable.Client{
-		APIKey:  opts.Airtable.Token,
-		BaseID:  opts.Airtable.BaseID,
-		Limiter: airtable.RateLimiter(opts.Airtable.RateLimiter),
+	if err := preflight(opts.Airtable, false); err != nil {
+		return err
 	}
 
 	cache := airtablemodel.NewDB()
 
 	for tableKind, tableName := range opts.Airtable.tableNames() {
-		table := client.Table(tableName)
-		if err := cache.Tables[tableKind].Fetch(table); err != nil {
+		if err := fetchTable(opts.Airtable, tableName, cache.Tables[tableKind]); err != nil {
 			return err
 		}
 		fmt.Printf("- %s: %d\n", tableName, cache.Tables[tableKind].Len())
@@ -3,8 +3,8 @@ package airtable
 import (
 	"fmt"
 	"log"
+	"strings"
 
-	"github.com/brianloveswords/airtable"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -22,8 +22,18 @@ import (
 type SyncOptions struct {
 	Airtable              Options             `mapstructure:"airtable"`
 	SQL                   sql.Options         `mapstructure:"sql"`     // inherited with sql.GetOptions()
-	Targets               []multipmuri.Entity `mapstructure:"targets"` // parsed from Args
+	Targets               []multipmuri.Entity `mapstructure:"targets"` // parsed from Args and TargetsFile
+	TargetsFile           string              `mapstructure:"targets-file"`
 	DestroyInvalidRecords bool                `mapstructure:"airtable-destroy-invalid-records"`
+	DedupeAccountsBy      string              `mapstructure:"dedupe-accounts-by"`
+	DryRun                bool                `mapstructure:"airtable-dry-run"`
+	IncludeExternal       bool                `mapstructure:"airtable-include-external"`
+	Direction             string              `mapstructure:"airtable-direction"`
+	SkipValidation        bool                `mapstructure:"airtable-skip-validation"`
+	ConflictStrategy      string              `mapstructure:"airtable-conflict-strategy"`
+	EstimateLabelPrefix   string              `mapstructure:"estimate-label-prefix"`
+
+	Parse compute.ParseOptions `mapstructure:",squash"`
 }
 
 type syncCommand struct{ opts SyncOptions }
@@ -34,7 +44,7 @@ func (cmd *syncCommand) CobraCommand(commands cli.Commands) *cobra.Command {
 		Short: "Upload issue info stored in database to airtable spreadsheets",
 		RunE: func(_ *cobra.Command, args []string) error {
 			opts := cmd.opts
-			targets, err := model.ParseTargets(args)
+			targets, err := model.ParseTargetsWithFile(args, opts.TargetsFile)
 			if err != nil {
 				return err
 			}
@@ -53,7 +63,18 @@ func (cmd *syncCommand) CobraCommand(commands cli.Commands) *cobra.Command {
 func (cmd *syncCommand) LoadDefaultOptions() error { return viper.Unmarshal(&cmd.opts) }
 
 func (cmd *syncCommand) ParseFlags(flags *pflag.FlagSet) {
-	flags.BoolVarP(&cmd.opts.DestroyInvalidRecords, "airtable-destroy-invalid-records", "", false, "Destroy invalid records")
+	flags.BoolVarP(&cmd.opts.DestroyInvalidRecords, "airtable-destroy-invalid-records", "", false, "delete the records of the base that do not match any synced issue info (unmatched records are kept and reported otherwise)")
+	flags.BoolVarP(&cmd.opts.DryRun, "airtable-dry-run", "", false, "print the changes that would be made to the base without applying them")
+	flags.BoolVarP(&cmd.opts.IncludeExternal, "airtable-include-external", "", false, "add stub records for the issues and repositories outside of the targets referenced by depends-on links")
+	flags.BoolVarP(&cmd.opts.SkipValidation, "airtable-skip-validation", "", false, "warn instead of failing when the tables or their fields do not match the expected schema")
+	flags.StringVarP(&cmd.opts.Direction, "airtable-direction", "", "push", "push the issues to the base, pull the estimates edited in the base into the database, or both")
+	flags.StringVarP(&cmd.opts.ConflictStrategy, "airtable-conflict-strategy", "", "skip", "when an estimate changed on both sides since the last sync, keep the 'airtable' or the 'depviz' one, or 'skip' it")
+	if flags.Lookup("estimate-label-prefix") == nil {
+		flags.StringVarP(&cmd.opts.EstimateLabelPrefix, "estimate-label-prefix", "", "estimate:", "prefix of the labels holding the estimates, i.e., 'estimate:3d'")
+	}
+	flags.StringVarP(&cmd.opts.TargetsFile, "targets-file", "", "", "read more targets from a file, one per line, '#' starts a comment")
+	flags.StringVarP(&cmd.opts.DedupeAccountsBy, "dedupe-accounts-by", "", "", "merge accounts sharing the same identity before syncing (login, email)")
+	cmd.opts.Parse.ParseFlags(flags)
 
 	if err := viper.BindPFlags(flags); err != nil {
 		zap.L().Warn("failed to bind viper flags", zap.Error(err))
@@ -75,8 +96,27 @@ func Sync(opts *SyncOptions) error {
 	tableNames[airtablemodel.ProviderIndex] = opts.Airtable.ProvidersTableName
 	tableNames[airtablemodel.RepositoryIndex] = opts.Airtable.RepositoriesTableName
 
-	if opts.Airtable.BaseID == "" || opts.Airtable.Token == "" {
-		return fmt.Errorf("missing token or baseid, check '-h'")
+	if err := opts.Airtable.Validate(); err != nil {
+		return err
+	}
+	if err := validateDirection(opts.Direction, opts.ConflictStrategy); err != nil {
+		return err
+	}
+	if opts.DryRun && len(opts.Targets) == 0 {
+		return fmt.Errorf("nothing to sync, no target configured")
+	}
+	if err := model.ValidateDedupeAccountsBy(opts.DedupeAccountsBy); err != nil {
+		return err
+	}
+	if err := preflight(opts.Airtable, opts.Direction != "pull" && !opts.DryRun); err != nil {
+		return err
+	}
+	unsupported, err := validateSchema(opts.Airtable)
+	if err != nil {
+		if !opts.SkipValidation {
+			return err
+		}
+		zap.L().Warn("continuing with an invalid airtable schema (--airtable-skip-validation)", zap.Error(err))
 	}
 
 	//
@@ -94,7 +134,7 @@ func Sync(opts *SyncOptions) error {
 	zap.L().Debug("fetch db entries", zap.Int("count", len(loadedIssues)))
 
 	// compute and filter issues
-	computed := compute.Compute(loadedIssues)
+	computed := compute.Compute(loadedIssues, opts.Parse)
 	computed.FilterByTargets(opts.Targets)
 	zap.L().Debug("fetch db entries", zap.Int("count", len(computed.Issues())))
 
@@ -131,7 +171,6 @@ func Sync(opts *SyncOptions) error {
 
 		// repositories
 		issueFeatures[airtablemodel.RepositoryIndex][issue.Repository.ID] = issue.Repository
-		// FIXME: find external repositories based on depends-on links
 
 		// milestones
 		if issue.Milestone != nil {
@@ -140,29 +179,41 @@ func Sync(opts *SyncOptions) error {
 
 		// issue
 		issueFeatures[airtablemodel.IssueIndex][issue.ID] = issue
-		// FIXME: find external issues based on depends-on links
 	}
 
-	if opts.Airtable.RateLimiter == 0 {
-		opts.Airtable.RateLimiter = 5
+	if opts.IncludeExternal {
+		added := addExternalFeatures(issueFeatures, &computed)
+		zap.L().Info("added external dependencies", zap.Int("issues", added))
 	}
-	client := airtable.Client{
-		APIKey:  opts.Airtable.Token,
-		BaseID:  opts.Airtable.BaseID,
-		Limiter: airtable.RateLimiter(opts.Airtable.RateLimiter),
+
+	if opts.DedupeAccountsBy != "" {
+		collapsed := dedupeAccountFeatures(issueFeatures, opts.DedupeAccountsBy)
+		zap.L().Info("deduplicated accounts", zap.String("by", opts.DedupeAccountsBy), zap.Int("collapsed", collapsed))
 	}
 
+	client := opts.Airtable.client()
+
 	// cache stores issueFeatures inserted into the airtable base.
 	cache := airtablemodel.NewDB()
 
 	// Store already existing issueFeatures into the cache.
 	for tableKind, tableName := range tableNames {
-		table := client.Table(tableName)
-		if err := cache.Tables[tableKind].Fetch(table); err != nil {
+		if err := fetchTable(opts.Airtable, tableName, cache.Tables[tableKind]); err != nil {
 			return err
 		}
 	}
 
+	issueRecords := *cache.Tables[airtablemodel.IssueIndex].Elems.(*[]airtablemodel.IssueRecord)
+	if err := syncEstimates(db, issueRecords, issueFeatures[airtablemodel.IssueIndex], opts); err != nil {
+		return errors.Wrap(err, "failed to sync the estimates")
+	}
+	if opts.Direction == "pull" {
+		return nil
+	}
+
+	// failed stores the IDs of the records that could not be written, per table
+	failed := make([][]string, len(tableNames))
+
 	// unmatched stores new issueFeatures (exist in the loaded issues but not the airtable base).
 	unmatched := airtablemodel.NewDB()
 
@@ -174,7 +225,7 @@ func Sync(opts *SyncOptions) error {
 
 		for _, dbEntry := range issueFeatures[tableKind] {
 			matched := false
-			dbRecord := dbEntry.ToRecord(cache)
+			dbRecord := withoutFields(dbEntry.ToRecord(cache), unsupported[tableKind])
 			for idx := 0; idx < cache.Tables[tableKind].Len(); idx++ {
 				t := cache.Tables[tableKind]
 				if t.GetFieldID(idx) == dbEntry.GetID() {
@@ -194,34 +245,18 @@ func Sync(opts *SyncOptions) error {
 		}
 
 		ct := cache.Tables[tableKind]
-		for i := 0; i < ut.Len(); i++ {
-			zap.L().Debug("create airtable entry", zap.String("type", tableName), zap.String("entry", ut.StringAt(i)))
-			if err := table.Create(ut.GetPtr(i)); err != nil {
-				return err
-			}
-			ut.SetState(i, airtabledb.StateNew)
-			ct.Append(ut.Get(i))
+		failed[tableKind] = createCachedRecords(opts, tableName, ut, ct, &table, func(batch []interface{}) error {
+			return createRecords(opts.Airtable, tableName, batch)
+		})
+		failed[tableKind] = append(failed[tableKind], pushCachedRecords(opts, tableName, ct, &table)...)
+	}
+
+	if !opts.DryRun {
+		if err := linkRecords(opts, client, cache, issueFeatures, tableNames, unsupported); err != nil {
+			return err
 		}
-		for i := 0; i < ct.Len(); i++ {
-			var err error
-			switch ct.GetState(i) {
-			case airtabledb.StateUnknown:
-				if opts.DestroyInvalidRecords {
-					err = table.Delete(ct.GetPtr(i))
-					zap.L().Debug("delete airtable entry", zap.String("type", tableName), zap.String("entry", ct.StringAt(i)), zap.Error(err))
-				} else {
-					zap.L().Debug("unknown airtable entry, doing nothing", zap.String("type", tableName), zap.String("entry", ct.StringAt(i)))
-				}
-			case airtabledb.StateChanged:
-				err = table.Update(ct.GetPtr(i))
-				zap.L().Debug("update airtable entry", zap.String("type", tableName), zap.String("entry", ct.StringAt(i)), zap.Error(err))
-			case airtabledb.StateUnchanged:
-				zap.L().Debug("unchanged airtable entry", zap.String("type", tableName), zap.String("entry", ct.StringAt(i)), zap.Error(err))
-				// do nothing
-			case airtabledb.StateNew:
-				zap.L().Debug("new airtable entry", zap.String("type", tableName), zap.String("entry", ct.StringAt(i)), zap.Error(err))
-				// do nothing
-			}
+		if err := savePushedEstimates(db, issueFeatures[airtablemodel.IssueIndex], failed[airtablemodel.IssueIndex]); err != nil {
+			return errors.Wrap(err, "failed to save the pushed estimates")
 		}
 	}
 
@@ -233,5 +268,135 @@ func Sync(opts *SyncOptions) error {
 		}
 	}
 
+	problems := []string{}
+	for tableKind, tableName := range tableNames {
+		if len(failed[tableKind]) > 0 {
+			problems = append(problems, fmt.Sprintf("%s: %s", tableName, strings.Join(failed[tableKind], ", ")))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("the airtable base is partially synced, failed to write:\n- %s", strings.Join(problems, "\n- "))
+	}
 	return nil
 }
+
+// printDryRun prints an operation skipped by --airtable-dry-run.
+func printDryRun(operation, tableName, id string, state airtabledb.State) {
+	fmt.Printf("dry-run: %s %q %s (%s)\n", operation, tableName, id, airtabledb.StateString[state])
+}
+
+// recordCreator is the part of an airtable table used to create the records
+// of a failed batch one by one.
+type recordCreator interface {
+	Create(recordPtr interface{}) error
+}
+
+// createCachedRecords creates the new records of ut by batches (see
+// maxBatchSize) with createBatch, the records of a failed batch are created one
+// by one. Only the created records are appended to the cached table ct, it
+// returns the IDs (depviz ones) of the records that could not be created.
+func createCachedRecords(opts *SyncOptions, tableName string, ut, ct airtabledb.Table, table recordCreator, createBatch func(batch []interface{}) error) []string {
+	failed := []string{}
+	for start := 0; start < ut.Len(); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > ut.Len() {
+			end = ut.Len()
+		}
+		batch := []interface{}{}
+		for i := start; i < end; i++ {
+			zap.L().Debug("create airtable entry", zap.String("type", tableName), zap.String("entry", ut.StringAt(i)))
+			if opts.DryRun {
+				printDryRun("create", tableName, ut.GetFieldID(i), airtabledb.StateNew)
+			}
+			batch = append(batch, ut.GetPtr(i))
+		}
+		created := make([]bool, len(batch))
+		if !opts.DryRun {
+			err := retry(opts.Airtable.MaxRetries, "create", tableName, func() error {
+				return createBatch(batch)
+			})
+			if err != nil {
+				zap.L().Warn("batch creation failed, creating the records one by one", zap.String("type", tableName), zap.Int("records", len(batch)), zap.Error(err))
+				for idx, record := range batch {
+					err := retry(opts.Airtable.MaxRetries, "create", tableName, func() error {
+						return table.Create(record)
+					})
+					if err != nil {
+						zap.L().Error("failed to create airtable entry", zap.String("type", tableName), zap.String("entry", ut.StringAt(start+idx)), zap.Error(err))
+						failed = append(failed, ut.GetFieldID(start+idx))
+						continue
+					}
+					created[idx] = true
+				}
+			} else {
+				for idx := range created {
+					created[idx] = true
+				}
+			}
+		}
+		for i := start; i < end; i++ {
+			if !opts.DryRun && !created[i-start] {
+				continue
+			}
+			ut.SetState(i, airtabledb.StateNew)
+			ct.Append(ut.Get(i))
+		}
+	}
+	return failed
+}
+
+// recordWriter is the part of an airtable table used to push the cached
+// records.
+type recordWriter interface {
+	Update(recordPtr interface{}) error
+	Delete(recordPtr interface{}) error
+}
+
+// pushCachedRecords updates the changed records of the cached table. The
+// records matching no synced issue info are only deleted with
+// --airtable-destroy-invalid-records, they are kept and reported otherwise.
+// It returns the IDs (depviz ones) of the records that could not be written.
+func pushCachedRecords(opts *SyncOptions, tableName string, ct airtabledb.Table, table recordWriter) []string {
+	failed := []string{}
+	for i := 0; i < ct.Len(); i++ {
+		switch ct.GetState(i) {
+		case airtabledb.StateUnknown:
+			if opts.DestroyInvalidRecords && opts.DryRun {
+				printDryRun("delete", tableName, ct.GetID(i), airtabledb.StateUnknown)
+			} else if opts.DestroyInvalidRecords {
+				err := retry(opts.Airtable.MaxRetries, "delete", tableName, func() error {
+					return table.Delete(ct.GetPtr(i))
+				})
+				if err != nil {
+					zap.L().Error("failed to delete airtable entry", zap.String("type", tableName), zap.String("entry", ct.StringAt(i)), zap.Error(err))
+					failed = append(failed, ct.GetFieldID(i))
+					break
+				}
+				zap.L().Debug("delete airtable entry", zap.String("type", tableName), zap.String("entry", ct.StringAt(i)))
+			} else {
+				zap.L().Warn("unknown airtable entry left untouched, use --airtable-destroy-invalid-records to delete it", zap.String("type", tableName), zap.String("entry", ct.StringAt(i)))
+			}
+		case airtabledb.StateChanged:
+			if opts.DryRun {
+				printDryRun("update", tableName, ct.GetID(i), airtabledb.StateChanged)
+				break
+			}
+			err := retry(opts.Airtable.MaxRetries, "update", tableName, func() error {
+				return table.Update(ct.GetPtr(i))
+			})
+			if err != nil {
+				zap.L().Error("failed to update airtable entry", zap.String("type", tableName), zap.String("entry", ct.StringAt(i)), zap.Error(err))
+				failed = append(failed, ct.GetFieldID(i))
+				break
+			}
+			zap.L().Debug("update airtable entry", zap.String("type", tableName), zap.String("entry", ct.StringAt(i)))
+		case airtabledb.StateUnchanged:
+			zap.L().Debug("unchanged airtable entry", zap.String("type", tableName), zap.String("entry", ct.StringAt(i)))
+			// do nothing
+		case airtabledb.StateNew:
+			zap.L().Debug("new airtable entry", zap.String("type", tableName), zap.String("entry", ct.StringAt(i)))
+			// do nothing
+		}
+	}
+	return failed
+}
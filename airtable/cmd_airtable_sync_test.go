@@ -0,0 +1,118 @@
+package airtable
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"moul.io/depviz/airtabledb"
+	"moul.io/depviz/airtablemodel"
+)
+
+type fakeRecordWriter struct {
+	updated []interface{}
+	deleted []interface{}
+	err     error // returned by every call when set
+}
+
+func (w *fakeRecordWriter) Update(recordPtr interface{}) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.updated = append(w.updated, recordPtr)
+	return nil
+}
+
+func (w *fakeRecordWriter) Delete(recordPtr interface{}) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.deleted = append(w.deleted, recordPtr)
+	return nil
+}
+
+func TestPushCachedRecordsDestroyInvalidRecords(t *testing.T) {
+	tests := []struct {
+		name                  string
+		destroyInvalidRecords bool
+		expectedDeleted       int
+	}{
+		{"kept by default", false, 0},
+		{"deleted with the flag", true, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache := airtablemodel.NewDB()
+			table := cache.Tables[airtablemodel.LabelIndex]
+			matched := airtablemodel.LabelRecord{State: airtabledb.StateUnchanged}
+			matched.ID = "recMatched"
+			extra := airtablemodel.LabelRecord{State: airtabledb.StateUnknown}
+			extra.ID = "recExtra"
+			table.Append(matched)
+			table.Append(extra)
+
+			writer := &fakeRecordWriter{}
+			opts := &SyncOptions{DestroyInvalidRecords: test.destroyInvalidRecords}
+			pushCachedRecords(opts, "Label", table, writer)
+
+			if len(writer.deleted) != test.expectedDeleted {
+				t.Fatalf("expected %d deleted record(s), got %d", test.expectedDeleted, len(writer.deleted))
+			}
+			if test.expectedDeleted > 0 {
+				if id := writer.deleted[0].(*airtablemodel.LabelRecord).ID; id != "recExtra" {
+					t.Errorf("expected the extra record to be deleted, got %q", id)
+				}
+			}
+			if len(writer.updated) != 0 {
+				t.Errorf("expected no update, got %d", len(writer.updated))
+			}
+		})
+	}
+}
+
+func TestPushCachedRecordsDryRun(t *testing.T) {
+	cache := airtablemodel.NewDB()
+	table := cache.Tables[airtablemodel.LabelIndex]
+	extra := airtablemodel.LabelRecord{State: airtabledb.StateUnknown}
+	extra.ID = "recExtra"
+	table.Append(extra)
+
+	writer := &fakeRecordWriter{}
+	opts := &SyncOptions{DestroyInvalidRecords: true, DryRun: true}
+	pushCachedRecords(opts, "Label", table, writer)
+
+	if len(writer.deleted) != 0 {
+		t.Errorf("expected no deletion in dry-run mode, got %d", len(writer.deleted))
+	}
+}
+
+func TestPushCachedRecordsFailures(t *testing.T) {
+	cache := airtablemodel.NewDB()
+	table := cache.Tables[airtablemodel.LabelIndex]
+	changed := airtablemodel.LabelRecord{State: airtabledb.StateChanged}
+	changed.ID = "recChanged"
+	changed.Fields.ID = "https://github.com/moul/depviz/labels/bug"
+	unchanged := airtablemodel.LabelRecord{State: airtabledb.StateUnchanged}
+	unchanged.ID = "recUnchanged"
+	unchanged.Fields.ID = "https://github.com/moul/depviz/labels/feature"
+	extra := airtablemodel.LabelRecord{State: airtabledb.StateUnknown}
+	extra.ID = "recExtra"
+	extra.Fields.ID = "https://github.com/moul/depviz/labels/old"
+	table.Append(changed)
+	table.Append(unchanged)
+	table.Append(extra)
+
+	writer := &fakeRecordWriter{err: fmt.Errorf("422 Unprocessable Entity")} // not retried
+	opts := &SyncOptions{DestroyInvalidRecords: true}
+	failed := pushCachedRecords(opts, "Label", table, writer)
+
+	expected := []string{"https://github.com/moul/depviz/labels/bug", "https://github.com/moul/depviz/labels/old"}
+	if !reflect.DeepEqual(failed, expected) {
+		t.Errorf("expected the failed records %v, got %v", expected, failed)
+	}
+
+	writer.err = nil
+	if failed := pushCachedRecords(opts, "Label", table, writer); len(failed) != 0 {
+		t.Errorf("expected no failure, got %v", failed)
+	}
+}
@@ -0,0 +1,48 @@
+package airtable
+
+import (
+	"moul.io/depviz/airtablemodel"
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+)
+
+// dedupeAccountFeatures merges the account features sharing the same identity
+// and rewrites the references held by the other features so they point to the
+// canonical accounts. It returns the number of collapsed accounts.
+func dedupeAccountFeatures(features []map[string]model.Feature, by string) int {
+	accounts := []*model.Account{}
+	for _, feature := range features[airtablemodel.AccountIndex] {
+		accounts = append(accounts, feature.(*model.Account))
+	}
+	canonicals, collapsed := model.DedupeAccounts(accounts, by)
+	canonical := func(account *model.Account) *model.Account {
+		if account == nil {
+			return nil
+		}
+		if merged, found := canonicals[account.ID]; found {
+			return merged
+		}
+		return account
+	}
+
+	features[airtablemodel.AccountIndex] = make(map[string]model.Feature)
+	for _, account := range canonicals {
+		features[airtablemodel.AccountIndex][account.ID] = account
+	}
+	for _, feature := range features[airtablemodel.RepositoryIndex] {
+		repo := feature.(*model.Repository)
+		repo.Owner = canonical(repo.Owner)
+	}
+	for _, feature := range features[airtablemodel.MilestoneIndex] {
+		milestone := feature.(*model.Milestone)
+		milestone.Creator = canonical(milestone.Creator)
+	}
+	for _, feature := range features[airtablemodel.IssueIndex] {
+		issue := feature.(*compute.ComputedIssue)
+		issue.Author = canonical(issue.Author)
+		for idx, assignee := range issue.Assignees {
+			issue.Assignees[idx] = canonical(assignee)
+		}
+	}
+	return collapsed
+}
@@ -0,0 +1,131 @@
+package airtable
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"go.uber.org/zap"
+	"moul.io/depviz/airtablemodel"
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+)
+
+func validateDirection(direction, strategy string) error {
+	switch direction {
+	case "push", "pull", "both":
+	default:
+		return fmt.Errorf("invalid --airtable-direction value: %q (supported: push, pull, both)", direction)
+	}
+	switch strategy {
+	case "airtable", "depviz", "skip":
+	default:
+		return fmt.Errorf("invalid --airtable-conflict-strategy value: %q (supported: airtable, depviz, skip)", strategy)
+	}
+	return nil
+}
+
+// localEstimate is the estimate of an issue on the depviz side: the one set by
+// a previous sync, or the one of its estimate label.
+func localEstimate(issue *compute.ComputedIssue, labelPrefix string) string {
+	if issue.Estimate != "" {
+		return issue.Estimate
+	}
+	if labelPrefix == "" {
+		return ""
+	}
+	for _, label := range issue.Labels {
+		if strings.HasPrefix(strings.ToLower(label.Name), strings.ToLower(labelPrefix)) {
+			return strings.TrimSpace(label.Name[len(labelPrefix):])
+		}
+	}
+	return ""
+}
+
+// syncEstimates reads the estimates of the Airtable issues table when pulling,
+// stores the ones changed in Airtable since the last sync in the database, and
+// sets the estimates to push on the issues.
+//
+// When an estimate changed on both sides since the last sync, the conflict is
+// resolved with --airtable-conflict-strategy: "airtable" and "depviz" keep the
+// value of this side, "skip" leaves both sides unchanged.
+func syncEstimates(db *gorm.DB, records []airtablemodel.IssueRecord, features map[string]model.Feature, opts *SyncOptions) error {
+	remotes := map[string]string{}
+	if opts.Direction != "push" {
+		for _, record := range records {
+			remotes[record.Fields.ID] = strings.TrimSpace(record.Fields.Estimate)
+		}
+	}
+
+	pulled, conflicts := 0, 0
+	for id, feature := range features {
+		issue, ok := feature.(*compute.ComputedIssue)
+		if !ok {
+			continue
+		}
+		local := localEstimate(issue, opts.EstimateLabelPrefix)
+		remote, found := remotes[id]
+		if !found || remote == issue.AirtableEstimate || remote == local { // not changed in Airtable
+			issue.Estimate = local
+			continue
+		}
+		if local != issue.AirtableEstimate { // changed on both sides
+			conflicts++
+			zap.L().Warn("conflicting estimates",
+				zap.String("issue", id),
+				zap.String("depviz", local),
+				zap.String("airtable", remote),
+				zap.String("last-sync", issue.AirtableEstimate),
+				zap.String("strategy", opts.ConflictStrategy),
+			)
+			switch opts.ConflictStrategy {
+			case "depviz":
+				issue.Estimate = local
+				continue
+			case "skip":
+				issue.Estimate = remote // pushed as is
+				issue.AirtableEstimate = remote
+				continue
+			}
+		}
+
+		pulled++
+		issue.Estimate = remote
+		issue.AirtableEstimate = remote
+		if opts.DryRun {
+			fmt.Printf("dry-run: pull estimate %s %q (was %q)\n", id, remote, local)
+			continue
+		}
+		err := db.Model(&model.Issue{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"estimate":          remote,
+			"airtable_estimate": remote,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if opts.Direction != "push" {
+		zap.L().Info("pulled airtable estimates", zap.Int("updated", pulled), zap.Int("conflicts", conflicts))
+	}
+	return nil
+}
+
+// savePushedEstimates records the estimates pushed to Airtable, so the next
+// sync detects the changes made there. The issues whose record could not be
+// written are skipped.
+func savePushedEstimates(db *gorm.DB, features map[string]model.Feature, failed []string) error {
+	skipped := map[string]bool{}
+	for _, id := range failed {
+		skipped[id] = true
+	}
+	for id, feature := range features {
+		issue, ok := feature.(*compute.ComputedIssue)
+		if !ok || issue.Estimate == issue.AirtableEstimate || skipped[id] {
+			continue
+		}
+		if err := db.Model(&model.Issue{}).Where("id = ?", id).Update("airtable_estimate", issue.Estimate).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package airtable
+
+import (
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3" // required by gorm
+	"moul.io/depviz/airtablemodel"
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+	"moul.io/depviz/sql"
+)
+
+func TestSyncEstimates(t *testing.T) {
+	const id = "https://github.com/moul/depviz/issues/1"
+	tests := []struct {
+		name      string
+		direction string
+		strategy  string
+		local     string // in database
+		remote    string // in Airtable
+		// the estimate at the last sync is "1d"
+
+		expectedPushed   string
+		expectedEstimate string // in database, after the sync
+		expectedLastSync string
+	}{
+		{"unchanged", "both", "skip", "1d", "1d", "1d", "1d", "1d"},
+		{"only airtable changed", "both", "skip", "1d", "2d", "2d", "2d", "2d"},
+		{"only depviz changed", "both", "skip", "3d", "1d", "3d", "3d", "3d"},
+		{"both changed, keep airtable", "both", "airtable", "3d", "2d", "2d", "2d", "2d"},
+		{"both changed, keep depviz", "both", "depviz", "3d", "2d", "3d", "3d", "3d"},
+		{"both changed, skip", "both", "skip", "3d", "2d", "2d", "3d", "1d"},
+		{"push ignores airtable", "push", "skip", "1d", "2d", "1d", "1d", "1d"},
+		{"pull only airtable changed", "pull", "skip", "1d", "2d", "", "2d", "2d"},
+		{"pull only depviz changed", "pull", "skip", "3d", "1d", "", "3d", "1d"},
+		{"pull both changed, skip", "pull", "skip", "3d", "2d", "", "3d", "1d"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := sql.FromOpts(&sql.Options{Driver: "sqlite", DSN: ":memory:"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			issue := model.Issue{
+				Base:             model.Base{ID: id, URL: id},
+				Title:            "Issue",
+				State:            "open",
+				Estimate:         test.local,
+				AirtableEstimate: "1d",
+			}
+			if err := db.Create(&issue).Error; err != nil {
+				t.Fatal(err)
+			}
+			computed := &compute.ComputedIssue{Issue: issue}
+			features := map[string]model.Feature{id: computed}
+			record := airtablemodel.IssueRecord{}
+			record.Fields.ID = id
+			record.Fields.Estimate = test.remote
+			opts := &SyncOptions{Direction: test.direction, ConflictStrategy: test.strategy, EstimateLabelPrefix: "estimate:"}
+
+			// same sequence as Sync: the estimates are pulled before pushing
+			// the issues, the pushed ones are saved afterwards
+			if err := syncEstimates(db, []airtablemodel.IssueRecord{record}, features, opts); err != nil {
+				t.Fatal(err)
+			}
+			if test.direction != "pull" {
+				if computed.Estimate != test.expectedPushed {
+					t.Errorf("expected to push %q, got %q", test.expectedPushed, computed.Estimate)
+				}
+				if err := savePushedEstimates(db, features, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var saved model.Issue
+			if err := db.Where("id = ?", id).First(&saved).Error; err != nil {
+				t.Fatal(err)
+			}
+			if saved.Estimate != test.expectedEstimate {
+				t.Errorf("expected the estimate %q, got %q", test.expectedEstimate, saved.Estimate)
+			}
+			if saved.AirtableEstimate != test.expectedLastSync {
+				t.Errorf("expected the last synced estimate %q, got %q", test.expectedLastSync, saved.AirtableEstimate)
+			}
+			if saved.Title != "Issue" || saved.State != "open" {
+				t.Errorf("expected the other columns to be unchanged, got %q and %q", saved.Title, saved.State)
+			}
+		})
+	}
+}
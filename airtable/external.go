@@ -0,0 +1,67 @@
+package airtable
+
+import (
+	"go.uber.org/zap"
+	"moul.io/depviz/airtablemodel"
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+	"moul.io/multipmuri"
+)
+
+// addExternalFeatures adds a stub record for each issue (and its repository)
+// referenced by the depends-on links of the synced issues but outside of the
+// targets, so the links do not dangle. The stubs only hold the URL, and the
+// title and state when the issue is in the database.
+func addExternalFeatures(features []map[string]model.Feature, computed *compute.Computed) int {
+	known := map[string]*compute.ComputedIssue{}
+	for _, issue := range computed.AllIssues {
+		known[issue.URL] = issue
+	}
+
+	added := 0
+	for _, issue := range computed.Issues() {
+		for _, url := range issue.DependsOn {
+			if _, found := features[airtablemodel.IssueIndex][url]; found {
+				continue
+			}
+			entity, err := model.ParseTarget(url)
+			if err != nil {
+				zap.L().Debug("invalid external dependency", zap.String("url", url), zap.Error(err))
+				continue
+			}
+			service := multipmuri.ServiceEntity(entity).String()
+			repo := multipmuri.RepoEntity(entity).String()
+
+			provider, found := features[airtablemodel.ProviderIndex][service].(*model.Provider)
+			if !found {
+				provider = &model.Provider{Base: model.Base{ID: service, URL: service}}
+				features[airtablemodel.ProviderIndex][service] = provider
+			}
+			repository, found := features[airtablemodel.RepositoryIndex][repo].(*model.Repository)
+			if !found {
+				repository = &model.Repository{
+					Base:       model.Base{ID: repo, URL: repo},
+					Provider:   provider,
+					ProviderID: provider.ID,
+				}
+				features[airtablemodel.RepositoryIndex][repo] = repository
+			}
+
+			stub := &compute.ComputedIssue{
+				Issue: model.Issue{
+					Base:         model.Base{ID: url, URL: url},
+					Repository:   repository,
+					RepositoryID: repository.ID,
+				},
+			}
+			if issue, found := known[url]; found {
+				stub.Title = issue.Title
+				stub.State = issue.State
+				stub.IsPR = issue.IsPR
+			}
+			features[airtablemodel.IssueIndex][url] = stub
+			added++
+		}
+	}
+	return added
+}
@@ -0,0 +1,44 @@
+package airtable
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"moul.io/depviz/airtabledb"
+)
+
+// maxPageSize is the maximum number of records per page returned by the
+// Airtable API.
+const maxPageSize = 100
+
+// fetchTable retrieves every record of a table into the cache, page by page
+// (see --airtable-page-size), the pages are retried independently.
+func fetchTable(opts Options, tableName string, table airtabledb.Table) error {
+	return table.FetchPages(tableName, func(offset string) (records []json.RawMessage, next string, err error) {
+		err = retry(opts.MaxRetries, "fetch", tableName, func() error {
+			var err error
+			records, next, err = fetchPage(opts, tableName, offset)
+			return err
+		})
+		return records, next, err
+	})
+}
+
+func fetchPage(opts Options, tableName, offset string) ([]json.RawMessage, string, error) {
+	query := url.Values{}
+	query.Set("pageSize", strconv.Itoa(opts.PageSize))
+	if offset != "" {
+		query.Set("offset", offset)
+	}
+	var out struct {
+		Records []json.RawMessage `json:"records"`
+		Offset  string            `json:"offset"`
+	}
+	path := fmt.Sprintf("/%s/%s?%s", opts.BaseID, url.PathEscape(tableName), query.Encode())
+	if err := airtableDo(opts, "GET", path, nil, &out); err != nil {
+		return nil, "", err
+	}
+	return out.Records, out.Offset, nil
+}
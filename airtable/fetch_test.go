@@ -0,0 +1,63 @@
+package airtable
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"moul.io/depviz/airtabledb"
+	"moul.io/depviz/airtablemodel"
+)
+
+func TestFetchTableRetriedPage(t *testing.T) {
+	defer func(delay time.Duration) { retryDelay = delay }(retryDelay)
+	retryDelay = time.Millisecond
+
+	// 3 pages of 2 records, the second page is rate-limited once
+	offsets := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/appBase/Issues" || r.URL.Query().Get("pageSize") != "2" {
+			http.NotFound(w, r)
+			return
+		}
+		offset := r.URL.Query().Get("offset")
+		offsets = append(offsets, offset)
+		if offset == "itr2" && len(offsets) == 2 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		page := map[string]int{"": 1, "itr2": 2, "itr3": 3}[offset]
+		out := map[string]interface{}{"records": []map[string]string{
+			{"id": fmt.Sprintf("rec%d", page*2-1)},
+			{"id": fmt.Sprintf("rec%d", page*2)},
+		}}
+		if page < 3 {
+			out["offset"] = fmt.Sprintf("itr%d", page+1)
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+	defer server.Close()
+	original := airtableAPIURL
+	defer func() { airtableAPIURL = original }()
+	airtableAPIURL = server.URL
+
+	opts := Options{Token: "TOKEN", BaseID: "appBase", MaxRetries: 3, PageSize: 2, RateLimit: 100}
+	records := []airtablemodel.IssueRecord{}
+	if err := fetchTable(opts, "Issues", airtabledb.Table{Elems: &records}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"", "itr2", "itr2", "itr3"}; !reflect.DeepEqual(offsets, expected) {
+		t.Errorf("expected the offsets %v, got %v", expected, offsets)
+	}
+	ids := []string{}
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	if expected := []string{"rec1", "rec2", "rec3", "rec4", "rec5", "rec6"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected every record once, got %v", ids)
+	}
+}
@@ -0,0 +1,43 @@
+package airtable
+
+import (
+	"github.com/brianloveswords/airtable"
+	"go.uber.org/zap"
+	"moul.io/depviz/airtabledb"
+	"moul.io/depviz/model"
+)
+
+// linkRecords is the second pass of the sync: once every record is created and
+// has an Airtable ID, the linked-record fields skipped by the first pass (i.e.,
+// links to records created later) are populated.
+func linkRecords(opts *SyncOptions, client airtable.Client, cache airtabledb.DB, features []map[string]model.Feature, tableNames []string, unsupported []map[string]bool) error {
+	for tableKind, tableName := range tableNames {
+		ct := cache.Tables[tableKind]
+		table := client.Table(tableName)
+		linked := 0
+		for _, feature := range features[tableKind] {
+			record := withoutFields(feature.ToRecord(cache), unsupported[tableKind])
+			for idx := 0; idx < ct.Len(); idx++ {
+				if ct.GetFieldID(idx) != feature.GetID() {
+					continue
+				}
+				if ct.RecordsEqual(idx, record) {
+					break
+				}
+				ct.CopyFields(idx, record)
+				err := retry(opts.Airtable.MaxRetries, "update", tableName, func() error {
+					return table.Update(ct.GetPtr(idx))
+				})
+				if err != nil {
+					return err
+				}
+				linked++
+				break
+			}
+		}
+		if linked > 0 {
+			zap.L().Debug("linked airtable entries", zap.String("type", tableName), zap.Int("records", linked))
+		}
+	}
+	return nil
+}
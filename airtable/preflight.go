@@ -0,0 +1,134 @@
+package airtable
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var airtableAPIURL = "https://api.airtable.com/v0" // replaced in the tests
+
+// requiredScopes returns the token scopes needed by depviz, the write one only
+// for the commands mutating the base.
+func requiredScopes(write bool) []string {
+	scopes := []string{"data.records:read", "schema.bases:read"}
+	if write {
+		scopes = append(scopes, "data.records:write")
+	}
+	return scopes
+}
+
+// missingScopes returns the required scopes not granted, sorted.
+func missingScopes(granted []string, write bool) []string {
+	set := map[string]bool{}
+	for _, scope := range granted {
+		set[scope] = true
+	}
+	missing := []string{}
+	for _, scope := range requiredScopes(write) {
+		if !set[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// preflight checks that the token is valid, has the required scopes and can
+// access the base, before any record is mutated.
+//
+// /meta/whoami only returns the scopes of the OAuth tokens: the personal access
+// tokens (pat...) and the legacy API keys (key...) are checked with a probe
+// request reading a record instead, their write access is only checked by the
+// first write.
+func preflight(opts Options, write bool) error {
+	var whoami struct {
+		ID     string   `json:"id"`
+		Scopes []string `json:"scopes"` // nil when not returned
+	}
+	if err := airtableMetaGet(opts, "/meta/whoami", &whoami); err != nil {
+		return fmt.Errorf("invalid airtable token: %v", err)
+	}
+	if strings.HasPrefix(opts.Token, "key") {
+		zap.L().Warn("airtable API keys are deprecated, use a personal access token instead")
+	}
+	if whoami.Scopes != nil {
+		if missing := missingScopes(whoami.Scopes, write); len(missing) > 0 {
+			return fmt.Errorf("airtable token is missing the following scopes: %s", strings.Join(missing, ", "))
+		}
+	}
+
+	tables, err := fetchBaseTables(opts)
+	if err != nil {
+		return fmt.Errorf("cannot access airtable base %q with this token: %v", opts.BaseID, err)
+	}
+	if whoami.Scopes == nil {
+		if err := probeRecords(opts); err != nil {
+			return fmt.Errorf("cannot read the records of airtable base %q with this token: %v", opts.BaseID, err)
+		}
+	}
+	zap.L().Debug("airtable preflight", zap.String("user", whoami.ID), zap.Strings("scopes", whoami.Scopes), zap.Int("tables", len(tables)))
+	return nil
+}
+
+// probeRecords reads a record of the first table.
+func probeRecords(opts Options) error {
+	var out struct {
+		Records []json.RawMessage `json:"records"`
+	}
+	table := opts.tableNames()[0]
+	return airtableMetaGet(opts, fmt.Sprintf("/%s/%s?maxRecords=1", opts.BaseID, url.PathEscape(table)), &out)
+}
+
+func airtableMetaGet(opts Options, path string, out interface{}) error {
+	return airtableDo(opts, "GET", path, nil, out)
+}
+
+// airtableDo calls the Airtable API without the client, i.e., for the batches
+// and the metadata, throttled by the limiter of the client.
+func airtableDo(opts Options, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, airtableAPIURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+opts.Token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	opts.limiter().Wait()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Error.Message != "" {
+			return fmt.Errorf("%s: %s (%s)", resp.Status, apiErr.Error.Message, apiErr.Error.Type)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,48 @@
+package airtable
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// statusCodeRegex extracts the HTTP status code from the airtable client
+// errors (i.e., "429 Too Many Requests").
+var statusCodeRegex = regexp.MustCompile(`\b([45]\d\d)\b`)
+
+// isRetryable returns false for the client errors (4xx) other than 429, the
+// rate-limit errors, the server errors and the network errors are retried.
+func isRetryable(err error) bool {
+	match := statusCodeRegex.FindStringSubmatch(err.Error())
+	if match == nil {
+		return true
+	}
+	code, _ := strconv.Atoi(match[1])
+	return code == 429 || code >= 500
+}
+
+// retryDelay is the delay before the first retry, doubled at each attempt.
+var retryDelay = time.Second
+
+// retry calls fn until it succeeds, returns a non-retryable error or fails
+// maxRetries+1 times, waiting 1s, 2s, 4s, ... between the attempts.
+func retry(maxRetries int, operation, tableName string, fn func() error) error {
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt > maxRetries || !isRetryable(err) {
+			return err
+		}
+		zap.L().Warn("airtable call failed, retrying",
+			zap.String("operation", operation),
+			zap.String("table", tableName),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,81 @@
+package airtable
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// failingTable is a fake airtable table whose calls fail n times before
+// succeeding.
+type failingTable struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (t *failingTable) Update(recordPtr interface{}) error {
+	t.calls++
+	if t.calls <= t.failures {
+		return t.err
+	}
+	return nil
+}
+
+func TestRetry(t *testing.T) {
+	defer func(delay time.Duration) { retryDelay = delay }(retryDelay)
+	retryDelay = time.Millisecond
+
+	tests := []struct {
+		name          string
+		failures      int
+		err           error
+		maxRetries    int
+		expectedCalls int
+		expectedErr   bool
+	}{
+		{"success", 0, nil, 3, 1, false},
+		{"rate-limited then success", 2, errors.New("429 Too Many Requests"), 3, 3, false},
+		{"server error then success", 1, errors.New("503 Service Unavailable"), 3, 2, false},
+		{"network error then success", 1, errors.New("connection reset by peer"), 3, 2, false},
+		{"too many failures", 5, errors.New("429 Too Many Requests"), 3, 4, true},
+		{"no retry", 1, errors.New("429 Too Many Requests"), 0, 1, true},
+		{"non-retryable error", 5, errors.New("422 Unprocessable Entity"), 3, 1, true},
+		{"unauthorized", 5, errors.New("401 Unauthorized"), 3, 1, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			table := &failingTable{failures: test.failures, err: test.err}
+			err := retry(test.maxRetries, "update", "Issues", func() error {
+				return table.Update(nil)
+			})
+			if (err != nil) != test.expectedErr {
+				t.Errorf("expected error: %v, got %v", test.expectedErr, err)
+			}
+			if table.calls != test.expectedCalls {
+				t.Errorf("expected %d call(s), got %d", test.expectedCalls, table.calls)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err      string
+		expected bool
+	}{
+		{"429 Too Many Requests", true},
+		{"500 Internal Server Error", true},
+		{"502 Bad Gateway", true},
+		{"dial tcp: i/o timeout", true},
+		{"400 Bad Request", false},
+		{"403 Forbidden", false},
+		{"404 Not Found", false},
+		{"422 Unprocessable Entity", false},
+	}
+	for _, test := range tests {
+		if got := isRetryable(errors.New(test.err)); got != test.expected {
+			t.Errorf("isRetryable(%q) = %v, expected %v", test.err, got, test.expected)
+		}
+	}
+}
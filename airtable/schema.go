@@ -0,0 +1,142 @@
+package airtable
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"moul.io/depviz/airtabledb"
+	"moul.io/depviz/airtablemodel"
+)
+
+// baseTable is the metadata of a table of the base.
+type baseTable struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Fields []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"fields"`
+}
+
+func fetchBaseTables(opts Options) ([]baseTable, error) {
+	var out struct {
+		Tables []baseTable `json:"tables"`
+	}
+	if err := airtableMetaGet(opts, fmt.Sprintf("/meta/bases/%s/tables", opts.BaseID), &out); err != nil {
+		return nil, err
+	}
+	return out.Tables, nil
+}
+
+// recordFields returns the fields written by ToRecord for a table, either the
+// required ones or the optional ones (omitempty).
+func recordFields(tableKind int, optional bool) []string {
+	elems := airtablemodel.NewDB().Tables[tableKind].Elems
+	fields, ok := reflect.TypeOf(elems).Elem().Elem().FieldByName("Fields")
+	if !ok {
+		panic("No struct field Fields in Record")
+	}
+	return jsonFieldNames(fields.Type, optional)
+}
+
+func jsonFieldNames(t reflect.Type, optional bool) []string {
+	names := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct { // airtabledb.Base
+			names = append(names, jsonFieldNames(field.Type, optional)...)
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		if tag[0] == "" || tag[0] == "-" || (len(tag) > 1 && tag[1] == "omitempty") != optional {
+			continue
+		}
+		names = append(names, tag[0])
+	}
+	return names
+}
+
+// withoutFields returns a copy of the record with the given fields cleared, the
+// optional fields being omitted when empty, they are not sent to the base.
+func withoutFields(record airtabledb.Record, names map[string]bool) airtabledb.Record {
+	if len(names) == 0 {
+		return record
+	}
+	copied := reflect.New(reflect.TypeOf(record)).Elem()
+	copied.Set(reflect.ValueOf(record))
+	clearFields(copied.FieldByName("Fields"), names)
+	return copied.Interface().(airtabledb.Record)
+}
+
+func clearFields(v reflect.Value, names map[string]bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct { // airtabledb.Base
+			clearFields(v.Field(i), names)
+			continue
+		}
+		if names[strings.Split(field.Tag.Get("json"), ",")[0]] {
+			v.Field(i).Set(reflect.Zero(field.Type))
+		}
+	}
+}
+
+// validateSchema checks that the tables exist (by name or ID) and have the
+// fields written by the sync, the error lists every problem. It also returns
+// the optional fields missing from each table, which are not synced.
+func validateSchema(opts Options) ([]map[string]bool, error) {
+	unsupported := make([]map[string]bool, airtablemodel.NumTables)
+	tables, err := fetchBaseTables(opts)
+	if err != nil {
+		return unsupported, fmt.Errorf("cannot fetch the airtable base schema: %v", err)
+	}
+	flags := make([]string, airtablemodel.NumTables)
+	flags[airtablemodel.AccountIndex] = "airtable-accounts-table-name"
+	flags[airtablemodel.IssueIndex] = "airtable-issues-table-name"
+	flags[airtablemodel.LabelIndex] = "airtable-labels-table-name"
+	flags[airtablemodel.MilestoneIndex] = "airtable-milestones-table-name"
+	flags[airtablemodel.ProviderIndex] = "airtable-providers-table-name"
+	flags[airtablemodel.RepositoryIndex] = "airtable-repositories-table-name"
+
+	problems := []string{}
+	for tableKind, tableName := range opts.tableNames() {
+		var table *baseTable
+		for idx := range tables {
+			if tables[idx].Name == tableName || tables[idx].ID == tableName {
+				table = &tables[idx]
+				break
+			}
+		}
+		if table == nil {
+			problems = append(problems, fmt.Sprintf("table %q (--%s) not found", tableName, flags[tableKind]))
+			continue
+		}
+		existing := map[string]bool{}
+		for _, field := range table.Fields {
+			existing[field.Name] = true
+		}
+		missing := []string{}
+		for _, name := range recordFields(tableKind, false) {
+			if !existing[name] {
+				missing = append(missing, name)
+			}
+		}
+		unsupported[tableKind] = map[string]bool{}
+		for _, name := range recordFields(tableKind, true) {
+			if !existing[name] {
+				unsupported[tableKind][name] = true
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			problems = append(problems, fmt.Sprintf("table %q: missing fields: %s", tableName, strings.Join(missing, ", ")))
+		}
+	}
+	if len(problems) > 0 {
+		return unsupported, fmt.Errorf("the airtable base does not match the expected schema:\n- %s", strings.Join(problems, "\n- "))
+	}
+	return unsupported, nil
+}
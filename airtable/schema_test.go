@@ -0,0 +1,173 @@
+package airtable
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"moul.io/depviz/airtablemodel"
+	"moul.io/depviz/model"
+)
+
+func testAccount(avatarURL, htmlURL string) model.Account {
+	return model.Account{
+		Base:      model.Base{ID: "https://github.com/moul", URL: "https://api.github.com/users/moul"},
+		Login:     "moul",
+		AvatarURL: avatarURL,
+		HTMLURL:   htmlURL,
+	}
+}
+
+func TestAccountRecordFields(t *testing.T) {
+	account := testAccount("https://avatars.githubusercontent.com/u/94029", "https://github.com/moul")
+	record := account.ToRecord(airtablemodel.NewDB()).(airtablemodel.AccountRecord)
+	if record.Fields.AvatarURL != account.AvatarURL {
+		t.Errorf("expected the avatar URL %q, got %q", account.AvatarURL, record.Fields.AvatarURL)
+	}
+	if record.Fields.HTMLURL != account.HTMLURL {
+		t.Errorf("expected the profile URL %q, got %q", account.HTMLURL, record.Fields.HTMLURL)
+	}
+	for _, field := range []string{`"avatar-url":"https://avatars.githubusercontent.com/u/94029"`, `"html-url":"https://github.com/moul"`} {
+		if !strings.Contains(record.String(), field) {
+			t.Errorf("expected %s in the record, got %s", field, record.String())
+		}
+	}
+}
+
+func TestAccountRecordUpdatedInPlace(t *testing.T) {
+	cache := airtablemodel.NewDB()
+	table := cache.Tables[airtablemodel.AccountIndex]
+	stored := testAccount("https://avatars.githubusercontent.com/u/1", "")
+	table.Append(stored.ToRecord(cache))
+
+	updated := testAccount("https://avatars.githubusercontent.com/u/94029", "https://github.com/moul").ToRecord(cache)
+	if table.RecordsEqual(0, updated) {
+		t.Fatal("expected the new avatar and profile URLs to be detected")
+	}
+	table.CopyFields(0, updated)
+	if !table.RecordsEqual(0, updated) {
+		t.Error("expected the record to be updated")
+	}
+	if table.Len() != 1 || table.GetFieldID(0) != "https://github.com/moul" {
+		t.Errorf("expected the record to be updated in place, got %d record(s)", table.Len())
+	}
+}
+
+func TestAccountRecordWithoutHTMLURLField(t *testing.T) {
+	optional := strings.Join(recordFields(airtablemodel.AccountIndex, true), ",")
+	required := strings.Join(recordFields(airtablemodel.AccountIndex, false), ",")
+	if !strings.Contains(optional, "html-url") || strings.Contains(required, "html-url") {
+		t.Fatalf("expected html-url to be optional, got required=%s optional=%s", required, optional)
+	}
+	if !strings.Contains(required, "avatar-url") {
+		t.Errorf("expected avatar-url to be required, got %s", required)
+	}
+
+	// a base without the html-url column keeps syncing the other fields
+	record := testAccount("https://avatars.githubusercontent.com/u/94029", "https://github.com/moul").ToRecord(airtablemodel.NewDB())
+	cleared := withoutFields(record, map[string]bool{"html-url": true}).(airtablemodel.AccountRecord)
+	if cleared.Fields.HTMLURL != "" || strings.Contains(cleared.String(), "html-url") {
+		t.Errorf("expected html-url not to be sent, got %s", cleared.String())
+	}
+	if cleared.Fields.AvatarURL == "" {
+		t.Error("expected the avatar URL to be kept")
+	}
+	if record.(airtablemodel.AccountRecord).Fields.HTMLURL == "" {
+		t.Error("expected the original record to be unchanged")
+	}
+}
+
+func TestValidateSchemaOptionalFields(t *testing.T) {
+	opts := Options{
+		Token:                 "TOKEN",
+		BaseID:                "appBase",
+		AccountsTableName:     "Accounts",
+		IssuesTableName:       "Issues",
+		LabelsTableName:       "Labels",
+		MilestonesTableName:   "Milestones",
+		ProvidersTableName:    "Providers",
+		RepositoriesTableName: "Repositories",
+	}
+	// every field exists, except html-url in the accounts table
+	tables := []baseTable{}
+	for tableKind, tableName := range opts.tableNames() {
+		table := baseTable{ID: "tbl" + tableName, Name: tableName}
+		names := append(recordFields(tableKind, false), recordFields(tableKind, true)...)
+		for _, name := range names {
+			if tableKind == airtablemodel.AccountIndex && name == "html-url" {
+				continue
+			}
+			table.Fields = append(table.Fields, struct {
+				Name string `json:"name"`
+				Type string `json:"type"`
+			}{Name: name})
+		}
+		tables = append(tables, table)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/meta/bases/appBase/tables" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"tables": tables})
+	}))
+	defer server.Close()
+	original := airtableAPIURL
+	defer func() { airtableAPIURL = original }()
+	airtableAPIURL = server.URL
+
+	unsupported, err := validateSchema(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !unsupported[airtablemodel.AccountIndex]["html-url"] {
+		t.Errorf("expected html-url to be skipped, got %v", unsupported[airtablemodel.AccountIndex])
+	}
+	for tableKind, fields := range unsupported {
+		if tableKind != airtablemodel.AccountIndex && len(fields) > 0 {
+			t.Errorf("table %d: expected every field to be synced, got %v skipped", tableKind, fields)
+		}
+	}
+}
+
+func TestOptionalColumns(t *testing.T) {
+	// the columns added after the initial schema are optional, so the
+	// existing bases keep passing the schema validation
+	tests := []struct {
+		tableKind int
+		field     string
+	}{
+		{airtablemodel.AccountIndex, "html-url"},
+		{airtablemodel.AccountIndex, "aliases"},
+		{airtablemodel.IssueIndex, "estimate"},
+	}
+	for _, test := range tests {
+		t.Run(test.field, func(t *testing.T) {
+			for _, name := range recordFields(test.tableKind, false) {
+				if name == test.field {
+					t.Fatalf("expected %q to be optional", test.field)
+				}
+			}
+			found := false
+			for _, name := range recordFields(test.tableKind, true) {
+				found = found || name == test.field
+			}
+			if !found {
+				t.Errorf("expected %q to be an optional field", test.field)
+			}
+		})
+	}
+}
+
+func TestIssueRecordWithoutTasks(t *testing.T) {
+	// the checklist progress is only rendered by the graph, it is not synced
+	for _, optional := range []bool{false, true} {
+		for _, name := range recordFields(airtablemodel.IssueIndex, optional) {
+			if name == "tasks-done" || name == "tasks-total" {
+				t.Errorf("unexpected %q column", name)
+			}
+		}
+	}
+}
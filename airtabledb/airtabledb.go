@@ -1,11 +1,12 @@
 package airtabledb // import "moul.io/depviz/airtabledb"
 
 import (
+	"encoding/json"
 	"reflect"
 	"sort"
 	"time"
 
-	"github.com/brianloveswords/airtable"
+	"go.uber.org/zap"
 )
 
 type Record interface {
@@ -105,9 +106,39 @@ func (t Table) Append(record interface{}) {
 	reflect.ValueOf(t.Elems).Elem().Set(a)
 }
 
-// Fetch retrieves the airtable table records from at over the network and inserts the records into the table.
-func (t Table) Fetch(at airtable.Table) error {
-	return at.List(t.Elems, &airtable.Options{})
+// PageFetcher returns the records of the page starting at offset (empty for the
+// first page) and the offset of the next page, empty for the last one.
+type PageFetcher func(offset string) (records []json.RawMessage, next string, err error)
+
+// FetchPages retrieves every page of records with fetch and replaces the
+// records of the table with them.
+func (t Table) FetchPages(tableName string, fetch PageFetcher) error {
+	slice := reflect.ValueOf(t.Elems).Elem()
+	slice.Set(reflect.MakeSlice(slice.Type(), 0, 0))
+	offset := ""
+	for page := 1; ; page++ {
+		records, next, err := fetch(offset)
+		if err != nil {
+			return err
+		}
+		for _, raw := range records {
+			record := reflect.New(slice.Type().Elem())
+			if err := json.Unmarshal(raw, record.Interface()); err != nil {
+				return err
+			}
+			t.Append(record.Elem().Interface())
+		}
+		zap.L().Debug("fetched airtable page",
+			zap.String("table", tableName),
+			zap.Int("page", page),
+			zap.Int("records", len(records)),
+			zap.Int("total", t.Len()),
+		)
+		if next == "" {
+			return nil
+		}
+		offset = next
+	}
 }
 
 // FindByID searches the table for a record with Fields.ID equal to id.
@@ -0,0 +1,89 @@
+package airtabledb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type testRecord struct {
+	ID     string `json:"id"`
+	Fields struct {
+		Base
+		Title string `json:"title"`
+	} `json:"fields"`
+}
+
+// testPages is a fake paginated Airtable table, the pages are indexed by
+// offset, the first one by the empty offset.
+type testPages struct {
+	pages    map[string][]string // record IDs
+	next     map[string]string
+	failures map[string]error
+	offsets  []string
+}
+
+func (p *testPages) fetch(offset string) ([]json.RawMessage, string, error) {
+	p.offsets = append(p.offsets, offset)
+	if err := p.failures[offset]; err != nil {
+		return nil, "", err
+	}
+	records := []json.RawMessage{}
+	for _, id := range p.pages[offset] {
+		records = append(records, json.RawMessage(fmt.Sprintf(`{"id": %q, "fields": {"id": "https://github.com/moul/depviz/issues/%s", "title": "Issue %s"}}`, id, id, id)))
+	}
+	return records, p.next[offset], nil
+}
+
+func newTestPages() *testPages {
+	return &testPages{
+		pages: map[string][]string{
+			"":     {"1", "2"},
+			"itr2": {"3", "4"},
+			"itr3": {"5"},
+		},
+		next:     map[string]string{"": "itr2", "itr2": "itr3"},
+		failures: map[string]error{},
+	}
+}
+
+func recordIDs(records []testRecord) []string {
+	ids := []string{}
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	return ids
+}
+
+func TestFetchPages(t *testing.T) {
+	pages := newTestPages()
+	records := []testRecord{{ID: "stale"}}
+	table := Table{Elems: &records}
+	if err := table.FetchPages("Issues", pages.fetch); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"", "itr2", "itr3"}; !reflect.DeepEqual(pages.offsets, expected) {
+		t.Errorf("expected the offsets %v, got %v", expected, pages.offsets)
+	}
+	if expected := []string{"1", "2", "3", "4", "5"}; !reflect.DeepEqual(recordIDs(records), expected) {
+		t.Errorf("expected the records %v, got %v", expected, recordIDs(records))
+	}
+	if got := table.FindByID("https://github.com/moul/depviz/issues/4"); got != "4" {
+		t.Errorf("expected the fields to be decoded, got %q", got)
+	}
+}
+
+func TestFetchPagesError(t *testing.T) {
+	pages := newTestPages()
+	pages.failures["itr3"] = errors.New("503 Service Unavailable")
+	records := []testRecord{}
+	table := Table{Elems: &records}
+	if err := table.FetchPages("Issues", pages.fetch); err == nil || err.Error() != "503 Service Unavailable" {
+		t.Errorf("expected the error of the last page, got %v", err)
+	}
+	if expected := []string{"", "itr2", "itr3"}; !reflect.DeepEqual(pages.offsets, expected) {
+		t.Errorf("expected the offsets %v, got %v", expected, pages.offsets)
+	}
+}
@@ -85,6 +85,8 @@ type AccountRecord struct {
 		Blog      string `json:"blog"`
 		Email     string `json:"email"`
 		AvatarURL string `json:"avatar-url"`
+		HTMLURL   string `json:"html-url,omitempty"`
+		Aliases   string `json:"aliases,omitempty"` // optional column, see --dedupe-accounts-by
 
 		// relationships
 		Provider []string `json:"provider"`
@@ -182,6 +184,7 @@ type IssueRecord struct {
 		NumDownvotes int       `json:"num-downvotes"`
 		IsOrphan     bool      `json:"is-orphan"`
 		IsHidden     bool      `json:"is-hidden"`
+		Estimate     string    `json:"estimate,omitempty"` // requires an 'estimate' text field, see --airtable-direction
 		// Weight  int       `json:"weight"`
 		// IsEpic  bool `json:"is-epic"`
 		// HasEpic bool `json:"has-epic"`
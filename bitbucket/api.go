@@ -0,0 +1,127 @@
+package bitbucket // import "moul.io/depviz/bitbucket"
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// the subset of the Bitbucket Cloud REST API 2.0 used by depviz, see
+// https://developer.atlassian.com/cloud/bitbucket/rest/
+
+const apiURL = "https://api.bitbucket.org/2.0"
+
+type apiUser struct {
+	UUID        string `json:"uuid"`
+	AccountID   string `json:"account_id"`
+	Nickname    string `json:"nickname"`
+	DisplayName string `json:"display_name"`
+	Links       struct {
+		Avatar struct {
+			Href string `json:"href"`
+		} `json:"avatar"`
+	} `json:"links"`
+}
+
+type apiContent struct {
+	Raw string `json:"raw"`
+}
+
+type apiIssue struct {
+	ID       int        `json:"id"`
+	Title    string     `json:"title"`
+	Content  apiContent `json:"content"`
+	State    string     `json:"state"` // new, open, on hold, resolved, duplicate, invalid, wontfix or closed
+	Kind     string     `json:"kind"`
+	Reporter *apiUser   `json:"reporter"`
+	Assignee *apiUser   `json:"assignee"`
+	Created  time.Time  `json:"created_on"`
+	Updated  time.Time  `json:"updated_on"`
+}
+
+type apiPullRequest struct {
+	ID           int       `json:"id"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	State        string    `json:"state"` // OPEN, MERGED, DECLINED or SUPERSEDED
+	Author       *apiUser  `json:"author"`
+	Reviewers    []apiUser `json:"reviewers"`
+	CommentCount int       `json:"comment_count"`
+	Created      time.Time `json:"created_on"`
+	Updated      time.Time `json:"updated_on"`
+	Links        struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+// apiPage is a page of a paginated collection, Next is the URL of the next
+// page, empty on the last one.
+type apiPage struct {
+	Values json.RawMessage `json:"values"`
+	Next   string          `json:"next"`
+}
+
+type client struct {
+	httpClient *http.Client
+	user       string
+	token      string // app password
+}
+
+func newClient(httpClient *http.Client, user, token string) *client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &client{httpClient: httpClient, user: user, token: token}
+}
+
+// get decodes the response of GET {apiURL}{path} in out, path can also be the
+// full URL of a next page.
+func (c *client) get(path string, out interface{}) error {
+	if !strings.HasPrefix(path, apiURL) {
+		path = apiURL + path
+	}
+	req, err := http.NewRequest("GET", path, nil)
+	if err != nil {
+		return err
+	}
+	if c.user != "" || c.token != "" {
+		req.SetBasicAuth(c.user, c.token)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Error.Message != "" {
+			return &apiError{status: resp.StatusCode, message: fmt.Sprintf("%s: %s", resp.Status, apiErr.Error.Message)}
+		}
+		return &apiError{status: resp.StatusCode, message: resp.Status}
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string { return e.message }
+
+// isNotFound returns true for the 404 errors, i.e., the issue tracker of the
+// repository is disabled.
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.status == http.StatusNotFound
+}
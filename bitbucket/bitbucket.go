@@ -0,0 +1,134 @@
+package bitbucket // import "moul.io/depviz/bitbucket"
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"go.uber.org/zap"
+	"moul.io/depviz/model"
+	"moul.io/depviz/sql"
+	"moul.io/multipmuri"
+)
+
+// Config are the Bitbucket options of 'pull'.
+type Config struct {
+	User  string
+	Token string // app password with the 'issues' and 'pull requests' read scopes
+}
+
+// Pull fetches the issues and pull requests of a Bitbucket Cloud repository,
+// the dependencies are parsed from their bodies like the GitHub ones.
+//
+// Bitbucket targets are parsed as GitHub entities (same URL layout) on the
+// bitbucket.org host.
+func Pull(input multipmuri.Entity, wg *sync.WaitGroup, config Config, fetchOpts model.FetchOptions, db *gorm.DB, out chan<- []*model.Issue) {
+	defer wg.Done()
+	type multipmuriMinimalInterface interface {
+		Repo() *multipmuri.GitHubRepo
+	}
+	target, ok := input.(multipmuriMinimalInterface)
+	if !ok {
+		zap.L().Warn("invalid input", zap.String("input", fmt.Sprintf("%v", input.String())))
+		return
+	}
+	repo := target.Repo()
+	u, err := url.Parse(repo.String())
+	if err != nil {
+		zap.L().Warn("invalid input", zap.String("input", repo.String()), zap.Error(err))
+		return
+	}
+	serviceURL := fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+	client := newClient(fetchOpts.HTTPClient, config.User, config.Token)
+
+	query := url.Values{}
+	query.Set("pagelen", "50")
+	startedAt := time.Now()
+	since := sql.PullSince(db, repo.String(), fetchOpts.Full)
+	if !since.IsZero() {
+		query.Set("q", fmt.Sprintf("updated_on >= %s", since.UTC().Format(time.RFC3339)))
+	}
+	base := fmt.Sprintf("/repositories/%s/%s", url.PathEscape(repo.OwnerID()), url.PathEscape(repo.RepoID()))
+
+	// issues, the issue tracker is optional
+	err = paginate(client, base+"/issues?"+query.Encode(), repo.String(), fetchOpts, true, func(values json.RawMessage) error {
+		var issues []*apiIssue
+		if err := json.Unmarshal(values, &issues); err != nil {
+			return err
+		}
+		normalizedIssues := []*model.Issue{}
+		for _, issue := range issues {
+			normalizedIssues = append(normalizedIssues, FromIssue(issue, serviceURL, repo.String()))
+		}
+		out <- normalizedIssues
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	// pull requests, in every state, with their reviewers
+	for _, state := range []string{"OPEN", "MERGED", "DECLINED", "SUPERSEDED"} {
+		query.Add("state", state)
+	}
+	query.Set("fields", "+values.reviewers")
+	err = paginate(client, base+"/pullrequests?"+query.Encode(), repo.String(), fetchOpts, false, func(values json.RawMessage) error {
+		var pulls []*apiPullRequest
+		if err := json.Unmarshal(values, &pulls); err != nil {
+			return err
+		}
+		normalizedIssues := []*model.Issue{}
+		for _, pull := range pulls {
+			normalizedIssues = append(normalizedIssues, FromPullRequest(pull, serviceURL, repo.String()))
+		}
+		out <- normalizedIssues
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	fetchOpts.Synced.Add(model.SyncedRepo{ID: repo.String(), StartedAt: startedAt, Full: since.IsZero()})
+}
+
+// paginate calls handle with the values of each page, following the next
+// links. The failures are logged and recorded in the fetch options, a missing
+// collection is empty if optional.
+func paginate(client *client, path string, repo string, fetchOpts model.FetchOptions, optional bool, handle func(values json.RawMessage) error) error {
+	for page := 1; path != ""; page++ {
+		var result apiPage
+		notFound := false
+		err := model.Retry(fetchOpts.Retries, func() error {
+			err := client.get(path, &result)
+			switch {
+			case optional && isNotFound(err):
+				notFound = true
+				return nil // not worth a retry
+			case err != nil:
+				zap.L().Debug("failed to pull issues, retrying", zap.String("repo", repo), zap.Int("page", page), zap.Error(err))
+			}
+			return err
+		})
+		if err == nil && !notFound {
+			err = handle(result.Values)
+		}
+		if err != nil {
+			zap.L().Error("failed to pull issues", zap.String("repo", repo), zap.Error(err))
+			fetchOpts.Failures.Add(model.FetchFailure{Provider: "bitbucket", Repo: repo, Page: page, Err: err})
+			return err
+		}
+		if notFound {
+			zap.L().Debug("no issue tracker", zap.String("repo", repo))
+			return nil
+		}
+		zap.L().Debug("paginate",
+			zap.String("provider", "bitbucket"),
+			zap.String("repo", repo),
+			zap.Int("page", page),
+		)
+		path = result.Next
+	}
+	return nil
+}
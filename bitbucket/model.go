@@ -0,0 +1,135 @@
+package bitbucket // import "moul.io/depviz/bitbucket"
+
+import (
+	"fmt"
+
+	"moul.io/depviz/model"
+)
+
+// FromIssue converts a Bitbucket issue, repoURL is the web URL of the
+// repository (i.e., "https://bitbucket.org/workspace/repo").
+//
+// Bitbucket does not record when an issue was closed, the last update is used.
+func FromIssue(input *apiIssue, serviceURL, repoURL string) *model.Issue {
+	url := fmt.Sprintf("%s/issues/%d", repoURL, input.ID)
+	service := FromServiceURL(serviceURL)
+	repo := FromRepositoryURL(service, repoURL)
+	issue := &model.Issue{
+		Base: model.Base{
+			ID:        url,
+			URL:       url,
+			CreatedAt: input.Created,
+			UpdatedAt: input.Updated,
+		},
+		Title:        input.Title,
+		State:        issueState(input.State),
+		Body:         input.Content.Raw,
+		Repository:   repo,
+		Service:      service,
+		Labels:       make([]*model.Label, 0),
+		Assignees:    make([]*model.Account, 0),
+		Author:       FromUser(service, input.Reporter),
+		Dependencies: []string{},
+	}
+	if issue.State == "closed" {
+		issue.CompletedAt = input.Updated
+	}
+	if input.Assignee != nil {
+		issue.Assignees = append(issue.Assignees, FromUser(service, input.Assignee))
+	}
+	return issue
+}
+
+// FromPullRequest converts a Bitbucket pull request, the reviewers are the
+// assignees.
+//
+// The pull requests have their own numbers on Bitbucket, they are stored with
+// the GitHub URL layout ("<repo>/pull/42") so they do not collide with the
+// issues, their web page ("<repo>/pull-requests/42") is the HTMLURL.
+//
+// Bitbucket does not record when a pull request was merged or declined, the
+// completion date is left empty.
+func FromPullRequest(input *apiPullRequest, serviceURL, repoURL string) *model.Issue {
+	url := fmt.Sprintf("%s/pull/%d", repoURL, input.ID)
+	service := FromServiceURL(serviceURL)
+	repo := FromRepositoryURL(service, repoURL)
+	issue := &model.Issue{
+		Base: model.Base{
+			ID:        url,
+			URL:       url,
+			CreatedAt: input.Created,
+			UpdatedAt: input.Updated,
+		},
+		Title:        input.Title,
+		State:        "open",
+		Body:         input.Description,
+		HTMLURL:      input.Links.HTML.Href,
+		IsPR:         true,
+		NumComments:  input.CommentCount,
+		Repository:   repo,
+		Service:      service,
+		Labels:       make([]*model.Label, 0),
+		Assignees:    make([]*model.Account, 0),
+		Author:       FromUser(service, input.Author),
+		Dependencies: []string{},
+	}
+	if input.State != "OPEN" {
+		issue.State = "closed"
+	}
+	for idx := range input.Reviewers {
+		issue.Assignees = append(issue.Assignees, FromUser(service, &input.Reviewers[idx]))
+	}
+	return issue
+}
+
+// issueState maps the Bitbucket issue states onto open and closed.
+func issueState(state string) string {
+	switch state {
+	case "new", "open", "on hold":
+		return "open"
+	}
+	return "closed"
+}
+
+func FromServiceURL(input string) *model.Provider {
+	return &model.Provider{
+		Base: model.Base{
+			ID:  input,
+			URL: input,
+		},
+		Driver: string(model.BitbucketDriver),
+	}
+}
+
+func FromRepositoryURL(service *model.Provider, input string) *model.Repository {
+	return &model.Repository{
+		Base: model.Base{
+			ID:  input,
+			URL: input,
+		},
+		Provider: service,
+	}
+}
+
+// FromUser converts a Bitbucket user, identified by its nickname, or by its
+// UUID when the profile is private.
+func FromUser(service *model.Provider, input *apiUser) *model.Account {
+	if input == nil {
+		return nil
+	}
+	login := input.Nickname
+	if login == "" {
+		login = input.UUID
+	}
+	url := fmt.Sprintf("%s/%s", service.URL, login)
+	return &model.Account{
+		Base: model.Base{
+			ID:  url,
+			URL: url,
+		},
+		Login:     login,
+		FullName:  input.DisplayName,
+		AvatarURL: input.Links.Avatar.Href,
+		Provider:  service,
+	}
+}
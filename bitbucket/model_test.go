@@ -0,0 +1,51 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const (
+	testServiceURL = "https://bitbucket.org"
+	testRepoURL    = "https://bitbucket.org/workspace/repo"
+)
+
+func TestFromPullRequest(t *testing.T) {
+	tests := []struct {
+		state    string
+		expected string
+	}{
+		{"OPEN", "open"},
+		{"MERGED", "closed"},
+		{"DECLINED", "closed"},
+	}
+	for _, test := range tests {
+		t.Run(test.state, func(t *testing.T) {
+			input := apiPullRequest{}
+			err := json.Unmarshal([]byte(`{
+				"id": 42,
+				"title": "Pull request",
+				"state": "`+test.state+`",
+				"created_on": "2020-01-01T10:00:00.000000+00:00",
+				"updated_on": "2020-01-03T10:00:00.000000+00:00",
+				"links": {"html": {"href": "https://bitbucket.org/workspace/repo/pull-requests/42"}}
+			}`), &input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			issue := FromPullRequest(&input, testServiceURL, testRepoURL)
+			if issue.ID != testRepoURL+"/pull/42" || issue.URL != issue.ID {
+				t.Errorf("expected the GitHub URL layout, got %q and %q", issue.ID, issue.URL)
+			}
+			if expected := testRepoURL + "/pull-requests/42"; issue.HTMLURL != expected || issue.WebURL() != expected {
+				t.Errorf("expected the web page %q, got %q and %q", expected, issue.HTMLURL, issue.WebURL())
+			}
+			if issue.State != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, issue.State)
+			}
+			if !issue.CompletedAt.IsZero() {
+				t.Errorf("expected no completion date, got %v", issue.CompletedAt)
+			}
+		})
+	}
+}
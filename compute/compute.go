@@ -3,10 +3,11 @@ package compute // import "moul.io/depviz/compute"
 import (
 	"fmt"
 	"sort"
+	"time"
 
+	"go.uber.org/zap"
 	"moul.io/depviz/model"
 	"moul.io/multipmuri"
-	"moul.io/multipmuri/pmbodyparser"
 )
 
 //
@@ -17,6 +18,7 @@ type Computed struct {
 	AllIssues     []*ComputedIssue
 	AllMilestones []*ComputedMilestone
 	AllRepos      []*ComputedRepo
+	Links         []*model.Link
 
 	// internal
 	mmap map[string]*ComputedMilestone
@@ -24,12 +26,20 @@ type Computed struct {
 	rmap map[string]*ComputedRepo
 }
 
-func Compute(input model.Issues) Computed {
+func Compute(input model.Issues, opts ParseOptions) Computed {
+	return ComputeWithLinks(input, nil, opts)
+}
+
+// ComputeWithLinks computes the issues using the links resolved at fetch time
+// instead of parsing the issue bodies. If links is nil, the bodies are parsed.
+func ComputeWithLinks(input model.Issues, links []*model.Link, opts ParseOptions) Computed {
 	computed := newComputed()
 	for _, issue := range input {
 		// issue
 		issue := newComputedIssue(issue)
-		issue.parseBody()
+		if links == nil {
+			issue.parseBody(opts)
+		}
 		computed.imap[issue.URL] = issue
 
 		// repo
@@ -47,23 +57,41 @@ func Compute(input model.Issues) Computed {
 		repo := computed.getOrCreateRepo(milestone.Repository)
 		repo.DependsOn = append(repo.DependsOn, milestone.URL)
 	}
-	for _, issue := range computed.imap {
-		for _, relationship := range issue.Relationships {
-			switch relationship.Kind {
-			case pmbodyparser.Blocks, pmbodyparser.Fixes, pmbodyparser.Closes, pmbodyparser.Addresses, pmbodyparser.PartOf:
-				if relatedIssue, found := computed.imap[relationship.Target.String()]; found {
-					relatedIssue.DependsOn = append(relatedIssue.DependsOn, issue.URL)
-				} else {
-					issue.Errs = append(issue.Errs, fmt.Errorf("is dependent of a missing issue: %q", relationship.Target.String()))
-					// FIXME: create dummy issue?
+	if links == nil {
+		links = []*model.Link{}
+		for _, issue := range computed.imap {
+			links = append(links, issue.links()...)
+		}
+	}
+	if !opts.ParseTaskLists {
+		links = withoutTaskListLinks(links)
+	}
+	computed.Links = links
+	for _, link := range links {
+		issue, found := computed.imap[link.SourceID]
+		if !found {
+			continue
+		}
+		switch link.Kind {
+		case model.BlocksLink, model.FixesLink, model.ClosesLink, model.AddressesLink, model.PartOfLink:
+			if relatedIssue, found := computed.imap[link.TargetID]; found {
+				relatedIssue.DependsOn = append(relatedIssue.DependsOn, issue.URL)
+				if issue.IsPR && (link.Kind == model.FixesLink || link.Kind == model.ClosesLink) {
+					issue.Fixes = append(issue.Fixes, relatedIssue.URL)
 				}
-			case pmbodyparser.DependsOn, pmbodyparser.ParentOf:
-				issue.DependsOn = append(issue.DependsOn, relationship.Target.String())
-			case pmbodyparser.RelatedWith:
-				// nothing to do (for now)
-			default:
-				panic(fmt.Errorf("unsupported pmbodyparser.Kind: %q", relationship.Kind))
+			} else {
+				issue.Errs = append(issue.Errs, fmt.Errorf("is dependent of a missing issue: %q", link.TargetID))
+				// FIXME: create dummy issue?
+			}
+		case model.DependsOnLink, model.ParentOfLink:
+			issue.DependsOn = append(issue.DependsOn, link.TargetID)
+			if link.Provenance == taskListDoneProvenance {
+				issue.Satisfied = append(issue.Satisfied, link.TargetID)
 			}
+		case model.RelatedWithLink:
+			// nothing to do (for now)
+		default:
+			panic(fmt.Errorf("unsupported link kind: %q", link.Kind))
 		}
 	}
 
@@ -71,39 +99,81 @@ func Compute(input model.Issues) Computed {
 	return computed
 }
 
+// SetLinkTimestamps sets the creation date of the links parsed from the
+// bodies: the one of the same stored link, now for the links not stored yet.
+func (computed *Computed) SetLinkTimestamps(stored []*model.Link, now time.Time) {
+	createdAt := map[string]time.Time{}
+	for _, link := range stored {
+		createdAt[link.ID] = link.CreatedAt
+	}
+	for _, link := range computed.Links {
+		if !link.CreatedAt.IsZero() {
+			continue
+		}
+		if t, found := createdAt[link.ID]; found && !t.IsZero() {
+			link.CreatedAt = t
+		} else {
+			link.CreatedAt = now
+		}
+	}
+}
+
+// withoutTaskListLinks drops the links found in the task lists, the stored
+// links may have been fetched with --parse-task-lists.
+func withoutTaskListLinks(links []*model.Link) []*model.Link {
+	filtered := []*model.Link{}
+	for _, link := range links {
+		if link.Provenance != taskListProvenance && link.Provenance != taskListDoneProvenance {
+			filtered = append(filtered, link)
+		}
+	}
+	return filtered
+}
+
+// ParseLinks parses the issue body (and comments, when fetched) and returns the
+// declared links, it also updates the sub-tasks completion of the issue.
+func ParseLinks(issue *model.Issue, opts ParseOptions) ([]*model.Link, []error) {
+	computed := newComputedIssue(issue)
+	computed.parseBody(opts)
+	issue.TasksDone, issue.TasksTotal = computed.TasksDone, computed.TasksTotal
+	links := append(computed.links(), computed.commentLinks()...)
+	links = append(links, computed.dependencyLinks()...)
+	return links, computed.Errs
+}
+
+// FilterByTargets hides the entities not matched by the targets, or matched by
+// one of their exclusions (see model.ExcludedTarget).
 func (computed *Computed) FilterByTargets(targets []multipmuri.Entity) {
-	for _, issue := range computed.AllIssues {
-		issueEntity := issue.MultipmuriEntity()
-		for _, target := range targets {
-			if issueEntity.Equals(target) || target.Contains(issueEntity) {
-				issue.DirectMatchWithTarget = true
-				break
+	included, excluded := model.SplitTargets(targets)
+	matches := func(entity multipmuri.Entity, err error) bool {
+		if err != nil {
+			zap.L().Warn("cannot match entity with targets", zap.Error(err))
+			return false
+		}
+		if model.IsExcluded(entity, excluded) {
+			return false
+		}
+		for _, target := range included {
+			if entity.Equals(target) || target.Contains(entity) {
+				return true
 			}
 		}
+		return false
+	}
+	for _, issue := range computed.AllIssues {
+		issue.DirectMatchWithTarget = matches(issue.MultipmuriEntity())
 		if !issue.DirectMatchWithTarget {
 			issue.Hidden = true
 		}
 	}
 	for _, milestone := range computed.AllMilestones {
-		milestoneEntity := milestone.MultipmuriEntity()
-		for _, target := range targets {
-			if milestoneEntity.Equals(target) || target.Contains(milestoneEntity) {
-				milestone.DirectMatchWithTarget = true
-				break
-			}
-		}
+		milestone.DirectMatchWithTarget = matches(milestone.MultipmuriEntity())
 		if !milestone.DirectMatchWithTarget {
 			milestone.Hidden = true
 		}
 	}
 	for _, repo := range computed.AllRepos {
-		repoEntity := repo.MultipmuriEntity()
-		for _, target := range targets {
-			if repoEntity.Equals(target) || target.Contains(repoEntity) {
-				repo.DirectMatchWithTarget = true
-				break
-			}
-		}
+		repo.DirectMatchWithTarget = matches(repo.MultipmuriEntity())
 		if !repo.DirectMatchWithTarget {
 			repo.Hidden = true
 		}
@@ -144,6 +214,7 @@ func (computed *Computed) mapsToSlices() {
 	// issues
 	for _, issue := range computed.imap {
 		sort.Strings(issue.DependsOn)
+		sort.Strings(issue.Fixes)
 		computed.AllIssues = append(computed.AllIssues, issue)
 	}
 	sort.Slice(computed.AllIssues, func(i, j int) bool {
@@ -180,3 +251,35 @@ func (computed *Computed) Issues() []*ComputedIssue {
 	}
 	return enabled
 }
+
+// Reverse flips the direction of every dependency, i.e., "A depends on B"
+// becomes "B depends on A". The dependencies to unknown entities are dropped
+// as they would have no node to start from.
+func (computed *Computed) Reverse() {
+	dependsOn := map[string]*[]string{}
+	for _, issue := range computed.AllIssues {
+		dependsOn[issue.URL] = &issue.DependsOn
+	}
+	for _, milestone := range computed.AllMilestones {
+		dependsOn[milestone.URL] = &milestone.DependsOn
+	}
+	for _, repo := range computed.AllRepos {
+		dependsOn[repo.URL] = &repo.DependsOn
+	}
+
+	reversed := map[string][]string{}
+	for url, dependencies := range dependsOn {
+		for _, dependency := range *dependencies {
+			if _, found := dependsOn[dependency]; found {
+				reversed[dependency] = append(reversed[dependency], url)
+			}
+		}
+	}
+	for url, dependencies := range dependsOn {
+		*dependencies = reversed[url]
+		if *dependencies == nil {
+			*dependencies = []string{}
+		}
+		sort.Strings(*dependencies)
+	}
+}
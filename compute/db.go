@@ -3,6 +3,7 @@ package compute
 import (
 	"github.com/jinzhu/gorm"
 	"go.uber.org/zap"
+	"moul.io/depviz/model"
 	"moul.io/depviz/sql"
 	"moul.io/multipmuri"
 )
@@ -22,11 +23,25 @@ type multipmuriService interface {
 // FIXME: loadIssuesByAuthor
 // FIXME: handle github search
 
-func LoadIssuesByTargets(db *gorm.DB, targets []multipmuri.Entity) (*Computed, error) {
+func LoadIssuesByTargets(db *gorm.DB, targets []multipmuri.Entity, opts ParseOptions) (*Computed, error) {
+	allIssues, err := sql.LoadAllIssues(FilterDBByTargets(db, targets))
+	if err != nil {
+		return nil, err
+	}
+
+	computed := Compute(allIssues, opts)
+	computed.FilterByTargets(targets) // in most cases, this step is optional as we are already filtering by targets when querying the database
+
+	return &computed, nil
+}
+
+// FilterDBByTargets returns a query restricted to the issues that may match the targets.
+func FilterDBByTargets(db *gorm.DB, targets []multipmuri.Entity) *gorm.DB {
 	byRepo := []string{}
 	byOwner := []string{}
 	byService := []string{}
-	useFilters := true
+	targets, _ = model.SplitTargets(targets) // the exclusions are applied by FilterByTargets
+	useFilters := len(targets) > 0
 	for _, target := range targets {
 		switch v := target.(type) {
 		case multipmuriRepo:
@@ -51,14 +66,5 @@ func LoadIssuesByTargets(db *gorm.DB, targets []multipmuri.Entity) (*Computed, e
 			byService,
 		)
 	}
-
-	allIssues, err := sql.LoadAllIssues(filteredDB)
-	if err != nil {
-		return nil, err
-	}
-
-	computed := Compute(allIssues)
-	computed.FilterByTargets(targets) // in most cases, this step is optional as we are already filtering by targets when querying the database
-
-	return &computed, nil
+	return filteredDB
 }
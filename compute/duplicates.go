@@ -0,0 +1,81 @@
+package compute
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var duplicateOfRegex = regexp.MustCompile(`(?im)^\s*duplicate\s+of\s+(\S+)`)
+
+// DuplicateReference is a dependency of an open issue on an issue closed as a
+// duplicate.
+type DuplicateReference struct {
+	Issue     string // the open issue
+	Duplicate string // the closed duplicate it depends on
+	Canonical string // the issue it duplicates, empty if unknown
+	Rewritten bool
+}
+
+// duplicateOf returns whether the issue was closed as a duplicate (with a
+// "duplicate" label or a "Duplicate of #42" line in its body) and the
+// canonical issue when it is known.
+func (i *ComputedIssue) duplicateOf() (bool, string) {
+	if i.State != "closed" {
+		return false, ""
+	}
+	labeled := false
+	for _, label := range i.Labels {
+		if strings.EqualFold(label.Name, "duplicate") {
+			labeled = true
+			break
+		}
+	}
+	canonical := ""
+	if match := duplicateOfRegex.FindStringSubmatch(i.Body); match != nil {
+		if base, err := i.MultipmuriEntity(); err == nil {
+			if entity, err := base.RelDecodeString(strings.TrimRight(match[1], ".,;")); err == nil {
+				canonical = entity.String()
+			}
+		}
+	}
+	return labeled || canonical != "", canonical
+}
+
+// ResolveDuplicates finds the open issues depending on closed duplicates.
+// If rewrite is true, the dependencies are moved to the canonical issue when
+// it is known and loaded.
+func (computed *Computed) ResolveDuplicates(rewrite bool) []DuplicateReference {
+	references := []DuplicateReference{}
+	for _, issue := range computed.AllIssues {
+		if issue.State == "closed" {
+			continue
+		}
+		dependsOn := []string{}
+		seen := map[string]bool{}
+		for _, dependency := range issue.DependsOn {
+			target := dependency
+			if duplicate, found := computed.imap[dependency]; found {
+				if isDuplicate, canonical := duplicate.duplicateOf(); isDuplicate {
+					reference := DuplicateReference{
+						Issue:     issue.URL,
+						Duplicate: dependency,
+						Canonical: canonical,
+					}
+					if _, loaded := computed.imap[canonical]; rewrite && loaded && canonical != issue.URL {
+						target = canonical
+						reference.Rewritten = true
+					}
+					references = append(references, reference)
+				}
+			}
+			if !seen[target] {
+				seen[target] = true
+				dependsOn = append(dependsOn, target)
+			}
+		}
+		sort.Strings(dependsOn)
+		issue.DependsOn = dependsOn
+	}
+	return references
+}
@@ -0,0 +1,349 @@
+package compute
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"moul.io/depviz/model"
+)
+
+// FilterByLabels hides the issues that have none of the given labels.
+// Label names are compared case-insensitively.
+func (computed *Computed) FilterByLabels(labels []string) {
+	if len(labels) == 0 {
+		return
+	}
+	wanted := map[string]bool{}
+	for _, label := range labels {
+		wanted[strings.ToLower(label)] = true
+	}
+	for _, issue := range computed.AllIssues {
+		matched := false
+		for _, label := range issue.Labels {
+			if wanted[strings.ToLower(label.Name)] {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			issue.Hidden = true
+		}
+	}
+}
+
+// FilterByAssignees hides the issues assigned to none of the given logins
+// (compared case-insensitively), the unassigned issues are kept only if the
+// special "none" login is given.
+func (computed *Computed) FilterByAssignees(logins []string) {
+	if len(logins) == 0 {
+		return
+	}
+	wanted := map[string]bool{}
+	for _, login := range logins {
+		wanted[strings.ToLower(login)] = true
+	}
+	for _, issue := range computed.AllIssues {
+		matched := len(issue.Assignees) == 0 && wanted["none"]
+		for _, assignee := range issue.Assignees {
+			if wanted[strings.ToLower(assignee.Login)] {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			issue.Hidden = true
+		}
+	}
+}
+
+// FilterByDepth keeps the issues at most maxDepth dependency hops (in both
+// directions) away from the issues matching the targets, and hides the other
+// ones. When sameRepos is true, the traversal does not leave the repositories
+// of the matching issues.
+func (computed *Computed) FilterByDepth(maxDepth int, sameRepos bool) {
+	neighbors := map[string][]string{}
+	for _, issue := range computed.AllIssues {
+		for _, dependency := range issue.DependsOn {
+			neighbors[issue.URL] = append(neighbors[issue.URL], dependency)
+			neighbors[dependency] = append(neighbors[dependency], issue.URL)
+		}
+	}
+	depth := map[string]int{}
+	repos := map[string]bool{}
+	queue := []string{}
+	for _, issue := range computed.AllIssues {
+		if issue.DirectMatchWithTarget {
+			depth[issue.URL] = 0
+			repos[issue.RepositoryID] = true
+			queue = append(queue, issue.URL)
+		}
+	}
+	for ; len(queue) > 0; queue = queue[1:] {
+		current := queue[0]
+		if depth[current] >= maxDepth {
+			continue
+		}
+		for _, neighbor := range neighbors[current] {
+			if _, seen := depth[neighbor]; seen {
+				continue
+			}
+			issue, found := computed.imap[neighbor]
+			if !found || (sameRepos && !repos[issue.RepositoryID]) {
+				continue
+			}
+			depth[neighbor] = depth[current] + 1
+			queue = append(queue, neighbor)
+		}
+	}
+	for _, issue := range computed.AllIssues {
+		_, reached := depth[issue.URL]
+		issue.Hidden = !reached
+	}
+}
+
+// ShowNeighbors makes the hidden candidates that are direct dependencies or
+// dependents of a visible issue visible again, the candidates are usually the
+// issues visible before applying a filter.
+func (computed *Computed) ShowNeighbors(candidates []*ComputedIssue) {
+	visible := map[string]bool{}
+	for _, issue := range computed.Issues() {
+		visible[issue.URL] = true
+	}
+	neighbors := map[string]bool{}
+	for _, issue := range candidates {
+		for _, dependency := range issue.DependsOn {
+			if visible[issue.URL] {
+				neighbors[dependency] = true
+			}
+			if visible[dependency] {
+				neighbors[issue.URL] = true
+			}
+		}
+	}
+	for _, issue := range candidates {
+		if neighbors[issue.URL] {
+			issue.Hidden = false
+		}
+	}
+}
+
+// FilterByMilestones hides the issues that are not attached to one of the
+// given milestones (matched on title), issues without milestone are hidden.
+func (computed *Computed) FilterByMilestones(milestones []string) {
+	if len(milestones) == 0 {
+		return
+	}
+	wanted := map[string]bool{}
+	for _, milestone := range milestones {
+		wanted[milestone] = true
+	}
+	for _, issue := range computed.AllIssues {
+		if issue.Milestone == nil || !wanted[issue.Milestone.Title] {
+			issue.Hidden = true
+		}
+	}
+	for _, milestone := range computed.AllMilestones {
+		if !wanted[milestone.Title] {
+			milestone.Hidden = true
+		}
+	}
+}
+
+// FilterByStates hides the issues whose state is not one of the given states
+// (i.e., "open", "closed").
+func (computed *Computed) FilterByStates(states []string) {
+	if len(states) == 0 {
+		return
+	}
+	wanted := map[string]bool{}
+	for _, state := range states {
+		wanted[strings.ToLower(state)] = true
+	}
+	for _, issue := range computed.AllIssues {
+		if !wanted[strings.ToLower(issue.State)] {
+			issue.Hidden = true
+		}
+	}
+}
+
+// NumberRange is a range of issue numbers, optionally limited to a repository.
+type NumberRange struct {
+	Repo     string
+	From, To int
+}
+
+// ParseNumberRange parses ranges like "1000-2000", "moul/depviz:1000-2000" or
+// "https://github.com/moul/depviz:1000-2000".
+func ParseNumberRange(input string) (NumberRange, error) {
+	r := NumberRange{}
+	bounds := input
+	if idx := strings.LastIndex(input, ":"); idx != -1 {
+		r.Repo = strings.TrimRight(input[:idx], "/")
+		bounds = input[idx+1:]
+	}
+	parts := strings.Split(bounds, "-")
+	if len(parts) != 2 {
+		return r, fmt.Errorf("invalid number range %q, expected [repo:]from-to", input)
+	}
+	var err error
+	if r.From, err = strconv.Atoi(parts[0]); err != nil {
+		return r, fmt.Errorf("invalid number range %q: %v", input, err)
+	}
+	if r.To, err = strconv.Atoi(parts[1]); err != nil {
+		return r, fmt.Errorf("invalid number range %q: %v", input, err)
+	}
+	if r.From > r.To {
+		return r, fmt.Errorf("invalid number range %q: %d > %d", input, r.From, r.To)
+	}
+	return r, nil
+}
+
+func (r NumberRange) contains(issue *ComputedIssue) bool {
+	if r.Repo != "" {
+		repo := strings.TrimPrefix(strings.TrimPrefix(r.Repo, "https://"), "http://")
+		if !strings.HasSuffix(issue.RepositoryID, "/"+repo) && issue.RepositoryID != r.Repo {
+			return false
+		}
+	}
+	number := issue.Number()
+	return number >= r.From && number <= r.To
+}
+
+// FilterByNumberRanges hides the issues that are not in one of the ranges,
+// except the direct dependencies and dependents of the matching issues.
+func (computed *Computed) FilterByNumberRanges(ranges []NumberRange) {
+	if len(ranges) == 0 {
+		return
+	}
+	matching := map[string]bool{}
+	for _, issue := range computed.AllIssues {
+		for _, r := range ranges {
+			if r.contains(issue) {
+				matching[issue.URL] = true
+				break
+			}
+		}
+	}
+	neighbors := map[string]bool{}
+	for _, issue := range computed.AllIssues {
+		for _, dependency := range issue.DependsOn {
+			if matching[issue.URL] {
+				neighbors[dependency] = true
+			}
+			if matching[dependency] {
+				neighbors[issue.URL] = true
+			}
+		}
+	}
+	for _, issue := range computed.AllIssues {
+		if !matching[issue.URL] && !neighbors[issue.URL] {
+			issue.Hidden = true
+		}
+	}
+}
+
+// openBlockers returns the visible open dependencies of each visible open
+// issue, closed (resolved) dependencies do not block.
+func (computed *Computed) openBlockers() map[string][]string {
+	open := map[string]bool{}
+	for _, issue := range computed.Issues() {
+		if issue.State != "closed" {
+			open[issue.URL] = true
+		}
+	}
+	blockers := map[string][]string{}
+	for _, issue := range computed.Issues() {
+		if !open[issue.URL] {
+			continue
+		}
+		blockers[issue.URL] = []string{}
+		for _, dependency := range issue.DependsOn {
+			if open[dependency] {
+				blockers[issue.URL] = append(blockers[issue.URL], dependency)
+			}
+		}
+	}
+	return blockers
+}
+
+// FilterBlockedOnly hides everything except the open issues blocked by at
+// least one other open issue, and their blockers.
+func (computed *Computed) FilterBlockedOnly() {
+	keep := map[string]bool{}
+	for url, blockers := range computed.openBlockers() {
+		if len(blockers) == 0 {
+			continue
+		}
+		keep[url] = true
+		for _, blocker := range blockers {
+			keep[blocker] = true
+		}
+	}
+	for _, issue := range computed.AllIssues {
+		if !keep[issue.URL] {
+			issue.Hidden = true
+		}
+	}
+}
+
+// FilterReadyOnly hides everything except the open issues whose dependencies
+// are all resolved (or that have none).
+func (computed *Computed) FilterReadyOnly() {
+	ready := map[string]bool{}
+	for url, blockers := range computed.openBlockers() {
+		if len(blockers) == 0 {
+			ready[url] = true
+		}
+	}
+	for _, issue := range computed.AllIssues {
+		if !ready[issue.URL] {
+			issue.Hidden = true
+		}
+	}
+}
+
+// FilterByUsers hides everything except the issues authored by or assigned to
+// one of the given logins, and their dependencies.
+func (computed *Computed) FilterByUsers(logins []string) {
+	wanted := map[string]bool{}
+	for _, login := range logins {
+		wanted[strings.ToLower(login)] = true
+	}
+	isWanted := func(account *model.Account, id string) bool {
+		if account != nil && account.Login != "" {
+			return wanted[strings.ToLower(account.Login)]
+		}
+		return wanted[strings.ToLower(id)]
+	}
+
+	keep := map[string]bool{}
+	queue := []string{}
+	for _, issue := range computed.Issues() {
+		matched := isWanted(issue.Author, issue.AuthorID)
+		for _, assignee := range issue.Assignees {
+			matched = matched || isWanted(assignee, assignee.ID)
+		}
+		if matched {
+			keep[issue.URL] = true
+			queue = append(queue, issue.URL)
+		}
+	}
+	for ; len(queue) > 0; queue = queue[1:] {
+		issue, found := computed.imap[queue[0]]
+		if !found {
+			continue
+		}
+		for _, dependency := range issue.DependsOn {
+			if !keep[dependency] {
+				keep[dependency] = true
+				queue = append(queue, dependency)
+			}
+		}
+	}
+	for _, issue := range computed.AllIssues {
+		if !keep[issue.URL] {
+			issue.Hidden = true
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package compute
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"moul.io/depviz/model"
+)
+
+// testIssue returns an open issue of moul/depviz with the given labels and
+// assignee logins.
+func testIssue(number int, labels []string, assignees []string) *model.Issue {
+	repo := &model.Repository{Base: model.Base{ID: "https://github.com/moul/depviz", URL: "https://github.com/moul/depviz"}}
+	url := fmt.Sprintf("%s/issues/%d", repo.URL, number)
+	issue := &model.Issue{
+		Base:         model.Base{ID: url, URL: url},
+		State:        "open",
+		Repository:   repo,
+		RepositoryID: repo.ID,
+	}
+	for _, name := range labels {
+		issue.Labels = append(issue.Labels, &model.Label{Name: name})
+	}
+	for _, login := range assignees {
+		issue.Assignees = append(issue.Assignees, &model.Account{Login: login})
+	}
+	return issue
+}
+
+// visibleNumbers returns the numbers of the visible issues, sorted.
+func visibleNumbers(computed Computed) []int {
+	numbers := []int{}
+	for _, issue := range computed.Issues() {
+		numbers = append(numbers, issue.Number())
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+func TestFilterByAssigneesAndLabels(t *testing.T) {
+	issues := model.Issues{
+		testIssue(1, []string{"bug"}, []string{"alice"}),
+		testIssue(2, []string{"bug"}, []string{"bob", "alice"}),
+		testIssue(3, []string{"bug"}, []string{"bob", "carol"}),
+		testIssue(4, []string{"feature"}, []string{"alice"}),
+		testIssue(5, []string{"bug"}, nil),
+		testIssue(6, nil, nil),
+	}
+	tests := []struct {
+		name      string
+		labels    []string
+		assignees []string
+		expected  []int
+	}{
+		{"no filter", nil, nil, []int{1, 2, 3, 4, 5, 6}},
+		{"one of the assignees", nil, []string{"alice"}, []int{1, 2, 4}},
+		{"case-insensitive login", nil, []string{"ALICE"}, []int{1, 2, 4}},
+		{"several logins", nil, []string{"carol", "alice"}, []int{1, 2, 3, 4}},
+		{"unassigned dropped", nil, []string{"bob"}, []int{2, 3}},
+		{"unassigned kept with none", nil, []string{"bob", "none"}, []int{2, 3, 5, 6}},
+		{"only unassigned", nil, []string{"none"}, []int{5, 6}},
+		{"labels and assignees", []string{"bug"}, []string{"alice"}, []int{1, 2}},
+		{"labels and none", []string{"bug"}, []string{"none"}, []int{5}},
+		{"no match", []string{"feature"}, []string{"bob"}, []int{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			computed := ComputeWithLinks(issues, []*model.Link{}, ParseOptions{})
+			computed.FilterByLabels(test.labels)
+			computed.FilterByAssignees(test.assignees)
+			if got := visibleNumbers(computed); !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestFilterByTargetsExclusions(t *testing.T) {
+	issues := model.Issues{
+		testIssue(1, nil, nil),
+		testIssue(2, nil, nil),
+		testIssue(3, nil, nil),
+	}
+	tests := []struct {
+		name     string
+		args     []string
+		expected []int
+	}{
+		{"repository", []string{"moul/depviz"}, []int{1, 2, 3}},
+		{"excluded issue", []string{"moul/depviz", "-moul/depviz#2"}, []int{1, 3}},
+		{"exclusion first", []string{"-moul/depviz#2", "-moul/depviz#3", "moul/depviz"}, []int{1}},
+		{"owner without a repository", []string{"moul", "-moul/depviz"}, []int{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			targets, err := model.ParseTargets(test.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			computed := ComputeWithLinks(issues, []*model.Link{}, ParseOptions{})
+			computed.FilterByTargets(targets)
+			if got := visibleNumbers(computed); !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestUnregisteredHost(t *testing.T) {
+	unregistered := testIssue(1, nil, nil)
+	unregistered.URL = "https://git.unregistered.example.com/moul/depviz/issues/1"
+	unregistered.ID = unregistered.URL
+	unregistered.Body = "Depends on #2"
+	unregistered.Comments = []string{"Blocked by #3"}
+	issues := model.Issues{unregistered, testIssue(2, nil, nil)}
+
+	computed := Compute(issues, ParseOptions{}) // parses the bodies
+	if len(computed.imap[unregistered.URL].Errs) == 0 {
+		t.Errorf("expected the unparsable URL to be reported")
+	}
+	targets, err := model.ParseTargets([]string{"moul/depviz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	computed.FilterByTargets(targets)
+	if got := visibleNumbers(computed); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("expected [2], got %v", got)
+	}
+}
@@ -0,0 +1,52 @@
+package compute
+
+import (
+	"regexp"
+	"strings"
+
+	"moul.io/multipmuri"
+)
+
+const (
+	DefaultDependsOnKeywords = "depends on,depends-on"
+	DefaultBlockedByKeywords = "blocked by"
+)
+
+var keywordReferenceRegex = regexp.MustCompile(`(https?://[^\s,;)]+|[\w.-]+/[\w.-]+#\d+|#\d+)`)
+
+// keywordsRegex matches the lines starting with one of the keywords (case
+// insensitive), optionally in a list item and followed by a colon.
+func keywordsRegex(keywords []string) *regexp.Regexp {
+	quoted := []string{}
+	for _, keyword := range keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			quoted = append(quoted, regexp.QuoteMeta(keyword))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?im)^\s*(?:[-*+]\s+)?(?:` + strings.Join(quoted, "|") + `)\s*:?\s+(.+)$`)
+}
+
+// parseKeywordDependencies returns the issues referenced (i.e., "#42",
+// "moul/depviz#42" or a full URL) on the lines starting with a depends-on or a
+// blocked-by keyword: in both cases, the issue depends on the referenced ones.
+func parseKeywordDependencies(context multipmuri.Entity, body string, opts ParseOptions) []multipmuri.Entity {
+	dependencies := []multipmuri.Entity{}
+	keywords := append(append([]string{}, opts.DependsOnKeywords...), opts.BlockedByKeywords...)
+	regex := keywordsRegex(keywords)
+	if regex == nil {
+		return dependencies
+	}
+	for _, match := range regex.FindAllStringSubmatch(body, -1) {
+		for _, reference := range keywordReferenceRegex.FindAllString(match[1], -1) {
+			entity, err := context.RelDecodeString(reference)
+			if err != nil {
+				continue
+			}
+			dependencies = append(dependencies, entity)
+		}
+	}
+	return dependencies
+}
@@ -0,0 +1,70 @@
+package compute
+
+import (
+	"reflect"
+	"testing"
+
+	"moul.io/depviz/model"
+)
+
+func TestParseKeywordDependencies(t *testing.T) {
+	context, err := model.ParseTarget("https://github.com/moul/depviz/issues/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults := DefaultParseOptions()
+	custom := DefaultParseOptions()
+	custom.DependsOnKeywords = []string{"needs"}
+	custom.BlockedByKeywords = []string{"waiting for"}
+
+	tests := []struct {
+		name     string
+		body     string
+		opts     ParseOptions
+		expected []string
+	}{
+		{"depends on", "Depends on #2", defaults, []string{"https://github.com/moul/depviz/issues/2"}},
+		{"depends-on with a colon", "depends-on: #2, #3", defaults, []string{"https://github.com/moul/depviz/issues/2", "https://github.com/moul/depviz/issues/3"}},
+		{"mixed casing", "DePeNdS oN #2", defaults, []string{"https://github.com/moul/depviz/issues/2"}},
+		{"blocked by", "Blocked by #4", defaults, []string{"https://github.com/moul/depviz/issues/4"}},
+		{"list item and full URL", "- blocked BY: https://github.com/moul/other/issues/5", defaults, []string{"https://github.com/moul/other/issues/5"}},
+		{"other repository", "depends on moul/other#6", defaults, []string{"https://github.com/moul/other/issues/6"}},
+		{"not at the line start", "this depends on #2", defaults, []string{}},
+		{"custom depends-on keyword", "Needs #7", custom, []string{"https://github.com/moul/depviz/issues/7"}},
+		{"custom blocked-by keyword", "WAITING FOR #8", custom, []string{"https://github.com/moul/depviz/issues/8"}},
+		{"default keyword replaced", "Depends on #2", custom, []string{}},
+		{"no keyword", "Depends on #2", ParseOptions{}, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := []string{}
+			for _, entity := range parseKeywordDependencies(context, test.body, test.opts) {
+				got = append(got, entity.String())
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestDefaultBodyParsing(t *testing.T) {
+	if !DefaultParseOptions().DefaultBodyParsing() {
+		t.Errorf("expected the defaults to parse like the defaults")
+	}
+	opts := DefaultParseOptions()
+	opts.ParseTaskLists = false // applied on the stored links
+	opts.DependsOnKeywords = []string{" Depends On", "depends-on"}
+	if !opts.DefaultBodyParsing() {
+		t.Errorf("expected the keyword comparison to be case-insensitive")
+	}
+	opts.BlockedByKeywords = []string{"blocked by", "needs"}
+	if opts.DefaultBodyParsing() {
+		t.Errorf("expected a custom blocked-by keyword to require parsing")
+	}
+	opts = DefaultParseOptions()
+	opts.MaxBodyScanBytes = 0
+	if opts.DefaultBodyParsing() {
+		t.Errorf("expected a custom body size limit to require parsing")
+	}
+}
@@ -0,0 +1,106 @@
+package compute
+
+// Metrics are key indicators of the backlog health.
+type Metrics struct {
+	Open     int
+	Closed   int
+	Blockers int // open issues blocking at least another open issue
+
+	// CriticalPath is the number of open issues in the longest chain of open
+	// dependencies.
+	CriticalPath int
+
+	// Unestimated is the number of open issues without estimate.
+	Unestimated int
+}
+
+// Metrics computes the metrics of the visible issues, estimated tells whether
+// an issue has an estimate.
+func (computed *Computed) Metrics(estimated func(*ComputedIssue) bool) Metrics {
+	metrics := Metrics{}
+	open := map[string]*ComputedIssue{}
+	for _, issue := range computed.Issues() {
+		if issue.State == "closed" {
+			metrics.Closed++
+			continue
+		}
+		metrics.Open++
+		open[issue.URL] = issue
+		if !estimated(issue) {
+			metrics.Unestimated++
+		}
+	}
+
+	blockers := map[string]bool{}
+	for _, issue := range open {
+		for _, dependency := range issue.DependsOn {
+			if _, found := open[dependency]; found {
+				blockers[dependency] = true
+			}
+		}
+	}
+	metrics.Blockers = len(blockers)
+
+	// longest chain, cycles are cut where they are detected
+	depths := map[string]int{}
+	visiting := map[string]bool{}
+	var depth func(url string) int
+	depth = func(url string) int {
+		if d, found := depths[url]; found {
+			return d
+		}
+		if visiting[url] {
+			return 0
+		}
+		visiting[url] = true
+		max := 0
+		for _, dependency := range open[url].DependsOn {
+			if _, found := open[dependency]; !found {
+				continue
+			}
+			if d := depth(dependency); d > max {
+				max = d
+			}
+		}
+		visiting[url] = false
+		depths[url] = max + 1
+		return max + 1
+	}
+	for url := range open {
+		if d := depth(url); d > metrics.CriticalPath {
+			metrics.CriticalPath = d
+		}
+	}
+	return metrics
+}
+
+// Diameter returns the length (in edges) of the longest shortest dependency
+// path between two visible issues, and its endpoints: from depends
+// (transitively) on to. It runs a breadth-first search from every issue, in
+// O(issues × links), so it is only computed on demand.
+func (computed *Computed) Diameter() (length int, from string, to string) {
+	visible := map[string]*ComputedIssue{}
+	for _, issue := range computed.Issues() {
+		visible[issue.URL] = issue
+	}
+	for _, start := range computed.Issues() {
+		distances := map[string]int{start.URL: 0}
+		for queue := []string{start.URL}; len(queue) > 0; queue = queue[1:] {
+			current := queue[0]
+			for _, dependency := range visible[current].DependsOn {
+				if _, found := visible[dependency]; !found {
+					continue
+				}
+				if _, seen := distances[dependency]; seen {
+					continue
+				}
+				distances[dependency] = distances[current] + 1
+				if distances[dependency] > length {
+					length, from, to = distances[dependency], start.URL, dependency
+				}
+				queue = append(queue, dependency)
+			}
+		}
+	}
+	return length, from, to
+}
@@ -1,6 +1,11 @@
 package compute
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
 	"moul.io/depviz/model"
 	"moul.io/multipmuri"
 	"moul.io/multipmuri/pmbodyparser"
@@ -15,36 +20,161 @@ type ComputedIssue struct {
 	DirectMatchWithTarget bool
 	Hidden                bool
 	DependsOn             []string
+	Fixes                 []string // issues fixed by this PR
+	Type                  string   // see DetectTypes()
 	Relationships         pmbodyparser.Relationships
+	TaskList              []TaskListItem
+	Satisfied             []string // dependencies checked in the task list
 	Errs                  []error
+
+	keywordDependencies []multipmuri.Entity // see --depends-on-keywords
+	parseTaskLists      bool
 }
 
-func (i ComputedIssue) MultipmuriEntity() multipmuri.Entity {
+// MultipmuriEntity parses the URL stored in the database, it fails if the
+// host is not registered (see --github-base-url, --gitea-hosts, --jira-base-url).
+func (i ComputedIssue) MultipmuriEntity() (multipmuri.Entity, error) {
 	// FIXME: can be optimized by creating object directly
-	entity, err := multipmuri.DecodeString(i.URL)
+	return model.ParseTarget(i.URL)
+}
+
+// Number returns the issue/PR number, parsed from its URL.
+func (i ComputedIssue) Number() int {
+	parts := strings.Split(strings.TrimRight(i.URL, "/"), "/")
+	number, err := strconv.Atoi(parts[len(parts)-1])
 	if err != nil {
-		panic(err)
+		return 0
 	}
-	return entity
+	return number
 }
 
 func newComputedIssue(issue *model.Issue) *ComputedIssue {
 	return &ComputedIssue{
 		Issue:     *issue,
 		DependsOn: []string{},
+		Fixes:     []string{},
+		Satisfied: []string{},
 		Errs:      []error{},
 	}
 }
 
-func (i *ComputedIssue) parseBody() {
+func (i *ComputedIssue) parseBody(opts ParseOptions) {
+	body := i.Body
+	if opts.MaxBodyScanBytes > 0 && len(body) > opts.MaxBodyScanBytes {
+		// cut on a line boundary to avoid parsing half a reference
+		body = body[:opts.MaxBodyScanBytes]
+		if idx := strings.LastIndex(body, "\n"); idx > 0 {
+			body = body[:idx]
+		}
+		zap.L().Info("truncated issue body for scanning",
+			zap.String("issue", i.URL),
+			zap.Int("size", len(i.Body)),
+			zap.Int("scanned", len(body)),
+		)
+	}
+	entity, err := i.MultipmuriEntity()
+	if err != nil {
+		i.Errs = append(i.Errs, err)
+		return
+	}
 	relationships, errs := pmbodyparser.RelParseString(
-		i.MultipmuriEntity(),
-		i.Body,
+		entity,
+		body,
 	)
 	if errs != nil && len(errs) > 0 {
 		i.Errs = append(i.Errs, errs...)
 	}
 	i.Relationships = relationships
+	i.keywordDependencies = parseKeywordDependencies(entity, body, opts)
+	i.TaskList = ParseTaskList(entity, body)
+	i.parseTaskLists = opts.ParseTaskLists
+	i.TasksDone, i.TasksTotal = TaskCompletion(i.TaskList)
+}
+
+// links converts the parsed relationships to links.
+func (i *ComputedIssue) links() []*model.Link {
+	links := []*model.Link{}
+	for _, relationship := range i.Relationships {
+		links = append(links, relationshipLink(i.URL, relationship, "body"))
+	}
+	if i.parseTaskLists {
+		for _, item := range i.TaskList {
+			if item.Reference == nil {
+				continue
+			}
+			provenance := taskListProvenance
+			if item.Done {
+				provenance = taskListDoneProvenance
+			}
+			links = append(links, model.NewLink(i.URL, model.ParentOfLink, item.Reference.String(), provenance))
+		}
+	}
+	seen := map[string]bool{}
+	for _, link := range links {
+		if link.Kind == model.DependsOnLink {
+			seen[link.TargetID] = true
+		}
+	}
+	for _, dependency := range i.keywordDependencies {
+		if target := dependency.String(); !seen[target] && target != i.URL {
+			seen[target] = true
+			links = append(links, model.NewLink(i.URL, model.DependsOnLink, target, "keyword"))
+		}
+	}
+	return links
+}
+
+// commentLinks parses the comments fetched with --scan-comments.
+func (i *ComputedIssue) commentLinks() []*model.Link {
+	links := []*model.Link{}
+	entity, err := i.MultipmuriEntity()
+	if err != nil { // already reported by parseBody
+		return links
+	}
+	for _, comment := range i.Comments {
+		relationships, errs := pmbodyparser.RelParseString(entity, comment)
+		if len(errs) > 0 {
+			i.Errs = append(i.Errs, errs...)
+		}
+		for _, relationship := range relationships {
+			links = append(links, relationshipLink(i.URL, relationship, "comment"))
+		}
+	}
+	return links
+}
+
+// dependencyLinks converts the dependencies reported by the provider API.
+func (i *ComputedIssue) dependencyLinks() []*model.Link {
+	links := []*model.Link{}
+	for _, dependency := range i.Dependencies {
+		links = append(links, model.NewLink(i.URL, model.DependsOnLink, dependency, "api"))
+	}
+	return links
+}
+
+func relationshipLink(source string, relationship pmbodyparser.Relationship, provenance string) *model.Link {
+	var kind model.LinkKind
+	switch relationship.Kind {
+	case pmbodyparser.Blocks:
+		kind = model.BlocksLink
+	case pmbodyparser.Fixes:
+		kind = model.FixesLink
+	case pmbodyparser.Closes:
+		kind = model.ClosesLink
+	case pmbodyparser.Addresses:
+		kind = model.AddressesLink
+	case pmbodyparser.PartOf:
+		kind = model.PartOfLink
+	case pmbodyparser.DependsOn:
+		kind = model.DependsOnLink
+	case pmbodyparser.ParentOf:
+		kind = model.ParentOfLink
+	case pmbodyparser.RelatedWith:
+		kind = model.RelatedWithLink
+	default:
+		panic(fmt.Errorf("unsupported pmbodyparser.Kind: %q", relationship.Kind))
+	}
+	return model.NewLink(source, kind, relationship.Target.String(), provenance)
 }
 
 //
@@ -58,13 +188,11 @@ type ComputedMilestone struct {
 	DependsOn             []string
 }
 
-func (m ComputedMilestone) MultipmuriEntity() multipmuri.Entity {
+// MultipmuriEntity parses the URL stored in the database, it fails if the
+// host is not registered (see --github-base-url, --gitea-hosts, --jira-base-url).
+func (m ComputedMilestone) MultipmuriEntity() (multipmuri.Entity, error) {
 	// FIXME: can be optimized by creating object directly
-	entity, err := multipmuri.DecodeString(m.URL)
-	if err != nil {
-		panic(err)
-	}
-	return entity
+	return model.ParseTarget(m.URL)
 }
 
 func newComputedMilestone(milestone *model.Milestone) *ComputedMilestone {
@@ -92,13 +220,11 @@ type ComputedRepo struct {
 	DependsOn             []string
 }
 
-func (r ComputedRepo) MultipmuriEntity() multipmuri.Entity {
+// MultipmuriEntity parses the URL stored in the database, it fails if the
+// host is not registered (see --github-base-url, --gitea-hosts, --jira-base-url).
+func (r ComputedRepo) MultipmuriEntity() (multipmuri.Entity, error) {
 	// FIXME: can be optimized by creating object directly
-	entity, err := multipmuri.DecodeString(r.URL)
-	if err != nil {
-		panic(err)
-	}
-	return entity
+	return model.ParseTarget(r.URL)
 }
 
 func newComputedRepo(repo *model.Repository) *ComputedRepo {
@@ -0,0 +1,65 @@
+package compute
+
+import (
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+const DefaultMaxBodyScanBytes = 64 * 1024
+
+// ParseOptions configures how issue bodies are parsed for relationships.
+type ParseOptions struct {
+	MaxBodyScanBytes  int      `mapstructure:"max-body-scan-bytes"`
+	DependsOnKeywords []string `mapstructure:"depends-on-keywords"`
+	BlockedByKeywords []string `mapstructure:"blocked-by-keywords"`
+	ParseTaskLists    bool     `mapstructure:"parse-task-lists"`
+}
+
+// ParseFlags registers the parsing flags, it can be called several times on
+// the same flag set by commands sharing it (i.e., 'run').
+func (opts *ParseOptions) ParseFlags(flags *pflag.FlagSet) {
+	if flags.Lookup("max-body-scan-bytes") == nil {
+		flags.IntVarP(&opts.MaxBodyScanBytes, "max-body-scan-bytes", "", DefaultMaxBodyScanBytes, "maximum number of bytes of each issue body scanned for references (0 means unlimited)")
+	}
+	if flags.Lookup("depends-on-keywords") == nil {
+		flags.StringSliceVarP(&opts.DependsOnKeywords, "depends-on-keywords", "", strings.Split(DefaultDependsOnKeywords, ","), "line prefixes declaring the dependencies of an issue (case insensitive)")
+	}
+	if flags.Lookup("blocked-by-keywords") == nil {
+		flags.StringSliceVarP(&opts.BlockedByKeywords, "blocked-by-keywords", "", strings.Split(DefaultBlockedByKeywords, ","), "line prefixes declaring the issues blocking an issue (case insensitive)")
+	}
+	if flags.Lookup("parse-task-lists") == nil {
+		flags.BoolVarP(&opts.ParseTaskLists, "parse-task-lists", "", true, "consider the issues referenced in the task lists (\"- [ ] #42\") as children, checked items are satisfied dependencies")
+	}
+}
+
+// DefaultParseOptions returns the defaults of the parsing flags.
+func DefaultParseOptions() ParseOptions {
+	return ParseOptions{
+		MaxBodyScanBytes:  DefaultMaxBodyScanBytes,
+		DependsOnKeywords: strings.Split(DefaultDependsOnKeywords, ","),
+		BlockedByKeywords: strings.Split(DefaultBlockedByKeywords, ","),
+		ParseTaskLists:    true,
+	}
+}
+
+// DefaultBodyParsing returns whether the options parse the issue bodies like
+// the defaults, the links stored by 'pull' can only be reused then.
+func (opts ParseOptions) DefaultBodyParsing() bool {
+	defaults := DefaultParseOptions()
+	return opts.MaxBodyScanBytes == defaults.MaxBodyScanBytes &&
+		sameKeywords(opts.DependsOnKeywords, defaults.DependsOnKeywords) &&
+		sameKeywords(opts.BlockedByKeywords, defaults.BlockedByKeywords)
+}
+
+func sameKeywords(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if !strings.EqualFold(strings.TrimSpace(a[idx]), strings.TrimSpace(b[idx])) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package compute
+
+import (
+	"regexp"
+	"strings"
+
+	"moul.io/multipmuri"
+)
+
+var (
+	taskListItemRegex  = regexp.MustCompile(`^(\s*)(?:[-*+]|\d+[.)])\s+\[([ xX])\]\s+(.*)$`)
+	taskReferenceRegex = regexp.MustCompile(`^(https?://[^\s)]+|[\w.-]+/[\w.-]+#\d+|#\d+)`)
+	taskLinkRegex      = regexp.MustCompile(`^\[[^\]]*\]\((https?://[^\s)]+)\)`)
+)
+
+const (
+	taskListProvenance     = "tasklist"
+	taskListDoneProvenance = "tasklist-done" // the checkbox is checked
+)
+
+// TaskListItem is a markdown checklist item ("- [ ] foo", "- [x] #42").
+type TaskListItem struct {
+	Done  bool
+	Text  string
+	Depth int // nesting level, 0 for top-level items
+
+	// Reference is set when the item points to another issue, such items
+	// are dependencies instead of plain sub-tasks.
+	Reference multipmuri.Entity
+}
+
+// ParseTaskList extracts the checklist items of a markdown body, nested items
+// are returned flattened with their depth.
+func ParseTaskList(context multipmuri.Entity, body string) []TaskListItem {
+	items := []TaskListItem{}
+	indents := []int{}
+	inCodeBlock := false
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+		match := taskListItemRegex.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+		indent := len(strings.Replace(match[1], "\t", "    ", -1))
+		for len(indents) > 0 && indents[len(indents)-1] >= indent {
+			indents = indents[:len(indents)-1]
+		}
+		item := TaskListItem{
+			Done:  match[2] != " ",
+			Text:  strings.TrimSpace(match[3]),
+			Depth: len(indents),
+		}
+		indents = append(indents, indent)
+		ref := taskReferenceRegex.FindString(item.Text)
+		if link := taskLinkRegex.FindStringSubmatch(item.Text); link != nil {
+			ref = link[1]
+		}
+		if ref != "" {
+			if entity, err := context.RelDecodeString(ref); err == nil {
+				item.Reference = entity
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// TaskCompletion returns the number of done and total plain sub-tasks, items
+// referencing other issues are not counted as they are dependencies.
+func TaskCompletion(items []TaskListItem) (done int, total int) {
+	for _, item := range items {
+		if item.Reference != nil {
+			continue
+		}
+		total++
+		if item.Done {
+			done++
+		}
+	}
+	return done, total
+}
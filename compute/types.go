@@ -0,0 +1,90 @@
+package compute
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// TypeRule detects an issue type (i.e., bug, feature, epic) from the labels or
+// the title of the issues, i.e.:
+//
+//	types:
+//	  - name: bug
+//	    labels: [bug, "type: bug"]
+//	    title-prefixes: ["fix:", "[bug]"]
+//	  - name: epic
+//	    labels: ["epic*"]
+//	    shape: folder
+type TypeRule struct {
+	Name          string   `mapstructure:"name"`
+	Labels        []string `mapstructure:"labels"`         // glob patterns, case-insensitive
+	TitlePrefixes []string `mapstructure:"title-prefixes"` // case-insensitive
+	Shape         string   `mapstructure:"shape"`          // optional dot shape
+}
+
+// matches returns true if the issue has a matching label or title prefix.
+func (r TypeRule) matches(issue *ComputedIssue) bool {
+	for _, pattern := range r.Labels {
+		for _, label := range issue.Labels {
+			if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(label.Name)); matched {
+				return true
+			}
+		}
+	}
+	title := strings.ToLower(strings.TrimSpace(issue.Title))
+	for _, prefix := range r.TitlePrefixes {
+		if strings.HasPrefix(title, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTypeRules checks the label patterns of the rules.
+func ValidateTypeRules(rules []TypeRule) error {
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("type rules require a name")
+		}
+		for _, pattern := range rule.Labels {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid label pattern %q of type %q: %v", pattern, rule.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// DetectTypes sets the type of each issue, the first matching rule wins,
+// issues not matching any rule fall back on "issue" or "pull-request".
+func (computed *Computed) DetectTypes(rules []TypeRule) {
+	for _, issue := range computed.AllIssues {
+		issue.Type = "issue"
+		if issue.IsPR {
+			issue.Type = "pull-request"
+		}
+		for _, rule := range rules {
+			if rule.matches(issue) {
+				issue.Type = rule.Name
+				break
+			}
+		}
+	}
+}
+
+// FilterByTypes hides the issues whose type is not one of the given types.
+func (computed *Computed) FilterByTypes(types []string) {
+	if len(types) == 0 {
+		return
+	}
+	wanted := map[string]bool{}
+	for _, t := range types {
+		wanted[strings.ToLower(t)] = true
+	}
+	for _, issue := range computed.AllIssues {
+		if !wanted[strings.ToLower(issue.Type)] {
+			issue.Hidden = true
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package gitea // import "moul.io/depviz/gitea"
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// the subset of the Gitea API v1 used by depviz, see https://try.gitea.io/api/swagger
+
+type apiUser struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	FullName  string `json:"full_name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type apiLabel struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
+type apiMilestone struct {
+	ID          int64      `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	State       string     `json:"state"`
+	Closed      *time.Time `json:"closed_at"`
+	Deadline    *time.Time `json:"due_on"`
+}
+
+type apiIssue struct {
+	ID          int64         `json:"id"`
+	Number      int           `json:"number"`
+	HTMLURL     string        `json:"html_url"`
+	Title       string        `json:"title"`
+	Body        string        `json:"body"`
+	State       string        `json:"state"`
+	User        *apiUser      `json:"user"`
+	Labels      []*apiLabel   `json:"labels"`
+	Milestone   *apiMilestone `json:"milestone"`
+	Assignees   []*apiUser    `json:"assignees"`
+	Comments    int           `json:"comments"`
+	IsLocked    bool          `json:"is_locked"`
+	Created     time.Time     `json:"created_at"`
+	Updated     time.Time     `json:"updated_at"`
+	Closed      *time.Time    `json:"closed_at"`
+	PullRequest *struct {
+		Merged bool `json:"merged"`
+	} `json:"pull_request"`
+	Repository *struct {
+		Owner string `json:"owner"`
+		Name  string `json:"name"`
+	} `json:"repository"`
+}
+
+type client struct {
+	httpClient *http.Client
+	baseURL    string // i.e., "https://gitea.example.com"
+	token      string
+}
+
+func newClient(httpClient *http.Client, baseURL, token string) *client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &client{httpClient: httpClient, baseURL: baseURL, token: token}
+}
+
+// get decodes the response of GET /api/v1{path} in out.
+func (c *client) get(path string, out interface{}) error {
+	req, err := http.NewRequest("GET", c.baseURL+"/api/v1"+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Message != "" {
+			return fmt.Errorf("%s: %s", resp.Status, apiErr.Message)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
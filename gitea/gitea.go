@@ -0,0 +1,100 @@
+package gitea // import "moul.io/depviz/gitea"
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"go.uber.org/zap"
+	"moul.io/depviz/model"
+	"moul.io/depviz/sql"
+	"moul.io/multipmuri"
+)
+
+// Pull fetches the issues and pull requests of a Gitea repository, with the
+// dependencies declared with the Gitea dependency feature.
+//
+// Gitea targets are parsed as GitHub entities (same URL layout), on a host
+// registered with model.RegisterGiteaHosts.
+func Pull(input multipmuri.Entity, wg *sync.WaitGroup, token string, fetchOpts model.FetchOptions, db *gorm.DB, out chan<- []*model.Issue) {
+	defer wg.Done()
+	type multipmuriMinimalInterface interface {
+		Repo() *multipmuri.GitHubRepo
+	}
+	target, ok := input.(multipmuriMinimalInterface)
+	if !ok {
+		zap.L().Warn("invalid input", zap.String("input", fmt.Sprintf("%v", input.String())))
+		return
+	}
+	repo := target.Repo()
+	u, err := url.Parse(repo.String())
+	if err != nil {
+		zap.L().Warn("invalid input", zap.String("input", repo.String()), zap.Error(err))
+		return
+	}
+	serviceURL := fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+	client := newClient(fetchOpts.HTTPClient, serviceURL, token)
+
+	since := ""
+	if lastSync := sql.PullSince(db, repo.String(), fetchOpts.Full); !lastSync.IsZero() {
+		since = "&since=" + url.QueryEscape(lastSync.Format(time.RFC3339))
+	}
+
+	total := 0
+	for page := 1; ; page++ {
+		var issues []*apiIssue
+		path := fmt.Sprintf("/repos/%s/%s/issues?state=all&limit=50&page=%d%s", repo.OwnerID(), repo.RepoID(), page, since)
+		err := model.Retry(fetchOpts.Retries, func() error {
+			err := client.get(path, &issues)
+			if err != nil {
+				zap.L().Debug("failed to pull issues, retrying", zap.String("repo", repo.String()), zap.Int("page", page), zap.Error(err))
+			}
+			return err
+		})
+		if err != nil {
+			zap.L().Error("failed to pull issues", zap.String("repo", repo.String()), zap.Error(err))
+			fetchOpts.Failures.Add(model.FetchFailure{Provider: "gitea", Repo: repo.String(), Page: page, Err: err})
+			return
+		}
+		total += len(issues)
+		zap.L().Debug("paginate",
+			zap.String("provider", "gitea"),
+			zap.String("repo", repo.String()),
+			zap.Int("new-issues", len(issues)),
+			zap.Int("total-issues", total),
+		)
+		normalizedIssues := []*model.Issue{}
+		for _, issue := range issues {
+			normalizedIssue := FromIssue(issue, serviceURL, repo.String())
+			normalizedIssue.Dependencies = fetchDependencies(client, serviceURL, repo, issue.Number)
+			normalizedIssues = append(normalizedIssues, normalizedIssue)
+		}
+		out <- normalizedIssues
+		if len(issues) == 0 {
+			return
+		}
+	}
+}
+
+// fetchDependencies returns the URLs of the issues blocking an issue, the
+// endpoint is missing before Gitea 1.17 and when the dependencies are disabled.
+func fetchDependencies(client *client, serviceURL string, repo *multipmuri.GitHubRepo, number int) []string {
+	var blockers []*apiIssue
+	path := fmt.Sprintf("/repos/%s/%s/issues/%d/dependencies", repo.OwnerID(), repo.RepoID(), number)
+	// not retried, the endpoint may not exist
+	if err := client.get(path, &blockers); err != nil {
+		zap.L().Debug("failed to pull dependencies", zap.String("repo", repo.String()), zap.Int("issue", number), zap.Error(err))
+		return []string{}
+	}
+	dependencies := []string{}
+	for _, blocker := range blockers {
+		repoURL := repo.String()
+		if blocker.Repository != nil {
+			repoURL = fmt.Sprintf("%s/%s/%s", serviceURL, blocker.Repository.Owner, blocker.Repository.Name)
+		}
+		dependencies = append(dependencies, fmt.Sprintf("%s/issues/%d", repoURL, blocker.Number))
+	}
+	return dependencies
+}
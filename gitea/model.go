@@ -0,0 +1,124 @@
+package gitea // import "moul.io/depviz/gitea"
+
+import (
+	"fmt"
+
+	"moul.io/depviz/model"
+)
+
+// FromIssue converts a Gitea issue or pull request, repoURL is the web URL of
+// the repository (i.e., "https://gitea.example.com/owner/repo").
+//
+// The pull requests are stored with their /issues/ URL (Gitea redirects it)
+// so they share the GitHub URL layout.
+func FromIssue(input *apiIssue, serviceURL, repoURL string) *model.Issue {
+	url := fmt.Sprintf("%s/issues/%d", repoURL, input.Number)
+	service := FromServiceURL(serviceURL)
+	repo := FromRepositoryURL(service, repoURL)
+	issue := &model.Issue{
+		Base: model.Base{
+			ID:        url,
+			URL:       url,
+			CreatedAt: input.Created,
+			UpdatedAt: input.Updated,
+		},
+		Title:        input.Title,
+		State:        input.State,
+		Body:         input.Body,
+		IsPR:         input.PullRequest != nil,
+		IsLocked:     input.IsLocked,
+		NumComments:  input.Comments,
+		Repository:   repo,
+		Service:      service,
+		Labels:       make([]*model.Label, 0),
+		Assignees:    make([]*model.Account, 0),
+		Author:       FromUser(service, input.User),
+		Milestone:    FromMilestone(repo, input.Milestone),
+		Dependencies: []string{},
+	}
+	if input.Closed != nil {
+		issue.CompletedAt = *input.Closed
+	}
+	for _, label := range input.Labels {
+		issue.Labels = append(issue.Labels, FromLabel(repo, label))
+	}
+	for _, assignee := range input.Assignees {
+		issue.Assignees = append(issue.Assignees, FromUser(service, assignee))
+	}
+	return issue
+}
+
+func FromServiceURL(input string) *model.Provider {
+	return &model.Provider{
+		Base: model.Base{
+			ID:  input,
+			URL: input,
+		},
+		Driver: string(model.GiteaDriver),
+	}
+}
+
+func FromRepositoryURL(service *model.Provider, input string) *model.Repository {
+	return &model.Repository{
+		Base: model.Base{
+			ID:  input,
+			URL: input,
+		},
+		Provider: service,
+	}
+}
+
+func FromUser(service *model.Provider, input *apiUser) *model.Account {
+	if input == nil {
+		return nil
+	}
+	url := fmt.Sprintf("%s/%s", service.URL, input.Login)
+	return &model.Account{
+		Base: model.Base{
+			ID:  url,
+			URL: url,
+		},
+		Login:     input.Login,
+		FullName:  input.FullName,
+		Email:     input.Email,
+		AvatarURL: input.AvatarURL,
+		HTMLURL:   url,
+		Provider:  service,
+	}
+}
+
+func FromLabel(repository *model.Repository, input *apiLabel) *model.Label {
+	url := fmt.Sprintf("%s/labels/%s", repository.URL, input.Name)
+	return &model.Label{
+		Base: model.Base{
+			ID:  url,
+			URL: url,
+		},
+		Name:        input.Name,
+		Color:       input.Color,
+		Description: input.Description,
+	}
+}
+
+func FromMilestone(repository *model.Repository, input *apiMilestone) *model.Milestone {
+	if input == nil {
+		return nil
+	}
+	url := fmt.Sprintf("%s/milestone/%d", repository.URL, input.ID)
+	milestone := &model.Milestone{
+		Base: model.Base{
+			ID:  url,
+			URL: url,
+		},
+		Title:       input.Title,
+		Description: input.Description,
+		Repository:  repository,
+	}
+	if input.Closed != nil {
+		milestone.ClosedAt = *input.Closed
+	}
+	if input.Deadline != nil {
+		milestone.DueOn = *input.Deadline
+	}
+	return milestone
+}
@@ -0,0 +1,105 @@
+package github // import "moul.io/depviz/github"
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"go.uber.org/zap"
+	"moul.io/depviz/model"
+)
+
+// cacheTransport sends the GET requests with the ETag and Last-Modified of
+// the previous response, GitHub answers 304 for the unchanged resources and
+// does not count them in the rate limit. The 304 responses are replaced by
+// the cached ones so the pagination keeps working: each page is cached
+// separately and only the changed pages are downloaded. The incremental
+// requests are not cached: their "since" parameter changes on every pull so
+// the entries would never be reused.
+type cacheTransport struct {
+	db        *gorm.DB
+	transport http.RoundTripper
+}
+
+func newCacheTransport(db *gorm.DB, transport http.RoundTripper) *cacheTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &cacheTransport{db: db, transport: transport}
+}
+
+// cacheKey identifies a request, the credentials are part of the key as the
+// responses depend on the permissions of the token.
+func cacheKey(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Authorization")))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.URL.Query().Get("since") != "" {
+		return t.transport.RoundTrip(req)
+	}
+	key := cacheKey(req)
+	var entry model.HTTPCacheEntry
+	cached := t.db.Where("id = ?", key).First(&entry).Error == nil
+	if cached {
+		req = cloneRequest(req) // a RoundTripper should not modify the request
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case cached && resp.StatusCode == http.StatusNotModified:
+		zap.L().Debug("not modified, using the cached response", zap.String("url", entry.URL))
+		resp.Body.Close()
+		resp.StatusCode = http.StatusOK
+		resp.Status = http.StatusText(http.StatusOK)
+		resp.Header.Set("Content-Type", entry.ContentType)
+		if entry.Link != "" {
+			resp.Header.Set("Link", entry.Link)
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(entry.Body))
+		resp.ContentLength = int64(len(entry.Body))
+	case resp.StatusCode == http.StatusOK && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""):
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		entry = model.HTTPCacheEntry{
+			ID:           key,
+			URL:          req.URL.String(),
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			Link:         resp.Header.Get("Link"),
+			ContentType:  resp.Header.Get("Content-Type"),
+			Body:         body,
+		}
+		if err := t.db.Save(&entry).Error; err != nil {
+			zap.L().Warn("failed to cache the response", zap.String("url", entry.URL), zap.Error(err))
+		}
+	}
+	return resp, nil
+}
+
+func cloneRequest(req *http.Request) *http.Request {
+	clone := new(http.Request)
+	*clone = *req
+	clone.Header = make(http.Header, len(req.Header))
+	for key, values := range req.Header {
+		clone.Header[key] = append([]string{}, values...)
+	}
+	return clone
+}
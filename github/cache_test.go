@@ -0,0 +1,57 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/mattn/go-sqlite3" // required by gorm
+	"moul.io/depviz/model"
+)
+
+func TestCacheTransportSkipsIncrementalRequests(t *testing.T) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.AutoMigrate(model.HTTPCacheEntry{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	conditional := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newCacheTransport(db, nil)}
+	for _, path := range []string{"/issues", "/issues", "/issues?since=2020-01-01T00:00:00Z", "/issues?since=2020-01-02T00:00:00Z"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", path, resp.StatusCode)
+		}
+	}
+
+	if conditional != 1 {
+		t.Errorf("expected 1 conditional request, got %d", conditional)
+	}
+	var entries int
+	if err := db.Model(model.HTTPCacheEntry{}).Count(&entries).Error; err != nil {
+		t.Fatal(err)
+	}
+	if entries != 1 {
+		t.Errorf("expected the incremental requests not to be cached, got %d entries", entries)
+	}
+}
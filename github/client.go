@@ -0,0 +1,24 @@
+package github // import "moul.io/depviz/github"
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/go-github/github"
+	"golang.org/x/oauth2"
+)
+
+// newClient returns a client authenticated with token, baseURL is the API
+// URL of a GitHub Enterprise instance (i.e., "https://github.example.com/api/v3/"),
+// empty for github.com.
+func newClient(ctx context.Context, httpClient *http.Client, token, baseURL string) (*github.Client, error) {
+	if httpClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	}
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	tc := oauth2.NewClient(ctx, ts)
+	if baseURL == "" {
+		return github.NewClient(tc), nil
+	}
+	return github.NewEnterpriseClient(baseURL, baseURL, tc)
+}
@@ -0,0 +1,75 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNewClientEnterpriseBaseURL(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []*http.Request
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"number": 42, "title": "Enterprise issue"}`)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client, err := newClient(ctx, server.Client(), "TOKEN", server.URL+"/api/v3/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	issue, _, err := client.Issues.Get(ctx, "moul", "depviz", 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issue.GetTitle() != "Enterprise issue" {
+		t.Errorf("unexpected issue %v", issue)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(requests))
+	}
+	request := requests[0]
+	if host := strings.TrimPrefix(server.URL, "http://"); request.Host != host {
+		t.Errorf("expected the request to be sent to %q, got %q", host, request.Host)
+	}
+	if request.URL.Path != "/api/v3/repos/moul/depviz/issues/42" {
+		t.Errorf("unexpected request path %q", request.URL.Path)
+	}
+	if auth := request.Header.Get("Authorization"); auth != "Bearer TOKEN" {
+		t.Errorf("expected the token to be sent, got %q", auth)
+	}
+}
+
+func TestIssueURLRegex(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		url     string
+		matches bool
+	}{
+		{"", "https://github.com/moul/depviz/issues/42", true},
+		{"", "https://github.com/moul/depviz/pull/42", true},
+		{"", "https://github.example.com/moul/depviz/issues/42", false},
+		{"https://github.example.com/api/v3/", "https://github.example.com/moul/depviz/issues/42", true},
+		{"https://github.example.com/api/v3/", "https://github.com/moul/depviz/issues/42", false},
+		{"https://github.example.com/api/v3/", "https://github.example.com/moul/depviz", false},
+	}
+	for _, test := range tests {
+		if matches := issueURLRegex(test.baseURL).MatchString(test.url); matches != test.matches {
+			t.Errorf("issueURLRegex(%q) on %q: expected %v, got %v", test.baseURL, test.url, test.matches, matches)
+		}
+	}
+}
@@ -3,18 +3,19 @@ package github // import "moul.io/depviz/github"
 import (
 	"context"
 	"fmt"
-	"log"
+	"net/http"
 	"sync"
+	"time"
 
 	"github.com/google/go-github/github"
 	"github.com/jinzhu/gorm"
 	"go.uber.org/zap"
-	"golang.org/x/oauth2"
 	"moul.io/depviz/model"
+	"moul.io/depviz/sql"
 	"moul.io/multipmuri"
 )
 
-func Pull(input multipmuri.Entity, wg *sync.WaitGroup, token string, db *gorm.DB, out chan<- []*model.Issue) {
+func Pull(input multipmuri.Entity, wg *sync.WaitGroup, token string, fetchOpts model.FetchOptions, db *gorm.DB, out chan<- []*model.Issue) {
 	defer wg.Done()
 	type multipmuriMinimalInterface interface {
 		Repo() *multipmuri.GitHubRepo
@@ -28,24 +29,42 @@ func Pull(input multipmuri.Entity, wg *sync.WaitGroup, token string, db *gorm.DB
 
 	// create client
 	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
+	httpClient := fetchOpts.HTTPClient
+	if !fetchOpts.NoCache {
+		var transport http.RoundTripper
+		if httpClient != nil {
+			transport = httpClient.Transport
+		}
+		httpClient = &http.Client{Transport: newCacheTransport(db, transport)}
+	}
+	client, err := newClient(ctx, httpClient, token, fetchOpts.GithubBaseURL)
+	if err != nil {
+		zap.L().Error("failed to configure GitHub client", zap.Error(err))
+		return
+	}
 
 	// queries
 	totalIssues := 0
 	callOpts := &github.IssueListByRepoOptions{State: "all"}
-	var lastEntry model.Issue
-	if err := db.Where("repository_id = ?", repo.String()).Order("updated_at desc").First(&lastEntry).Error; err == nil {
-		callOpts.Since = lastEntry.UpdatedAt
-	} else {
-		zap.L().Warn("failed to get last entry", zap.String("repo", repo.String()), zap.Error(err))
-	}
+	startedAt := time.Now()
+	callOpts.Since = sql.PullSince(db, repo.String(), fetchOpts.Full)
 
 	for {
-		issues, resp, err := client.Issues.ListByRepo(ctx, repo.OwnerID(), repo.RepoID(), callOpts)
+		var (
+			issues []*github.Issue
+			resp   *github.Response
+		)
+		err := model.Retry(fetchOpts.Retries, func() error {
+			var err error
+			issues, resp, err = client.Issues.ListByRepo(ctx, repo.OwnerID(), repo.RepoID(), callOpts)
+			if err != nil {
+				zap.L().Debug("failed to pull issues, retrying", zap.String("repo", repo.String()), zap.Int("page", callOpts.Page), zap.Error(err))
+			}
+			return err
+		})
 		if err != nil {
-			log.Fatal(err)
+			zap.L().Error("failed to pull issues", zap.String("repo", repo.String()), zap.Error(err))
+			fetchOpts.Failures.Add(model.FetchFailure{Provider: "github", Repo: repo.String(), Page: callOpts.Page, Err: err})
 			return
 		}
 		totalIssues += len(issues)
@@ -57,7 +76,11 @@ func Pull(input multipmuri.Entity, wg *sync.WaitGroup, token string, db *gorm.DB
 		)
 		normalizedIssues := []*model.Issue{}
 		for _, issue := range issues {
-			normalizedIssues = append(normalizedIssues, FromIssue(issue))
+			normalizedIssue := FromIssue(issue)
+			if fetchOpts.ScanComments && issue.GetComments() > 0 {
+				normalizedIssue.Comments = fetchComments(ctx, client, repo, issue.GetNumber(), fetchOpts)
+			}
+			normalizedIssues = append(normalizedIssues, normalizedIssue)
 		}
 		out <- normalizedIssues
 		if resp.NextPage == 0 {
@@ -65,7 +88,40 @@ func Pull(input multipmuri.Entity, wg *sync.WaitGroup, token string, db *gorm.DB
 		}
 		callOpts.Page = resp.NextPage
 	}
+	// with "since", the reopened issues are returned as their update date
+	// changed, the deleted ones are only detected by a full pull
+	fetchOpts.Synced.Add(model.SyncedRepo{ID: repo.String(), StartedAt: startedAt, Full: callOpts.Since.IsZero()})
 	if rateLimits, _, err := client.RateLimits(ctx); err == nil {
 		zap.L().Debug("github API rate limiting", zap.Stringer("limit", rateLimits.GetCore()))
 	}
 }
+
+// fetchComments returns the bodies of the comments of an issue, failures are
+// recorded and the already fetched comments are kept.
+func fetchComments(ctx context.Context, client *github.Client, repo *multipmuri.GitHubRepo, number int, fetchOpts model.FetchOptions) []string {
+	comments := []string{}
+	callOpts := &github.IssueListCommentsOptions{}
+	for {
+		var (
+			page []*github.IssueComment
+			resp *github.Response
+		)
+		err := model.Retry(fetchOpts.Retries, func() error {
+			var err error
+			page, resp, err = client.Issues.ListComments(ctx, repo.OwnerID(), repo.RepoID(), number, callOpts)
+			return err
+		})
+		if err != nil {
+			zap.L().Error("failed to pull comments", zap.String("repo", repo.String()), zap.Int("issue", number), zap.Error(err))
+			fetchOpts.Failures.Add(model.FetchFailure{Provider: "github", Repo: fmt.Sprintf("%s#%d comments", repo.String(), number), Page: callOpts.Page, Err: err})
+			return comments
+		}
+		for _, comment := range page {
+			comments = append(comments, comment.GetBody())
+		}
+		if resp.NextPage == 0 {
+			return comments
+		}
+		callOpts.Page = resp.NextPage
+	}
+}
@@ -32,6 +32,7 @@ func FromUser(input *github.User) *model.Account {
 		Blog:      input.GetBlog(),
 		Email:     input.GetEmail(),
 		AvatarURL: input.GetAvatarURL(),
+		HTMLURL:   input.GetHTMLURL(),
 		Login:     input.GetLogin(),
 		FullName:  name,
 	}
@@ -129,8 +130,8 @@ func FromIssue(input *github.Issue) *model.Issue {
 		IsPR:            input.PullRequestLinks != nil,
 		IsLocked:        input.GetLocked(),
 		NumComments:     input.GetComments(),
-		NumUpvotes:      *input.Reactions.PlusOne,
-		NumDownvotes:    *input.Reactions.MinusOne,
+		NumUpvotes:      input.GetReactions().GetPlusOne(),
+		NumDownvotes:    input.GetReactions().GetMinusOne(),
 		Labels:          make([]*model.Label, 0),
 		Assignees:       make([]*model.Account, 0),
 		Author:          FromUser(input.User),
@@ -154,3 +155,22 @@ func FromServiceURL(input string) *model.Provider {
 		Driver: string(model.GithubDriver),
 	}
 }
+
+// FromPullRequest converts a pull request, i.e., from a webhook payload, the
+// labels are not part of the payload and are updated by the next pull.
+func FromPullRequest(input *github.PullRequest) *model.Issue {
+	return FromIssue(&github.Issue{
+		Number:           input.Number,
+		State:            input.State,
+		Title:            input.Title,
+		Body:             input.Body,
+		User:             input.User,
+		Assignees:        input.Assignees,
+		Milestone:        input.Milestone,
+		CreatedAt:        input.CreatedAt,
+		UpdatedAt:        input.UpdatedAt,
+		ClosedAt:         input.ClosedAt,
+		HTMLURL:          input.HTMLURL,
+		PullRequestLinks: &github.PullRequestLinks{HTMLURL: input.HTMLURL},
+	})
+}
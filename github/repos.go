@@ -0,0 +1,87 @@
+package github // import "moul.io/depviz/github"
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/github"
+	"go.uber.org/zap"
+	"moul.io/depviz/model"
+)
+
+// ListRepos returns the URLs of the repositories of an organization or a user,
+// the archived repositories and the forks are skipped unless included.
+func ListRepos(owner string, token string, fetchOpts model.FetchOptions, includeArchived, includeForks bool) ([]string, error) {
+	ctx := context.Background()
+	client, err := newClient(ctx, fetchOpts.HTTPClient, token, fetchOpts.GithubBaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var account *github.User
+	err = model.Retry(fetchOpts.Retries, func() error {
+		var (
+			resp *github.Response
+			err  error
+		)
+		account, resp, err = client.Users.Get(ctx, owner)
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil // not worth a retry
+		}
+		return err
+	})
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("cannot get the GitHub account %q: %v", owner, err)
+	case account == nil:
+		return nil, fmt.Errorf("GitHub account %q not found", owner)
+	}
+	isOrg := account.GetType() == "Organization"
+
+	urls := []string{}
+	skipped := 0
+	listOpts := github.ListOptions{PerPage: 100}
+	for {
+		var (
+			repos []*github.Repository
+			resp  *github.Response
+		)
+		err := model.Retry(fetchOpts.Retries, func() error {
+			var err error
+			if isOrg {
+				repos, resp, err = client.Repositories.ListByOrg(ctx, owner, &github.RepositoryListByOrgOptions{Type: "all", ListOptions: listOpts})
+			} else {
+				repos, resp, err = client.Repositories.List(ctx, owner, &github.RepositoryListOptions{Type: "owner", ListOptions: listOpts})
+			}
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot list the repositories of %q: %v", owner, err)
+		}
+		for _, repo := range repos {
+			if (repo.GetArchived() && !includeArchived) || (repo.GetFork() && !includeForks) {
+				skipped++
+				continue
+			}
+			urls = append(urls, repo.GetHTMLURL())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+	zap.L().Debug("owner repositories",
+		zap.String("owner", owner),
+		zap.Int("repos", len(urls)),
+		zap.Int("skipped", skipped),
+	)
+
+	if len(urls) == 0 {
+		if token == "" {
+			return nil, fmt.Errorf("no repository found for %q, the private repositories are only listed with a --github-token", owner)
+		}
+		return nil, fmt.Errorf("no repository found for %q (%d archived or forks skipped, see --include-archived and --include-forks)", owner, skipped)
+	}
+	return urls, nil
+}
@@ -0,0 +1,65 @@
+package github // import "moul.io/depviz/github"
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/google/go-github/github"
+	"go.uber.org/zap"
+	"moul.io/depviz/model"
+)
+
+// issueURLRegex returns the pattern of the issue URLs of the instance whose
+// API is at baseURL: github.com when empty, a GitHub Enterprise host
+// otherwise.
+func issueURLRegex(baseURL string) *regexp.Regexp {
+	host := "github.com"
+	if baseURL != "" {
+		if parsed, err := url.Parse(baseURL); err == nil && parsed.Host != "" {
+			host = parsed.Host
+		}
+	}
+	return regexp.MustCompile(`^https?://` + regexp.QuoteMeta(host) + `/([^/]+)/([^/]+)/(?:issues|pull)/(\d+)$`)
+}
+
+// ResolveTransfer returns the current URL of an issue that may have been
+// transferred to another repository: GitHub redirects the API requests of
+// transferred issues to their new location.
+// It returns an empty string if the issue was not transferred.
+func ResolveTransfer(issueURL string, token string, fetchOpts model.FetchOptions) (string, error) {
+	match := issueURLRegex(fetchOpts.GithubBaseURL).FindStringSubmatch(issueURL)
+	if match == nil {
+		return "", nil
+	}
+	number, err := strconv.Atoi(match[3])
+	if err != nil {
+		return "", nil
+	}
+
+	ctx := context.Background()
+	client, err := newClient(ctx, fetchOpts.HTTPClient, token, fetchOpts.GithubBaseURL)
+	if err != nil {
+		return "", err
+	}
+
+	var issue *github.Issue
+	err = model.Retry(fetchOpts.Retries, func() error {
+		var err error
+		issue, _, err = client.Issues.Get(ctx, match[1], match[2], number)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	entity, err := model.ParseTarget(issue.GetHTMLURL())
+	if err != nil {
+		return "", err
+	}
+	if entity.String() == issueURL {
+		return "", nil
+	}
+	zap.L().Debug("transferred issue", zap.String("old", issueURL), zap.String("new", entity.String()))
+	return entity.String(), nil
+}
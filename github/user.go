@@ -0,0 +1,20 @@
+package github // import "moul.io/depviz/github"
+
+import (
+	"context"
+)
+
+// AuthenticatedLogin returns the login of the owner of the token, baseURL is
+// the GitHub Enterprise API URL (empty for github.com).
+func AuthenticatedLogin(token, baseURL string) (string, error) {
+	ctx := context.Background()
+	client, err := newClient(ctx, nil, token, baseURL)
+	if err != nil {
+		return "", err
+	}
+	user, _, err := client.Users.Get(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	return user.GetLogin(), nil
+}
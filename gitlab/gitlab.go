@@ -2,16 +2,19 @@ package gitlab // import "moul.io/depviz/gitlab"
 
 import (
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/jinzhu/gorm"
 	gitlab "github.com/xanzy/go-gitlab"
 	"go.uber.org/zap"
 	"moul.io/depviz/model"
+	"moul.io/depviz/sql"
 	"moul.io/multipmuri"
 )
 
-func Pull(input multipmuri.Entity, wg *sync.WaitGroup, token string, db *gorm.DB, out chan<- []*model.Issue) {
+func Pull(input multipmuri.Entity, wg *sync.WaitGroup, token string, fetchOpts model.FetchOptions, db *gorm.DB, out chan<- []*model.Issue) {
 	defer wg.Done()
 	// parse input
 	type multipmuriMinimalInterface interface {
@@ -25,7 +28,7 @@ func Pull(input multipmuri.Entity, wg *sync.WaitGroup, token string, db *gorm.DB
 	repo := target.RepoEntity()
 
 	// create client
-	client := gitlab.NewClient(nil, token)
+	client := gitlab.NewClient(fetchOpts.HTTPClient, token)
 	if err := client.SetBaseURL(fmt.Sprintf("%s/api/v4", repo.ServiceEntity().String())); err != nil {
 		zap.L().Error("failed to configure GitLab client", zap.Error(err))
 		return
@@ -38,18 +41,27 @@ func Pull(input multipmuri.Entity, wg *sync.WaitGroup, token string, db *gorm.DB
 		},
 	}
 
-	var lastEntry model.Issue
-	if err := db.Where("repository_id = ?", repo.String()).Order("updated_at desc").First(&lastEntry).Error; err == nil {
-		gitlabOpts.UpdatedAfter = &lastEntry.UpdatedAt
+	if since := sql.PullSince(db, repo.String(), fetchOpts.Full); !since.IsZero() {
+		gitlabOpts.UpdatedAfter = &since
 	}
 
-	// FIXME: fetch PRs
-
+	path := fmt.Sprintf("%s/%s", repo.Owner(), repo.Repo())
 	for {
-		path := fmt.Sprintf("%s/%s", repo.Owner(), repo.Repo())
-		issues, resp, err := client.Issues.ListProjectIssues(path, gitlabOpts)
+		var (
+			issues []*gitlab.Issue
+			resp   *gitlab.Response
+		)
+		err := model.Retry(fetchOpts.Retries, func() error {
+			var err error
+			issues, resp, err = client.Issues.ListProjectIssues(path, gitlabOpts)
+			if err != nil {
+				zap.L().Debug("failed to pull issues, retrying", zap.String("repo", repo.String()), zap.Int("page", gitlabOpts.Page), zap.Error(err))
+			}
+			return err
+		})
 		if err != nil {
 			zap.L().Error("failed to pull issues", zap.Error(err))
+			fetchOpts.Failures.Add(model.FetchFailure{Provider: "gitlab", Repo: repo.String(), Page: gitlabOpts.Page, Err: err})
 			return
 		}
 		total += len(issues)
@@ -61,7 +73,11 @@ func Pull(input multipmuri.Entity, wg *sync.WaitGroup, token string, db *gorm.DB
 		)
 		normalizedIssues := []*model.Issue{}
 		for _, issue := range issues {
-			normalizedIssues = append(normalizedIssues, FromIssue(issue))
+			normalizedIssue := FromIssue(issue)
+			if fetchOpts.ScanComments { // the system notes hold the related issues
+				normalizedIssue.Comments = fetchNotes(client, path, issue.IID, fetchOpts)
+			}
+			normalizedIssues = append(normalizedIssues, normalizedIssue)
 		}
 		out <- normalizedIssues
 		if resp.NextPage == 0 {
@@ -69,4 +85,94 @@ func Pull(input multipmuri.Entity, wg *sync.WaitGroup, token string, db *gorm.DB
 		}
 		gitlabOpts.ListOptions.Page = resp.NextPage
 	}
+
+	pullMergeRequests(client, repo, path, gitlabOpts.UpdatedAfter, fetchOpts, out)
+}
+
+// pullMergeRequests fetches the merge requests, stored as PRs.
+func pullMergeRequests(client *gitlab.Client, repo *multipmuri.GitLabRepo, path string, updatedAfter *time.Time, fetchOpts model.FetchOptions, out chan<- []*model.Issue) {
+	mrOpts := &gitlab.ListProjectMergeRequestsOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 30,
+			Page:    1,
+		},
+		UpdatedAfter: updatedAfter,
+	}
+	total := 0
+	for {
+		var (
+			mrs  []*gitlab.MergeRequest
+			resp *gitlab.Response
+		)
+		err := model.Retry(fetchOpts.Retries, func() error {
+			var err error
+			mrs, resp, err = client.MergeRequests.ListProjectMergeRequests(path, mrOpts)
+			if err != nil {
+				zap.L().Debug("failed to pull merge requests, retrying", zap.String("repo", repo.String()), zap.Int("page", mrOpts.Page), zap.Error(err))
+			}
+			return err
+		})
+		if err != nil {
+			zap.L().Error("failed to pull merge requests", zap.Error(err))
+			fetchOpts.Failures.Add(model.FetchFailure{Provider: "gitlab", Repo: repo.String() + " merge requests", Page: mrOpts.Page, Err: err})
+			return
+		}
+		total += len(mrs)
+		zap.L().Debug("paginate",
+			zap.String("provider", "gitlab"),
+			zap.String("repo", repo.String()),
+			zap.Int("new-merge-requests", len(mrs)),
+			zap.Int("total-merge-requests", total),
+		)
+		normalizedIssues := []*model.Issue{}
+		for _, mr := range mrs {
+			normalizedIssues = append(normalizedIssues, FromMergeRequest(mr))
+		}
+		out <- normalizedIssues
+		if resp.NextPage == 0 {
+			return
+		}
+		mrOpts.ListOptions.Page = resp.NextPage
+	}
+}
+
+// relatedSystemNotePrefix starts the system notes created by the "/relate"
+// quick action and the "Linked issues" UI.
+const relatedSystemNotePrefix = "marked this issue as related to "
+
+// fetchNotes returns the bodies of the user notes (comments) of an issue and
+// the related issues declared with "/relate", failures are recorded and the
+// already fetched notes are kept.
+func fetchNotes(client *gitlab.Client, path string, iid int, fetchOpts model.FetchOptions) []string {
+	notes := []string{}
+	notesOpts := &gitlab.ListIssueNotesOptions{PerPage: 30, Page: 1}
+	for {
+		var (
+			page []*gitlab.Note
+			resp *gitlab.Response
+		)
+		err := model.Retry(fetchOpts.Retries, func() error {
+			var err error
+			page, resp, err = client.Notes.ListIssueNotes(path, iid, notesOpts)
+			return err
+		})
+		if err != nil {
+			zap.L().Error("failed to pull notes", zap.String("repo", path), zap.Int("issue", iid), zap.Error(err))
+			fetchOpts.Failures.Add(model.FetchFailure{Provider: "gitlab", Repo: fmt.Sprintf("%s#%d notes", path, iid), Page: notesOpts.Page, Err: err})
+			return notes
+		}
+		for _, note := range page {
+			switch {
+			case !note.System:
+				notes = append(notes, note.Body)
+			case strings.HasPrefix(note.Body, relatedSystemNotePrefix):
+				// "/relate #42" is only visible as a system note
+				notes = append(notes, "related with "+strings.TrimPrefix(note.Body, relatedSystemNotePrefix))
+			}
+		}
+		if resp.NextPage == 0 {
+			return notes
+		}
+		notesOpts.Page = resp.NextPage
+	}
 }
@@ -69,6 +69,50 @@ func FromIssue(input *gitlab.Issue) *model.Issue {
 	return issue
 }
 
+func FromMergeRequest(input *gitlab.MergeRequest) *model.Issue {
+	// same repository ID as the issues (see gitlab.Issue.Links.Project)
+	repoURL := strings.TrimSuffix(input.WebURL, fmt.Sprintf("/merge_requests/%d", input.IID))
+	if u, err := url.Parse(input.WebURL); err == nil && input.ProjectID != 0 {
+		repoURL = fmt.Sprintf("%s://%s/api/v4/projects/%d", u.Scheme, u.Host, input.ProjectID)
+	}
+
+	repo := FromRepositoryURL(repoURL)
+	issue := &model.Issue{
+		Base: model.Base{
+			ID:        input.WebURL,
+			CreatedAt: *input.CreatedAt,
+			UpdatedAt: *input.UpdatedAt,
+			URL:       input.WebURL,
+		},
+		Repository:   repo,
+		Title:        input.Title,
+		State:        input.State,
+		Body:         input.Description,
+		IsPR:         true,
+		NumUpvotes:   input.Upvotes,
+		NumDownvotes: input.Downvotes,
+		Labels:       make([]*model.Label, 0),
+		Assignees:    make([]*model.Account, 0),
+		Author:       FromBasicUser(repo.Provider, input.Author),
+		Milestone:    FromMilestone(repo, input.Milestone),
+	}
+	if input.State == "merged" {
+		issue.State = "closed"
+		if input.MergedAt != nil {
+			issue.CompletedAt = *input.MergedAt
+		}
+	} else if input.ClosedAt != nil {
+		issue.CompletedAt = *input.ClosedAt
+	}
+	for _, label := range input.Labels {
+		issue.Labels = append(issue.Labels, FromLabelname(repo, label))
+	}
+	if input.Assignee != nil {
+		issue.Assignees = append(issue.Assignees, FromBasicUser(repo.Provider, input.Assignee))
+	}
+	return issue
+}
+
 func FromLabelname(repository *model.Repository, name string) *model.Label {
 	url := fmt.Sprintf("%s/labels/%s", repository.URL, name)
 	return &model.Label{
@@ -87,6 +131,20 @@ func FromIssueAssignee(provider *model.Provider, input *gitlab.IssueAssignee) *m
 	return FromIssueAuthor(provider, &author)
 }
 
+func FromBasicUser(provider *model.Provider, input *gitlab.BasicUser) *model.Account {
+	if input == nil {
+		return nil
+	}
+	return FromIssueAuthor(provider, &gitlab.IssueAuthor{
+		ID:        input.ID,
+		State:     input.State,
+		WebURL:    input.WebURL,
+		Name:      input.Name,
+		AvatarURL: input.AvatarURL,
+		Username:  input.Username,
+	})
+}
+
 func FromIssueAuthor(provider *model.Provider, input *gitlab.IssueAuthor) *model.Account {
 	name := input.Name
 	if name == "" {
@@ -114,6 +172,7 @@ func FromIssueAuthor(provider *model.Provider, input *gitlab.IssueAuthor) *model
 		// Company:
 		// Blog:
 		AvatarURL: input.AvatarURL,
+		HTMLURL:   input.WebURL,
 	}
 
 	return &account
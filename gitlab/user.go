@@ -0,0 +1,21 @@
+package gitlab // import "moul.io/depviz/gitlab"
+
+import (
+	"fmt"
+
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// AuthenticatedLogin returns the username of the owner of the token on the
+// given GitLab instance.
+func AuthenticatedLogin(serviceURL, token string) (string, error) {
+	client := gitlab.NewClient(nil, token)
+	if err := client.SetBaseURL(fmt.Sprintf("%s/api/v4", serviceURL)); err != nil {
+		return "", err
+	}
+	user, _, err := client.Users.CurrentUser()
+	if err != nil {
+		return "", err
+	}
+	return user.Username, nil
+}
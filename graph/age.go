@@ -0,0 +1,73 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"moul.io/depviz/compute"
+)
+
+const (
+	defaultStaleThreshold = "3mo"
+	ageLegendID           = "legend_age"
+)
+
+// ageColor returns the fill color of an issue last updated age ago, on a
+// gradient from green (just updated) to red (stale, older than threshold).
+func ageColor(age, threshold time.Duration) string {
+	ratio := float64(age) / float64(threshold)
+	ratio = math.Max(0, math.Min(1, ratio))
+	return hsvColor(120*(1-ratio), 0.45, 0.95)
+}
+
+// hsvColor converts a hue in degrees, a saturation and a value into a
+// "#rrggbb" color, understood by both Graphviz and SVG.
+func hsvColor(hue, saturation, value float64) string {
+	chroma := value * saturation
+	x := chroma * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+	var r, g, b float64
+	switch {
+	case hue < 60:
+		r, g = chroma, x
+	case hue < 120:
+		r, g = x, chroma
+	default:
+		g, b = chroma, x
+	}
+	m := value - chroma
+	return fmt.Sprintf("#%02x%02x%02x", int(math.Round((r+m)*255)), int(math.Round((g+m)*255)), int(math.Round((b+m)*255)))
+}
+
+// styleAges fills the open issues with the color of their last update, see
+// --color-by age.
+func styleAges(computed *compute.Computed, threshold time.Duration, now time.Time, decorations *decorations) {
+	for _, issue := range computed.Issues() {
+		if issue.State == "closed" || issue.UpdatedAt.IsZero() {
+			continue
+		}
+		decorations.node(issue.URL)["style"] = "filled"
+		decorations.node(issue.URL)["fillcolor"] = ageColor(now.Sub(issue.UpdatedAt), threshold)
+	}
+}
+
+// ageLegendStatements returns the dot statements of the --color-by age legend,
+// a node filled with the gradient.
+func ageLegendStatements(opts *Options) []string {
+	if opts.ColorBy != "age" {
+		return nil
+	}
+	legend := attrs{
+		"shape":         "box",
+		"style":         "filled",
+		"fillcolor":     ageColor(0, 1) + ":" + ageColor(1, 1),
+		"gradientangle": "0",
+		"fontsize":      "10",
+		"label":         ageLegendLabel(opts.StaleThreshold),
+	}
+	return []string{fmt.Sprintf("%q %s;", ageLegendID, legend.dot())}
+}
+
+func ageLegendLabel(threshold string) string {
+	return fmt.Sprintf("updated: today → %s+ ago", threshold)
+}
@@ -0,0 +1,41 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"time"
+
+	"moul.io/depviz/compute"
+)
+
+func parseTimestamp(input string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, input); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q, expected RFC3339 or YYYY-MM-DD", input)
+}
+
+// highlightChanges styles what changed since the given date: new issues in
+// green, newly closed issues in grey and new links in bold.
+func highlightChanges(computed *compute.Computed, since time.Time, decorations *decorations) {
+	for _, issue := range computed.Issues() {
+		switch {
+		case issue.State == "closed" && issue.CompletedAt.After(since):
+			decorations.node(issue.URL)["style"] = "filled"
+			decorations.node(issue.URL)["fillcolor"] = "grey"
+		case issue.CreatedAt.After(since):
+			decorations.node(issue.URL)["style"] = "filled"
+			decorations.node(issue.URL)["fillcolor"] = "palegreen"
+		}
+	}
+	for _, link := range computed.Links {
+		if !link.CreatedAt.After(since) {
+			continue
+		}
+		if dependency, dependent, ok := link.Dependency(); ok {
+			decorations.edge(dependency, dependent)["style"] = "bold"
+			decorations.edge(dependency, dependent)["penwidth"] = "3"
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package graph // import "moul.io/depviz/graph"
 
 import (
+	"strings"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -24,19 +26,16 @@ type graphCommand struct {
 
 func (cmd *graphCommand) CobraCommand(commands cli.Commands) *cobra.Command {
 	cc := &cobra.Command{
-		Use:   "graph",
+		Use:   "graph [targets...]",
 		Short: "Output graph of relationships between all issues stored in database",
-		Args: func(c *cobra.Command, args []string) error {
-			// FIXME: if no args, then graph the whole database
-			if err := cobra.MinimumNArgs(1)(c, args); err != nil {
-				return err
-			}
-			return nil
-		},
+		Long:  "Output graph of relationships between the issues of the targets, or of all the issues stored in database when no target is given",
 		RunE: func(_ *cobra.Command, args []string) error {
 			opts := cmd.opts
 			opts.SQL = sql.GetOptions(commands)
-			targets, err := model.ParseTargets(args)
+			if err := model.RegisterHosts(opts.GithubBaseURL, opts.GiteaHosts, opts.JiraBaseURL); err != nil {
+				return err
+			}
+			targets, err := model.ParseTargetsWithFile(args, opts.TargetsFile)
 			if err != nil {
 				return err
 			}
@@ -62,8 +61,72 @@ func (cmd *graphCommand) ParseFlags(flags *pflag.FlagSet) {
 	flags.BoolVarP(&cmd.opts.ShowPRs, "show-prs", "", false, "show PRs")
 	flags.BoolVarP(&cmd.opts.ShowAllRelated, "show-all-related", "", false, "show related from other repos")
 	flags.BoolVarP(&cmd.opts.Vertical, "vertical", "", false, "display graph vertically instead of horizontally")
-	flags.StringVarP(&cmd.opts.Format, "format", "f", "dot", "output format (dot, graphman-pert)")
+	flags.BoolVarP(&cmd.opts.Reverse, "reverse", "", false, "flip the edges to show what each issue unblocks instead of what blocks it")
+	flags.StringVarP(&cmd.opts.Format, "format", "f", "dot", "output format (dot, graphman-pert, xlsx, gv-json, confluence, mermaid, plantuml, json, graphml, svg, gantt, gantt-csv, cytoscape)")
+	flags.StringVarP(&cmd.opts.Output, "output", "o", "-", "write the output to this file (truncated, parent directories created) instead of stdout ('-')")
 	flags.BoolVarP(&cmd.opts.NoPertEstimates, "no-pert-estimates", "", false, "do not compute PERT estimates")
+	flags.BoolVarP(&cmd.opts.CollapseFixingPRs, "collapse-fixing-prs", "", false, "merge PRs with the issues they fix into a single PERT unit")
+	flags.BoolVarP(&cmd.opts.ShowCollapsedPRs, "show-collapsed-prs", "", false, "keep collapsed PRs visible (as zero-duration steps)")
+	flags.StringVarP(&cmd.opts.View, "view", "", "", "apply a named set of filters defined in the 'views' section of the config file")
+	flags.BoolVarP(&cmd.opts.Open, "open", "", false, "also open the rendered output with the default application (dot is rendered to SVG)")
+	flags.BoolVarP(&cmd.opts.Progress, "progress", "", false, "report the progress of the graph build phases on stderr")
+	flags.StringSliceVarP(&cmd.opts.NumberRanges, "number-range", "", []string{}, "only keep issues in these number ranges and their direct neighbors (i.e., 'moul/depviz:100-200')")
+	flags.StringVarP(&cmd.opts.NodeLabelTemplate, "node-label-template", "", "", "Go text/template of the node labels of the dot, graphman-pert, svg, mermaid, plantuml and cytoscape formats (fields: .Number, .Ref, .Title, .State, .URL, .Repo, .IsPR, .Type, .Assignee, .Assignees, .Estimate, .TasksDone, .TasksTotal), defaults to '"+defaultLabelTemplate+"' for Graphviz and '"+defaultPlainLabelTemplate+"' for the others")
+	flags.StringVarP(&cmd.opts.LabelTemplate, "label-template", "", "", "deprecated alias of --node-label-template")
+	_ = flags.MarkDeprecated("label-template", "use --node-label-template instead")
+	flags.BoolVarP(&cmd.opts.RecomputeEdges, "recompute-edges", "", false, "parse issue bodies instead of using the links resolved at fetch time")
+	flags.StringVarP(&cmd.opts.HighlightChangesSince, "highlight-changes-since", "", "", "highlight issues and links created or closed since this date (RFC3339 or YYYY-MM-DD)")
+	flags.StringVarP(&cmd.opts.Layout, "layout", "", "pert", "layout of the dot output (pert, roadmap)")
+	flags.IntVarP(&cmd.opts.MaxDepth, "max-depth", "", -1, "only keep the issues at most N dependency hops away from the issues matching the targets (0: the matching issues only, -1: unlimited)")
+	flags.StringSliceVarP(&cmd.opts.FilterLabels, "filter-label", "", []string{}, "only keep the issues with one of these labels (and their direct dependencies and dependents with --show-all-related)")
+	flags.StringSliceVarP(&cmd.opts.FilterAssignees, "filter-assignee", "", []string{}, "only keep the issues assigned to one of these logins, unassigned issues are dropped unless 'none' is given (combined with --filter-label)")
+	flags.StringSliceVarP(&cmd.opts.FilterMilestones, "filter-milestone", "", []string{}, "only keep the issues attached to one of these milestones (matched on title)")
+	flags.BoolVarP(&cmd.opts.MilestonesOnly, "milestones-only", "", false, "render the dependency graph of the milestones only")
+	flags.BoolVarP(&cmd.opts.RollupMilestoneDependencies, "rollup-milestone-dependencies", "", false, "with --milestones-only, add the milestone dependencies implied by the issues and report the undeclared ones")
+	flags.StringVarP(&cmd.opts.Focus, "focus", "", "", "highlight this issue (URL, target or number), dot and svg formats only")
+	flags.IntVarP(&cmd.opts.FocusDepth, "focus-depth", "", -1, "with --focus, dim the issues more than N dependency hops away from it (-1: none)")
+	flags.StringVarP(&cmd.opts.TreeFrom, "tree-from", "", "", "render the breakdown of this issue as a top-down tree instead of a PERT graph")
+	flags.BoolVarP(&cmd.opts.RewriteDuplicates, "rewrite-duplicates", "", false, "move the dependencies on closed duplicates to the canonical issue, when known")
+	flags.BoolVarP(&cmd.opts.Mine, "mine", "", false, "only show the issues authored by or assigned to the owner of the tokens, and their dependencies")
+	if flags.Lookup("github-token") == nil { // shared with 'pull' in 'run'
+		flags.StringVarP(&cmd.opts.GithubToken, "github-token", "", "", "GitHub Token with 'issues' access")
+	}
+	if flags.Lookup("github-base-url") == nil {
+		flags.StringVarP(&cmd.opts.GithubBaseURL, "github-base-url", "", "", "GitHub Enterprise API URL (i.e., 'https://github.example.com/api/v3/')")
+	}
+	if flags.Lookup("gitea-hosts") == nil {
+		flags.StringSliceVarP(&cmd.opts.GiteaHosts, "gitea-hosts", "", []string{}, "hosts of the Gitea instances (i.e., 'gitea.example.com')")
+	}
+	if flags.Lookup("jira-base-url") == nil {
+		flags.StringVarP(&cmd.opts.JiraBaseURL, "jira-base-url", "", "", "URL of the Jira instance of the 'jira:PROJ' targets (i.e., 'https://example.atlassian.net')")
+	}
+	if flags.Lookup("gitlab-token") == nil {
+		flags.StringVarP(&cmd.opts.GitlabToken, "gitlab-token", "", "", "GitLab Token with 'issues' access")
+	}
+	flags.BoolVarP(&cmd.opts.BlockedOnly, "blocked-only", "", false, "only show the open issues blocked by another open issue, and their blockers")
+	flags.BoolVarP(&cmd.opts.ReadyOnly, "ready-only", "", false, "only show the open issues whose dependencies are all resolved")
+	flags.BoolVarP(&cmd.opts.CriticalPathOnly, "critical-path-only", "", false, "only show the longest chain of dependencies (fails on cycles)")
+	flags.BoolVarP(&cmd.opts.DOTMetadata, "dot-metadata", "", false, "embed machine-readable metadata comments (targets, filters, node URL and state) in the dot output")
+	flags.IntVarP(&cmd.opts.MaxDiameter, "max-diameter", "", 0, "warn when the longest shortest dependency path is longer than this, runs a search from every issue (0 to disable)")
+	flags.StringVarP(&cmd.opts.DefaultEstimate, "default-estimate", "", "", "estimate of the issues without estimate label, i.e., 4h, 2d or 1w (PERT counts them as one day otherwise)")
+	flags.StringVarP(&cmd.opts.EstimateLabelPrefix, "estimate-label-prefix", "", DefaultEstimateLabelPrefix, "prefix of the labels giving the estimate of an issue, i.e., estimate:3d")
+	flags.StringSliceVarP(&cmd.opts.FilterTypes, "type", "", []string{}, "only show the issues of these types (see the 'types' section of the config file)")
+	flags.StringVarP(&cmd.opts.ColorBy, "color-by", "", "", "fill the nodes with a color per attribute value (supported: type, age)")
+	flags.StringVarP(&cmd.opts.StaleThreshold, "stale-threshold", "", defaultStaleThreshold, "last update age of the reddest nodes of --color-by age (units: d, w, mo, y)")
+	flags.StringVarP(&cmd.opts.Watermark, "watermark", "", "", "text drawn at the bottom of the rendered images (i.e., 'Confidential')")
+	flags.StringVarP(&cmd.opts.GraphTitle, "graph-title", "", "", "title drawn at the top of the dot and svg outputs")
+	flags.BoolVarP(&cmd.opts.Legend, "legend", "", false, "draw a legend of the node and edge styles in the dot and svg outputs")
+	flags.StringVarP(&cmd.opts.BgColor, "bg-color", "", "", "background color of the rendered images (Graphviz color, i.e., '#f5f5f5')")
+	if flags.Lookup("targets-file") == nil {
+		flags.StringVarP(&cmd.opts.TargetsFile, "targets-file", "", "", "read more targets from a file, one per line, '#' starts a comment")
+	}
+	flags.BoolVarP(&cmd.opts.ShowEmptyTargets, "show-empty-targets", "", false, "render a placeholder cluster for the targets without any matching issue")
+	flags.BoolVarP(&cmd.opts.FailOnEmpty, "fail-on-empty", "", false, "exit with an error if a target has no matching issue")
+	flags.StringVarP(&cmd.opts.GroupBy, "group-by", "", "", "cluster the nodes (supported: age)")
+	flags.StringSliceVarP(&cmd.opts.AgeBuckets, "age-buckets", "", strings.Split(defaultAgeBuckets, ","), "boundaries of the --group-by age buckets (units: d, w, mo, y)")
+	flags.StringVarP(&cmd.opts.ClusterBy, "cluster-by", "", "", "wrap the nodes of each repo or milestone in a cluster, dot and svg formats only (supported: repo, milestone)")
+	flags.StringSliceVarP(&cmd.opts.IssueFields, "issue-fields", "", []string{}, "issue fields of the xlsx and gv-json outputs, '-name' excludes a default field (overrides the 'fields' section of the config file)")
+	cmd.opts.Parse.ParseFlags(flags)
 	if err := viper.BindPFlags(flags); err != nil {
 		zap.L().Warn("failed to bind viper flags", zap.Error(err))
 	}
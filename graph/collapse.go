@@ -0,0 +1,93 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"sort"
+
+	"moul.io/depviz/compute"
+)
+
+// collapseFixingPRs makes each visible PR and the visible issues it fixes a
+// single scheduling unit, so PERT does not count them twice.
+//
+// When keepVisible is false, the PR is removed from the graph: the fixed issues
+// inherit its dependencies and everything depending on the PR now depends on
+// the fixed issues.
+// When keepVisible is true, the graph is left untouched and the returned set
+// contains the PRs that should be rendered as zero-duration states instead of
+// actions.
+func collapseFixingPRs(computed *compute.Computed, keepVisible bool) map[string]bool {
+	visible := map[string]*compute.ComputedIssue{}
+	for _, issue := range computed.Issues() {
+		visible[issue.URL] = issue
+	}
+
+	collapsed := map[string]bool{}
+	replacements := map[string][]string{}
+	for _, pr := range computed.Issues() {
+		if !pr.IsPR {
+			continue
+		}
+		fixes := []string{}
+		for _, url := range pr.Fixes {
+			if _, found := visible[url]; found {
+				fixes = append(fixes, url)
+			}
+		}
+		if len(fixes) == 0 {
+			continue
+		}
+		collapsed[pr.URL] = true
+		if keepVisible {
+			continue
+		}
+		replacements[pr.URL] = fixes
+		for _, url := range fixes {
+			issue := visible[url]
+			dependsOn := []string{}
+			for _, dependency := range issue.DependsOn {
+				if dependency != pr.URL {
+					dependsOn = append(dependsOn, dependency)
+				}
+			}
+			for _, dependency := range pr.DependsOn {
+				if dependency != issue.URL {
+					dependsOn = append(dependsOn, dependency)
+				}
+			}
+			issue.DependsOn = dependsOn
+		}
+		pr.Hidden = true
+	}
+	if keepVisible {
+		return collapsed
+	}
+
+	replace := func(dependsOn []string, self string) []string {
+		seen := map[string]bool{}
+		out := []string{}
+		for _, dependency := range dependsOn {
+			targets, found := replacements[dependency]
+			if !found {
+				targets = []string{dependency}
+			}
+			for _, target := range targets {
+				if target != self && !seen[target] {
+					seen[target] = true
+					out = append(out, target)
+				}
+			}
+		}
+		sort.Strings(out)
+		return out
+	}
+	for _, issue := range computed.Issues() {
+		issue.DependsOn = replace(issue.DependsOn, issue.URL)
+	}
+	for _, milestone := range computed.Milestones() {
+		milestone.DependsOn = replace(milestone.DependsOn, milestone.URL)
+	}
+	for _, repo := range computed.Repos() {
+		repo.DependsOn = replace(repo.DependsOn, repo.URL)
+	}
+	return collapsed
+}
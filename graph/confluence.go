@@ -0,0 +1,73 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+
+	"moul.io/depviz/compute"
+)
+
+// toConfluence renders a table of the issues and their blockers in the
+// Confluence storage format (XHTML), with a status macro per issue.
+//
+// See https://confluence.atlassian.com/doc/confluence-storage-format-790796544.html
+func toConfluence(computed *compute.Computed) (string, error) {
+	visible := map[string]*compute.ComputedIssue{}
+	for _, issue := range computed.Issues() {
+		visible[issue.URL] = issue
+	}
+
+	var b strings.Builder
+	b.WriteString("<table><tbody>\n")
+	b.WriteString("<tr><th>Issue</th><th>Title</th><th>Status</th><th>Assignees</th><th>Blocked by</th></tr>\n")
+	for _, issue := range computed.Issues() {
+		assignees := []string{}
+		for _, assignee := range issue.Assignees {
+			assignees = append(assignees, html.EscapeString(assignee.Login))
+		}
+		blockers := []string{}
+		for _, dependency := range issue.DependsOn {
+			if blocker, found := visible[dependency]; found {
+				blockers = append(blockers, confluenceLink(blocker))
+			}
+		}
+		fmt.Fprintf(&b, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			confluenceLink(issue),
+			html.EscapeString(issue.Title),
+			confluenceStatus(issue),
+			strings.Join(assignees, ", "),
+			strings.Join(blockers, "<br/>"),
+		)
+	}
+	b.WriteString("</tbody></table>")
+	return b.String(), nil
+}
+
+func confluenceLink(issue *compute.ComputedIssue) string {
+	return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(issue.WebURL()), html.EscapeString(shortReference(issue)))
+}
+
+func confluenceStatus(issue *compute.ComputedIssue) string {
+	colour := "Green"
+	switch {
+	case issue.State == "closed":
+		colour = "Grey"
+	case issue.IsPR:
+		colour = "Blue"
+	}
+	return fmt.Sprintf(
+		`<ac:structured-macro ac:name="status"><ac:parameter ac:name="colour">%s</ac:parameter><ac:parameter ac:name="title">%s</ac:parameter></ac:structured-macro>`,
+		colour, html.EscapeString(issue.State),
+	)
+}
+
+// shortReference returns the "owner/repo#42" form of an issue.
+func shortReference(issue *compute.ComputedIssue) string {
+	repo := issue.RepositoryID
+	if u, err := url.Parse(issue.RepositoryID); err == nil && u.Path != "" {
+		repo = strings.Trim(u.Path, "/")
+	}
+	return fmt.Sprintf("%s#%d", repo, issue.Number())
+}
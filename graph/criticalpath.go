@@ -0,0 +1,140 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"sort"
+
+	"moul.io/depviz/compute"
+)
+
+// topologicalOrder returns the issues sorted so that each issue comes after
+// its dependencies, the dependencies outside of the set are ignored. An error
+// is returned if the dependencies contain a cycle.
+func topologicalOrder(issues []*compute.ComputedIssue) ([]*compute.ComputedIssue, error) {
+	byURL := map[string]*compute.ComputedIssue{}
+	urls := []string{}
+	for _, issue := range issues {
+		byURL[issue.URL] = issue
+		urls = append(urls, issue.URL)
+	}
+	sort.Strings(urls)
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	order := []*compute.ComputedIssue{}
+	var visit func(url string) error
+	visit = func(url string) error {
+		switch state[url] {
+		case visiting:
+			return fmt.Errorf("dependency cycle detected on %q", url)
+		case visited:
+			return nil
+		}
+		state[url] = visiting
+		dependencies := append([]string{}, byURL[url].DependsOn...)
+		sort.Strings(dependencies)
+		for _, dependency := range dependencies {
+			if _, found := byURL[dependency]; !found {
+				continue
+			}
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		state[url] = visited
+		order = append(order, byURL[url])
+		return nil
+	}
+	for _, url := range urls {
+		if err := visit(url); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// ComputeCriticalPath returns the longest estimated chain of dependencies
+// between the issues, from the first issue to do to the last one.
+//
+// When several chains have the same length, the one going through the
+// lowest URLs (in lexical order) is returned, so the result is stable. The
+// dependencies must not contain cycles, an error is returned otherwise.
+// The issues are estimated with their "estimate:" label, or one day.
+func ComputeCriticalPath(issues []*compute.ComputedIssue) ([]*compute.ComputedIssue, error) {
+	return computeCriticalPath(issues, defaultEstimator)
+}
+
+func computeCriticalPath(issues []*compute.ComputedIssue, estimator *estimator) ([]*compute.ComputedIssue, error) {
+	order, err := topologicalOrder(issues)
+	if err != nil {
+		return nil, err
+	}
+
+	// finish is the length of the longest chain ending with the issue
+	finish := map[string]float64{}
+	previous := map[string]*compute.ComputedIssue{}
+	byURL := map[string]*compute.ComputedIssue{}
+	for _, issue := range order {
+		byURL[issue.URL] = issue
+		for _, dependency := range issue.DependsOn {
+			candidate, found := byURL[dependency] // dependencies come first
+			if !found {
+				continue
+			}
+			best := previous[issue.URL]
+			if best == nil || finish[candidate.URL] > finish[best.URL] ||
+				(finish[candidate.URL] == finish[best.URL] && candidate.URL < best.URL) {
+				previous[issue.URL] = candidate
+			}
+		}
+		finish[issue.URL] = estimator.duration(issue)
+		if best := previous[issue.URL]; best != nil {
+			finish[issue.URL] += finish[best.URL]
+		}
+	}
+
+	var last *compute.ComputedIssue
+	for _, issue := range order {
+		if last == nil || finish[issue.URL] > finish[last.URL] ||
+			(finish[issue.URL] == finish[last.URL] && issue.URL < last.URL) {
+			last = issue
+		}
+	}
+	path := []*compute.ComputedIssue{}
+	for issue := last; issue != nil; issue = previous[issue.URL] {
+		path = append([]*compute.ComputedIssue{issue}, path...)
+	}
+	return path, nil
+}
+
+// filterCriticalPath hides everything except the issues of the critical path,
+// each of them only keeps the dependency on its predecessor in the path.
+func filterCriticalPath(computed *compute.Computed, estimator *estimator) error {
+	path, err := computeCriticalPath(computed.Issues(), estimator)
+	if err != nil {
+		return fmt.Errorf("cannot compute the critical path: %v", err)
+	}
+	onPath := map[string]bool{}
+	for idx, issue := range path {
+		onPath[issue.URL] = true
+		issue.DependsOn = []string{}
+		if idx > 0 {
+			issue.DependsOn = []string{path[idx-1].URL}
+		}
+	}
+	for _, issue := range computed.AllIssues {
+		if !onPath[issue.URL] {
+			issue.Hidden = true
+		}
+	}
+	for _, milestone := range computed.AllMilestones {
+		milestone.Hidden = true
+	}
+	for _, repo := range computed.AllRepos {
+		repo.Hidden = true
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"encoding/json"
+	"strings"
+
+	"moul.io/depviz/compute"
+)
+
+// toCytoscape renders the visible nodes and edges as Cytoscape.js elements,
+// with the kind, state and PR-ness of the nodes as classes (i.e., "issue
+// closed pr") to style them with selectors.
+//
+// See https://js.cytoscape.org/#notation/elements-json
+func toCytoscape(computed *compute.Computed, opts *Options) (string, error) {
+	type cytoscapeNodeData struct {
+		ID    string `json:"id"`
+		Label string `json:"label"`
+		URL   string `json:"url"`
+		Kind  string `json:"kind"`
+		State string `json:"state,omitempty"`
+	}
+	type cytoscapeNode struct {
+		Data    cytoscapeNodeData `json:"data"`
+		Classes string            `json:"classes"`
+	}
+	type cytoscapeEdgeData struct {
+		ID     string `json:"id"` // stable, there is at most one edge per pair
+		Source string `json:"source"`
+		Target string `json:"target"`
+		Kind   string `json:"kind"`
+	}
+	type cytoscapeEdge struct {
+		Data cytoscapeEdgeData `json:"data"`
+	}
+	out := struct {
+		Elements struct {
+			Nodes []cytoscapeNode `json:"nodes"`
+			Edges []cytoscapeEdge `json:"edges"`
+		} `json:"elements"`
+	}{}
+	out.Elements.Nodes = []cytoscapeNode{}
+	out.Elements.Edges = []cytoscapeEdge{}
+
+	labeler, err := newPlainLabeler(opts)
+	if err != nil {
+		return "", err
+	}
+	nodes, edges := entities(computed)
+	for _, n := range nodes {
+		classes := []string{string(n.Kind)}
+		if n.State != "" {
+			classes = append(classes, n.State)
+		}
+		if n.IsPR {
+			classes = append(classes, "pr")
+		}
+		out.Elements.Nodes = append(out.Elements.Nodes, cytoscapeNode{
+			Data: cytoscapeNodeData{
+				ID:    n.ID,
+				Label: labeler.plain(n),
+				URL:   n.URL,
+				Kind:  string(n.Kind),
+				State: n.State,
+			},
+			Classes: strings.Join(classes, " "),
+		})
+	}
+	for _, e := range edges {
+		out.Elements.Edges = append(out.Elements.Edges, cytoscapeEdge{
+			Data: cytoscapeEdgeData{
+				ID:     e.Src + " -> " + e.Dst,
+				Source: e.Src,
+				Target: e.Dst,
+				Kind:   e.Kind,
+			},
+		})
+	}
+
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,52 @@
+package graph
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestToCytoscape(t *testing.T) {
+	output, err := toCytoscape(testComputed(), &Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "cytoscape.json", output)
+}
+
+func TestToCytoscapeUniqueEdgeIDs(t *testing.T) {
+	output, err := toCytoscape(testComputed(), &Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Elements struct {
+			Nodes []struct {
+				Data struct {
+					ID string `json:"id"`
+				} `json:"data"`
+			} `json:"nodes"`
+			Edges []struct {
+				Data struct {
+					ID string `json:"id"`
+				} `json:"data"`
+			} `json:"edges"`
+		} `json:"elements"`
+	}
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	// Cytoscape.js shares the ID namespace between nodes and edges
+	seen := map[string]bool{}
+	for _, n := range decoded.Elements.Nodes {
+		seen[n.Data.ID] = true
+	}
+	for _, e := range decoded.Elements.Edges {
+		if seen[e.Data.ID] {
+			t.Errorf("duplicate element ID %q", e.Data.ID)
+		}
+		seen[e.Data.ID] = true
+	}
+	if len(decoded.Elements.Edges) != 2 {
+		t.Errorf("expected 2 edges, got %d", len(decoded.Elements.Edges))
+	}
+}
@@ -0,0 +1,110 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"time"
+
+	"moul.io/depviz/compute"
+	"moul.io/graphman"
+)
+
+type attrs map[string]string
+
+// decorations are extra styles computed from the options (highlights,
+// colors, ...), applied on top of the rendered graph.
+type decorations struct {
+	nodes map[string]attrs    // by node ID
+	edges map[[2]string]attrs // by (src, dst) node IDs
+
+	reversed bool // see --reverse, edges are given in the dependency order
+}
+
+func newDecorations() *decorations {
+	return &decorations{
+		nodes: map[string]attrs{},
+		edges: map[[2]string]attrs{},
+	}
+}
+
+// decorations returns the decorations configured in the options.
+func (opts Options) decorations(computed *compute.Computed) (*decorations, error) {
+	decorations := newDecorations()
+	decorations.reversed = opts.Reverse
+	styleSatisfied(computed, decorations)
+	styleStates(computed, decorations)
+	if opts.ColorBy == "type" || len(opts.Types) > 0 {
+		styleTypes(computed, opts.Types, opts.ColorBy == "type", decorations)
+	}
+	if opts.ColorBy == "age" {
+		threshold, err := parseAge(opts.StaleThreshold)
+		if err != nil {
+			return nil, err
+		}
+		styleAges(computed, threshold, time.Now(), decorations)
+	}
+	if opts.HighlightChangesSince != "" {
+		since, err := parseTimestamp(opts.HighlightChangesSince)
+		if err != nil {
+			return nil, err
+		}
+		highlightChanges(computed, since, decorations)
+	}
+	if opts.Focus != "" {
+		focused, err := resolveFocus(computed, opts.Focus)
+		if err != nil {
+			return nil, err
+		}
+		styleFocus(computed, focused, opts.FocusDepth, decorations)
+	}
+	return decorations, nil
+}
+
+// styleSatisfied dashes the edges of the dependencies checked in a task list.
+func styleSatisfied(computed *compute.Computed, decorations *decorations) {
+	for _, issue := range computed.Issues() {
+		for _, dependency := range issue.Satisfied {
+			decorations.edge(dependency, issue.URL)["style"] = "dashed"
+		}
+	}
+}
+
+func (d *decorations) node(id string) attrs {
+	if _, found := d.nodes[id]; !found {
+		d.nodes[id] = attrs{}
+	}
+	return d.nodes[id]
+}
+
+func (d *decorations) edge(src, dst string) attrs {
+	key := [2]string{src, dst}
+	if d.reversed {
+		key = [2]string{dst, src}
+	}
+	if _, found := d.edges[key]; !found {
+		d.edges[key] = attrs{}
+	}
+	return d.edges[key]
+}
+
+func (d *decorations) applyToGraphman(graph *graphman.Graph) {
+	for id, nodeAttrs := range d.nodes {
+		vertex := graph.GetVertex(id)
+		if vertex == nil {
+			continue
+		}
+		for key, value := range nodeAttrs {
+			vertex.Attrs[key] = value
+		}
+	}
+	if len(d.edges) == 0 {
+		return
+	}
+	for _, edge := range graph.Edges() {
+		edgeAttrs, found := d.edges[[2]string{edge.Src().ID(), edge.Dst().ID()}]
+		if !found {
+			continue
+		}
+		for key, value := range edgeAttrs {
+			edge.Attrs[key] = value
+		}
+	}
+}
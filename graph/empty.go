@@ -0,0 +1,48 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+	"moul.io/multipmuri"
+)
+
+// emptyTargets returns the targets without any visible issue, after filtering.
+func emptyTargets(computed *compute.Computed, targets []multipmuri.Entity) []multipmuri.Entity {
+	empty := []multipmuri.Entity{}
+	included, _ := model.SplitTargets(targets)
+	for _, target := range included {
+		matched := false
+		for _, issue := range computed.Issues() {
+			entity, err := issue.MultipmuriEntity()
+			if err != nil {
+				continue
+			}
+			if entity.Equals(target) || target.Contains(entity) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			empty = append(empty, target)
+		}
+	}
+	return empty
+}
+
+// emptyTargetClusters returns dot placeholder clusters acknowledging the
+// targets without any matching issue.
+func emptyTargetClusters(targets []multipmuri.Entity) []string {
+	statements := []string{}
+	for idx, target := range targets {
+		statements = append(statements, fmt.Sprintf(
+			"subgraph %s { graph %s; %s %s; }",
+			dotQuote(fmt.Sprintf("cluster_empty_%d", idx)),
+			attrs{"label": target.String(), "style": "dashed", "color": "grey"}.dot(),
+			dotQuote("empty: "+target.String()),
+			attrs{"label": "0 issues matched filters", "shape": "plaintext", "fontcolor": "grey"}.dot(),
+		))
+	}
+	return statements
+}
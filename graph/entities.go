@@ -0,0 +1,121 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"sort"
+
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+)
+
+// node and edge are a format-agnostic representation of the visible part of
+// the computed graph, used by the renderers that do not rely on graphman.
+
+type nodeKind string
+
+const (
+	issueNode     nodeKind = "issue"
+	milestoneNode nodeKind = "milestone"
+	repoNode      nodeKind = "repo"
+)
+
+type node struct {
+	ID    string
+	Kind  nodeKind
+	Title string
+	URL   string
+	State string
+	IsPR  bool
+
+	issue *compute.ComputedIssue // only set for issueNode
+}
+
+// edge is oriented in the scheduling order: Src should be done before Dst.
+// Kind is the kind of the link declaring the edge (i.e., "blocks",
+// "part-of"), "depends-on" for the milestones and repos.
+type edge struct {
+	Src  string
+	Dst  string
+	Kind string
+}
+
+const dependsOnEdge = "depends-on"
+
+// edgeKinds returns the kind of the link declaring each dependency, keyed by
+// "src dst" in the scheduling order.
+func edgeKinds(computed *compute.Computed) map[string]string {
+	kinds := map[string]string{}
+	for _, link := range computed.Links {
+		src, dst := link.SourceID, link.TargetID
+		if link.Kind == model.DependsOnLink || link.Kind == model.ParentOfLink {
+			src, dst = dst, src
+		}
+		if _, found := kinds[src+" "+dst]; !found {
+			kinds[src+" "+dst] = string(link.Kind)
+		}
+	}
+	return kinds
+}
+
+// entities returns the visible nodes and the edges between them, sorted by ID.
+func entities(computed *compute.Computed) ([]node, []edge) {
+	nodes := []node{}
+	dependencies := map[string][]string{}
+	for _, issue := range computed.Issues() {
+		nodes = append(nodes, node{
+			ID:    issue.URL,
+			Kind:  issueNode,
+			Title: issue.Title,
+			URL:   issue.WebURL(),
+			State: issue.State,
+			IsPR:  issue.IsPR,
+			issue: issue,
+		})
+		dependencies[issue.URL] = issue.DependsOn
+	}
+	for _, milestone := range computed.Milestones() {
+		nodes = append(nodes, node{
+			ID:    milestone.URL,
+			Kind:  milestoneNode,
+			Title: milestone.Title,
+			URL:   model.WebURL(milestone.URL),
+		})
+		dependencies[milestone.URL] = milestone.DependsOn
+	}
+	if len(computed.Repos()) > 1 {
+		for _, repo := range computed.Repos() {
+			nodes = append(nodes, node{
+				ID:    repo.URL,
+				Kind:  repoNode,
+				Title: repo.URL,
+				URL:   model.WebURL(repo.URL),
+			})
+			dependencies[repo.URL] = repo.DependsOn
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+
+	kinds := edgeKinds(computed)
+	edges := []edge{}
+	for dst, srcs := range dependencies {
+		for _, src := range srcs {
+			if _, found := dependencies[src]; !found { // hidden or missing
+				continue
+			}
+			kind, found := kinds[src+" "+dst]
+			if !found {
+				kind, found = kinds[dst+" "+src] // --reverse
+			}
+			if !found {
+				kind = dependsOnEdge
+			}
+			edges = append(edges, edge{Src: src, Dst: dst, Kind: kind})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Src != edges[j].Src {
+			return edges[i].Src < edges[j].Src
+		}
+		return edges[i].Dst < edges[j].Dst
+	})
+	return nodes, edges
+}
@@ -0,0 +1,17 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEntitiesEdgeKinds(t *testing.T) {
+	_, edges := entities(testComputed())
+	expected := []edge{
+		{Src: testRepoURL + "/issues/1", Dst: testRepoURL + "/issues/2", Kind: "blocks"},
+		{Src: testRepoURL + "/pull/3", Dst: testRepoURL + "/issues/2", Kind: "depends-on"},
+	}
+	if !reflect.DeepEqual(edges, expected) {
+		t.Errorf("expected %v, got %v", expected, edges)
+	}
+}
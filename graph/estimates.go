@@ -0,0 +1,110 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"moul.io/depviz/compute"
+)
+
+// DefaultEstimateLabelPrefix is the default --estimate-label-prefix.
+const DefaultEstimateLabelPrefix = "estimate:"
+
+var dayEstimateRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)([dw])$`)
+
+// parseEstimate parses Go durations ("4h", "90m") and day-based ones: "2d",
+// "1.5w".
+func parseEstimate(input string) (time.Duration, error) {
+	input = strings.TrimSpace(input)
+	var (
+		duration time.Duration
+		err      error
+	)
+	if match := dayEstimateRegex.FindStringSubmatch(input); match != nil {
+		n, _ := strconv.ParseFloat(match[1], 64)
+		if match[2] == "w" {
+			n *= 7
+		}
+		duration = time.Duration(n * float64(24*time.Hour))
+	} else if duration, err = time.ParseDuration(input); err != nil {
+		return 0, fmt.Errorf("invalid estimate %q, expected a duration such as 4h, 2d or 1w", input)
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("invalid estimate %q, should be positive", input)
+	}
+	return duration, nil
+}
+
+func durationDays(duration time.Duration) float64 {
+	return duration.Hours() / 24
+}
+
+// estimator resolves the estimates of the issues, in days.
+type estimator struct {
+	labelPrefix string  // i.e., "estimate:" for the "estimate:3d" labels
+	fallback    float64 // --default-estimate, 0 if not set
+}
+
+// defaultEstimator is used when no options are available.
+var defaultEstimator = &estimator{labelPrefix: DefaultEstimateLabelPrefix}
+
+func (opts Options) estimator() (*estimator, error) {
+	e := &estimator{labelPrefix: opts.EstimateLabelPrefix}
+	if opts.DefaultEstimate != "" {
+		duration, err := parseEstimate(opts.DefaultEstimate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --default-estimate: %v", err)
+		}
+		e.fallback = durationDays(duration)
+	}
+	return e, nil
+}
+
+// estimate returns the estimate of the issue (set by 'airtable sync'), the one
+// from its labels or the default one, ok is false if the issue has no
+// estimate.
+func (e *estimator) estimate(issue *compute.ComputedIssue) (days float64, ok bool) {
+	if issue.Estimate != "" {
+		if duration, err := parseEstimate(issue.Estimate); err == nil {
+			return durationDays(duration), true
+		}
+	}
+	if e.labelPrefix != "" {
+		for _, label := range issue.Labels {
+			name := strings.ToLower(label.Name)
+			if !strings.HasPrefix(name, strings.ToLower(e.labelPrefix)) {
+				continue
+			}
+			if duration, err := parseEstimate(name[len(e.labelPrefix):]); err == nil {
+				return durationDays(duration), true
+			}
+		}
+	}
+	if e.fallback > 0 {
+		return e.fallback, true
+	}
+	return 0, false
+}
+
+// HasEstimate returns whether the issue has an estimate, set by 'airtable
+// sync' or in a label with the given prefix, the --default-estimate is not
+// counted.
+func HasEstimate(issue *compute.ComputedIssue, labelPrefix string) bool {
+	_, ok := (&estimator{labelPrefix: labelPrefix}).estimate(issue)
+	return ok
+}
+
+// duration is the estimate used by the PERT computations: the closed issues
+// are free and the ones without estimate take one day.
+func (e *estimator) duration(issue *compute.ComputedIssue) float64 {
+	if issue.State == "closed" {
+		return 0
+	}
+	if days, ok := e.estimate(issue); ok {
+		return days
+	}
+	return 1
+}
@@ -0,0 +1,101 @@
+package graph
+
+import (
+	"testing"
+
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+)
+
+func TestParseEstimate(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    float64 // days
+		expectedErr bool
+	}{
+		{"2d", 2, false},
+		{"1w", 7, false},
+		{"1.5w", 10.5, false},
+		{"0.5d", 0.5, false},
+		{" 3d ", 3, false},
+		{"12h", 0.5, false},
+		{"90m", 0.0625, false},
+		{"", 0, true},
+		{"3", 0, true},
+		{"2y", 0, true},
+		{"d", 0, true},
+		{"0d", 0, true},
+		{"-1h", 0, true},
+	}
+	for _, test := range tests {
+		duration, err := parseEstimate(test.input)
+		if (err != nil) != test.expectedErr {
+			t.Errorf("parseEstimate(%q): expected error: %v, got %v", test.input, test.expectedErr, err)
+			continue
+		}
+		if days := durationDays(duration); !test.expectedErr && days != test.expected {
+			t.Errorf("parseEstimate(%q): expected %v days, got %v", test.input, test.expected, days)
+		}
+	}
+}
+
+func TestEstimatorLabels(t *testing.T) {
+	issue := func(estimate string, labels ...string) *compute.ComputedIssue {
+		computed := &compute.ComputedIssue{Issue: model.Issue{State: "open", Estimate: estimate}}
+		for _, name := range labels {
+			computed.Labels = append(computed.Labels, &model.Label{Name: name})
+		}
+		return computed
+	}
+	tests := []struct {
+		name        string
+		estimator   estimator
+		issue       *compute.ComputedIssue
+		expected    float64
+		expectedOK  bool
+		expectedDur float64
+	}{
+		{"default prefix", estimator{labelPrefix: DefaultEstimateLabelPrefix}, issue("", "estimate:3d"), 3, true, 3},
+		{"weeks", estimator{labelPrefix: DefaultEstimateLabelPrefix}, issue("", "bug", "estimate:2w"), 14, true, 14},
+		{"hours", estimator{labelPrefix: DefaultEstimateLabelPrefix}, issue("", "estimate:6h"), 0.25, true, 0.25},
+		{"case-insensitive", estimator{labelPrefix: DefaultEstimateLabelPrefix}, issue("", "Estimate:3D"), 3, true, 3},
+		{"custom prefix", estimator{labelPrefix: "size/"}, issue("", "estimate:3d", "size/2d"), 2, true, 2},
+		{"other prefix ignored", estimator{labelPrefix: "size/"}, issue("", "estimate:3d"), 0, false, 1},
+		{"invalid label skipped", estimator{labelPrefix: DefaultEstimateLabelPrefix}, issue("", "estimate:soon", "estimate:1d"), 1, true, 1},
+		{"estimate field first", estimator{labelPrefix: DefaultEstimateLabelPrefix}, issue("5d", "estimate:3d"), 5, true, 5},
+		{"no prefix", estimator{}, issue("", "estimate:3d"), 0, false, 1},
+		{"fallback", estimator{labelPrefix: DefaultEstimateLabelPrefix, fallback: 2}, issue(""), 2, true, 2},
+		{"unestimated", estimator{labelPrefix: DefaultEstimateLabelPrefix}, issue(""), 0, false, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			days, ok := test.estimator.estimate(test.issue)
+			if days != test.expected || ok != test.expectedOK {
+				t.Errorf("expected (%v, %v), got (%v, %v)", test.expected, test.expectedOK, days, ok)
+			}
+			if duration := test.estimator.duration(test.issue); duration != test.expectedDur {
+				t.Errorf("expected a %v days duration, got %v", test.expectedDur, duration)
+			}
+		})
+	}
+}
+
+func TestEstimatorClosedIssue(t *testing.T) {
+	issue := &compute.ComputedIssue{Issue: model.Issue{State: "closed", Estimate: "3d"}}
+	if duration := defaultEstimator.duration(issue); duration != 0 {
+		t.Errorf("expected the closed issues to be free, got %v", duration)
+	}
+}
+
+func TestOptionsEstimator(t *testing.T) {
+	e, err := Options{EstimateLabelPrefix: DefaultEstimateLabelPrefix, DefaultEstimate: "1w"}.estimator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.fallback != 7 || e.labelPrefix != DefaultEstimateLabelPrefix {
+		t.Errorf("unexpected estimator %+v", e)
+	}
+	if _, err := (Options{DefaultEstimate: "soon"}).estimator(); err == nil {
+		t.Errorf("expected an invalid --default-estimate to be rejected")
+	}
+}
@@ -0,0 +1,140 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"moul.io/depviz/compute"
+)
+
+// issueField is an issue attribute that can be included in the outputs.
+type issueField struct {
+	header string
+	link   bool // rendered as an hyperlink when the format supports it
+	value  func(issue *compute.ComputedIssue) string
+}
+
+var issueFields = map[string]issueField{
+	"url":    {header: "URL", link: true, value: func(i *compute.ComputedIssue) string { return i.URL }},
+	"number": {header: "Number", value: func(i *compute.ComputedIssue) string { return strconv.Itoa(i.Number()) }},
+	"title":  {header: "Title", value: func(i *compute.ComputedIssue) string { return i.Title }},
+	"state":  {header: "State", value: func(i *compute.ComputedIssue) string { return i.State }},
+	"kind": {header: "Kind", value: func(i *compute.ComputedIssue) string {
+		if i.IsPR {
+			return "pull-request"
+		}
+		return "issue"
+	}},
+	"type": {header: "Type", value: func(i *compute.ComputedIssue) string { return i.Type }},
+	"repo": {header: "Repository", value: func(i *compute.ComputedIssue) string { return i.RepositoryID }},
+	"milestone": {header: "Milestone", value: func(i *compute.ComputedIssue) string {
+		if i.Milestone == nil {
+			return ""
+		}
+		return i.Milestone.Title
+	}},
+	"assignees": {header: "Assignees", value: func(i *compute.ComputedIssue) string {
+		assignees := []string{}
+		for _, assignee := range i.Assignees {
+			assignees = append(assignees, assignee.Login)
+		}
+		return strings.Join(assignees, ", ")
+	}},
+	"labels": {header: "Labels", value: func(i *compute.ComputedIssue) string {
+		labels := []string{}
+		for _, label := range i.Labels {
+			labels = append(labels, label.Name)
+		}
+		return strings.Join(labels, ", ")
+	}},
+	"tasks": {header: "Tasks", value: func(i *compute.ComputedIssue) string {
+		if i.TasksTotal == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%d/%d", i.TasksDone, i.TasksTotal)
+	}},
+	"created-at":   {header: "Created At", value: func(i *compute.ComputedIssue) string { return formatFieldTime(i.CreatedAt) }},
+	"updated-at":   {header: "Updated At", value: func(i *compute.ComputedIssue) string { return formatFieldTime(i.UpdatedAt) }},
+	"completed-at": {header: "Completed At", value: func(i *compute.ComputedIssue) string { return formatFieldTime(i.CompletedAt) }},
+}
+
+// defaultFields are the issue fields of each format, when not configured, the
+// other formats do not support them. The node labels are set with
+// --node-label-template.
+var defaultFields = map[string][]string{
+	"xlsx": {
+		"url", "title", "state", "kind", "repo", "milestone", "assignees",
+		"labels", "created-at", "updated-at", "completed-at",
+	},
+	"gv-json": {"title", "url"},
+}
+
+func formatFieldTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// fields returns the issue fields to render for the current format.
+//
+// The fields are configured per format in the config file, or with
+// --issue-fields for the current format, i.e.:
+//
+//	fields:
+//	  xlsx: [number, title, state, tasks]
+//	  gv-json: [-url]
+//
+// When only exclusions ("-name") are listed, they are removed from the defaults.
+// Configuring the fields of a format without fields is an error.
+func (opts Options) fields() ([]string, error) {
+	config := opts.Fields[opts.Format]
+	if len(opts.IssueFields) > 0 {
+		config = opts.IssueFields
+	}
+	if len(config) == 0 {
+		return defaultFields[opts.Format], nil
+	}
+	if _, supported := defaultFields[opts.Format]; !supported {
+		formats := []string{}
+		for format := range defaultFields {
+			formats = append(formats, format)
+		}
+		sort.Strings(formats)
+		return nil, fmt.Errorf("the %q format does not support issue fields (supported: %s)", opts.Format, strings.Join(formats, ", "))
+	}
+
+	included := []string{}
+	excluded := map[string]bool{}
+	for _, name := range config {
+		name = strings.ToLower(strings.TrimSpace(name))
+		exclude := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		if _, found := issueFields[name]; !found {
+			available := []string{}
+			for key := range issueFields {
+				available = append(available, key)
+			}
+			sort.Strings(available)
+			return nil, fmt.Errorf("unknown field %q for format %q, available fields: %s", name, opts.Format, strings.Join(available, ", "))
+		}
+		if exclude {
+			excluded[name] = true
+		} else {
+			included = append(included, name)
+		}
+	}
+	if len(included) == 0 {
+		included = defaultFields[opts.Format]
+	}
+	fields := []string{}
+	for _, name := range included {
+		if !excluded[name] {
+			fields = append(fields, name)
+		}
+	}
+	return fields, nil
+}
@@ -0,0 +1,117 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+)
+
+// validateFocus checks the syntax of the --focus value, an issue number or a
+// target.
+func validateFocus(input string) error {
+	if _, err := strconv.Atoi(strings.TrimPrefix(input, "#")); err == nil {
+		return nil
+	}
+	if _, err := model.ParseTarget(input); err != nil {
+		return fmt.Errorf("invalid --focus value %q: %v", input, err)
+	}
+	return nil
+}
+
+// resolveFocus returns the URL of the --focus issue, given as a target (i.e.,
+// moul/depviz#42) or as a number when it is unique in the graph.
+func resolveFocus(computed *compute.Computed, input string) (string, error) {
+	visible := computed.Issues()
+	if number, err := strconv.Atoi(strings.TrimPrefix(input, "#")); err == nil {
+		matches := []string{}
+		for _, issue := range visible {
+			if issue.Number() == number {
+				matches = append(matches, issue.URL)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return "", fmt.Errorf("focus issue %q is not in the graph (check the targets and filters)", input)
+		case 1:
+			return matches[0], nil
+		}
+		sort.Strings(matches)
+		return "", fmt.Errorf("focus issue %q is ambiguous, use its URL: %s", input, strings.Join(matches, ", "))
+	}
+	target, err := model.ParseTarget(input)
+	if err != nil {
+		return "", fmt.Errorf("invalid --focus value %q: %v", input, err)
+	}
+	for _, issue := range visible {
+		if issue.URL == target.String() {
+			return issue.URL, nil
+		}
+	}
+	return "", fmt.Errorf("focus issue %q is not in the graph (check the targets and filters)", input)
+}
+
+// focusDimmed returns the visible issues more than depth dependency hops away
+// from the focused one, in either direction, nil for a negative depth.
+func focusDimmed(computed *compute.Computed, focused string, depth int) map[string]bool {
+	if depth < 0 {
+		return nil
+	}
+	visible := map[string]bool{}
+	for _, issue := range computed.Issues() {
+		visible[issue.URL] = true
+	}
+	neighbors := map[string][]string{}
+	for _, issue := range computed.Issues() {
+		for _, dependency := range issue.DependsOn {
+			if !visible[dependency] {
+				continue
+			}
+			neighbors[issue.URL] = append(neighbors[issue.URL], dependency)
+			neighbors[dependency] = append(neighbors[dependency], issue.URL)
+		}
+	}
+	hops := map[string]int{focused: 0}
+	for queue := []string{focused}; len(queue) > 0; queue = queue[1:] {
+		current := queue[0]
+		if hops[current] == depth {
+			continue
+		}
+		for _, neighbor := range neighbors[current] {
+			if _, found := hops[neighbor]; !found {
+				hops[neighbor] = hops[current] + 1
+				queue = append(queue, neighbor)
+			}
+		}
+	}
+	dimmed := map[string]bool{}
+	for _, issue := range computed.Issues() {
+		if _, found := hops[issue.URL]; !found {
+			dimmed[issue.URL] = true
+		}
+	}
+	return dimmed
+}
+
+// styleFocus fills the --focus issue with a bold border, and dims the issues
+// beyond --focus-depth and their edges.
+func styleFocus(computed *compute.Computed, focused string, depth int, decorations *decorations) {
+	dimmed := focusDimmed(computed, focused, depth)
+	for _, issue := range computed.Issues() {
+		if dimmed[issue.URL] {
+			decorations.node(issue.URL)["color"] = "grey80"
+			decorations.node(issue.URL)["fontcolor"] = "grey70"
+		}
+		for _, dependency := range issue.DependsOn {
+			if dimmed[issue.URL] || dimmed[dependency] {
+				decorations.edge(dependency, issue.URL)["color"] = "grey80"
+			}
+		}
+	}
+	decorations.node(focused)["style"] = "filled,bold"
+	decorations.node(focused)["fillcolor"] = "gold"
+	decorations.node(focused)["penwidth"] = "3"
+}
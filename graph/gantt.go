@@ -0,0 +1,139 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"moul.io/depviz/compute"
+)
+
+const noMilestoneSection = "No milestone"
+
+type ganttTask struct {
+	ID       string
+	Title    string
+	URL      string
+	Start    time.Time
+	Duration time.Duration
+}
+
+type ganttSection struct {
+	Title string
+	Tasks []ganttTask
+}
+
+// ganttSections lays out the open issues with an estimate (see
+// --default-estimate) by their PERT earliest start, from today. The sections
+// are the milestones sorted by title, the issues without milestone come last.
+func ganttSections(computed *compute.Computed, opts *Options, now time.Time) ([]ganttSection, error) {
+	estimator, err := opts.estimator()
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := computeSchedule(computed.Issues(), estimator)
+	if err != nil {
+		return nil, fmt.Errorf("cannot compute the schedule: %v", err)
+	}
+	origin := now.UTC().Truncate(24 * time.Hour)
+
+	byTitle := map[string]*ganttSection{}
+	for _, issue := range computed.Issues() {
+		if issue.State == "closed" {
+			continue
+		}
+		days, ok := estimator.estimate(issue)
+		if !ok {
+			continue
+		}
+		title := noMilestoneSection
+		if issue.Milestone != nil {
+			title = issue.Milestone.Title
+		}
+		if _, found := byTitle[title]; !found {
+			byTitle[title] = &ganttSection{Title: title}
+		}
+		byTitle[title].Tasks = append(byTitle[title].Tasks, ganttTask{
+			ID:       safeID(issue.URL),
+			Title:    fmt.Sprintf("%s: %s", shortReference(issue), issue.Title),
+			URL:      issue.WebURL(),
+			Start:    origin.Add(time.Duration(schedule[issue.URL].EarliestStart * float64(24*time.Hour))),
+			Duration: time.Duration(days * float64(24*time.Hour)),
+		})
+	}
+
+	sections := []ganttSection{}
+	for _, section := range byTitle {
+		sort.Slice(section.Tasks, func(i, j int) bool {
+			if !section.Tasks[i].Start.Equal(section.Tasks[j].Start) {
+				return section.Tasks[i].Start.Before(section.Tasks[j].Start)
+			}
+			return section.Tasks[i].URL < section.Tasks[j].URL
+		})
+		sections = append(sections, *section)
+	}
+	sort.Slice(sections, func(i, j int) bool {
+		if (sections[i].Title == noMilestoneSection) != (sections[j].Title == noMilestoneSection) {
+			return sections[j].Title == noMilestoneSection
+		}
+		return sections[i].Title < sections[j].Title
+	})
+	return sections, nil
+}
+
+// toGantt renders a Mermaid gantt chart.
+//
+// See https://mermaid.js.org/syntax/gantt.html
+func toGantt(computed *compute.Computed, opts *Options) (string, error) {
+	sections, err := ganttSections(computed, opts, time.Now())
+	if err != nil {
+		return "", err
+	}
+	// ':' separates the task name from its metadata and '#' starts a comment
+	escape := strings.NewReplacer(":", " -", "#", "", ";", ",", "\n", " ")
+
+	var b strings.Builder
+	b.WriteString("gantt\n")
+	b.WriteString("  dateFormat YYYY-MM-DD HH:mm\n")
+	b.WriteString("  axisFormat %Y-%m-%d\n")
+	for _, section := range sections {
+		fmt.Fprintf(&b, "  section %s\n", escape.Replace(section.Title))
+		for _, task := range section.Tasks {
+			hours := int(task.Duration.Round(time.Hour).Hours())
+			if hours < 1 {
+				hours = 1
+			}
+			fmt.Fprintf(&b, "  %s :%s, %s, %dh\n", escape.Replace(task.Title), task.ID, task.Start.Format("2006-01-02 15:04"), hours)
+		}
+	}
+	return b.String(), nil
+}
+
+// toGanttCSV renders the gantt chart tasks as CSV, the dates are in RFC 3339
+// and the durations in days.
+func toGanttCSV(computed *compute.Computed, opts *Options) (string, error) {
+	sections, err := ganttSections(computed, opts, time.Now())
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	_ = w.Write([]string{"section", "url", "title", "start", "end", "duration"})
+	for _, section := range sections {
+		for _, task := range section.Tasks {
+			_ = w.Write([]string{
+				section.Title,
+				task.URL,
+				task.Title,
+				task.Start.Format(time.RFC3339),
+				task.Start.Add(task.Duration).Format(time.RFC3339),
+				fmt.Sprintf("%g", durationDays(task.Duration)),
+			})
+		}
+	}
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n"), w.Error()
+}
@@ -3,10 +3,12 @@ package graph // import "moul.io/depviz/graph"
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v2"
 	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
 	"moul.io/depviz/sql"
 	"moul.io/graphman"
 	"moul.io/graphman/viz"
@@ -15,14 +17,94 @@ import (
 
 type Options struct {
 	SQL             sql.Options         `mapstructure:"sql"`     // inherited with sql.GetOptions()
-	Targets         []multipmuri.Entity `mapstructure:"targets"` // parsed from Args
+	Targets         []multipmuri.Entity `mapstructure:"targets"` // parsed from Args and TargetsFile
+	TargetsFile     string              `mapstructure:"targets-file"`
 	ShowClosed      bool                `mapstructure:"show-closed"`
 	ShowOrphans     bool                `mapstructure:"show-orphans"`
 	ShowPRs         bool                `mapstructure:"show-prs"`
 	ShowAllRelated  bool                `mapstructure:"show-all-related"`
 	NoPertEstimates bool                `mapstructure:"no-pert-estimates"`
 	Vertical        bool                `mapstructure:"vertical"`
+	Reverse         bool                `mapstructure:"reverse"`
 	Format          string              `mapstructure:"format"`
+	Output          string              `mapstructure:"output"`
+
+	CollapseFixingPRs bool `mapstructure:"collapse-fixing-prs"`
+	ShowCollapsedPRs  bool `mapstructure:"show-collapsed-prs"`
+
+	View  string          `mapstructure:"view"`
+	Views map[string]View `mapstructure:"views"` // loaded from the config file
+
+	Progress bool `mapstructure:"progress"`
+
+	Open bool `mapstructure:"open"`
+
+	NumberRanges []string `mapstructure:"number-range"`
+
+	NodeLabelTemplate string `mapstructure:"node-label-template"`
+	LabelTemplate     string `mapstructure:"label-template"` // deprecated, see NodeLabelTemplate
+
+	RecomputeEdges bool `mapstructure:"recompute-edges"`
+
+	HighlightChangesSince string `mapstructure:"highlight-changes-since"`
+
+	TreeFrom string `mapstructure:"tree-from"`
+	Layout   string `mapstructure:"layout"`
+
+	Focus      string `mapstructure:"focus"`
+	FocusDepth int    `mapstructure:"focus-depth"`
+
+	MaxDepth         int      `mapstructure:"max-depth"`
+	FilterLabels     []string `mapstructure:"filter-label"`
+	FilterAssignees  []string `mapstructure:"filter-assignee"`
+	FilterMilestones []string `mapstructure:"filter-milestone"`
+
+	MilestonesOnly              bool                `mapstructure:"milestones-only"`
+	MilestoneDependencies       map[string][]string `mapstructure:"milestone-dependencies"` // loaded from the config file
+	RollupMilestoneDependencies bool                `mapstructure:"rollup-milestone-dependencies"`
+
+	RewriteDuplicates bool `mapstructure:"rewrite-duplicates"`
+
+	Mine          bool     `mapstructure:"mine"`
+	GithubToken   string   `mapstructure:"github-token"` // used by --mine
+	GithubBaseURL string   `mapstructure:"github-base-url"`
+	GiteaHosts    []string `mapstructure:"gitea-hosts"`
+	JiraBaseURL   string   `mapstructure:"jira-base-url"`
+	GitlabToken   string   `mapstructure:"gitlab-token"` // used by --mine
+
+	BlockedOnly      bool `mapstructure:"blocked-only"`
+	ReadyOnly        bool `mapstructure:"ready-only"`
+	CriticalPathOnly bool `mapstructure:"critical-path-only"`
+
+	DOTMetadata bool `mapstructure:"dot-metadata"`
+
+	Watermark  string `mapstructure:"watermark"`
+	BgColor    string `mapstructure:"bg-color"`
+	GraphTitle string `mapstructure:"graph-title"`
+	Legend     bool   `mapstructure:"legend"`
+
+	MaxDiameter int `mapstructure:"max-diameter"`
+
+	DefaultEstimate     string `mapstructure:"default-estimate"`
+	EstimateLabelPrefix string `mapstructure:"estimate-label-prefix"`
+
+	Types       []compute.TypeRule `mapstructure:"types"` // loaded from the config file
+	FilterTypes []string           `mapstructure:"type"`
+	ColorBy     string             `mapstructure:"color-by"`
+
+	StaleThreshold string `mapstructure:"stale-threshold"`
+
+	ShowEmptyTargets bool `mapstructure:"show-empty-targets"`
+	FailOnEmpty      bool `mapstructure:"fail-on-empty"`
+
+	GroupBy    string   `mapstructure:"group-by"`
+	AgeBuckets []string `mapstructure:"age-buckets"`
+	ClusterBy  string   `mapstructure:"cluster-by"`
+
+	Fields      map[string][]string `mapstructure:"fields"` // loaded from the config file
+	IssueFields []string            `mapstructure:"issue-fields"`
+
+	Parse compute.ParseOptions `mapstructure:",squash"`
 }
 
 func (opts Options) Validate() error {
@@ -30,13 +112,156 @@ func (opts Options) Validate() error {
 		return err
 	}
 	switch format := opts.Format; format {
-	case "dot", "graphman-pert":
+	case "dot", "graphman-pert", "xlsx", "gv-json", "confluence", "mermaid", "plantuml", "json", "graphml", "svg", "gantt", "gantt-csv", "cytoscape":
 	default:
 		return fmt.Errorf("invalid format: %q", format)
 	}
+	if err := opts.validateView(); err != nil {
+		return err
+	}
+	if _, err := opts.numberRanges(); err != nil {
+		return err
+	}
+	if _, err := parseLabelTemplate(opts.nodeLabelTemplate(), defaultLabelTemplate); err != nil {
+		return err
+	}
+	if opts.HighlightChangesSince != "" {
+		if _, err := parseTimestamp(opts.HighlightChangesSince); err != nil {
+			return err
+		}
+	}
+	switch opts.GroupBy {
+	case "":
+	case "age":
+		if _, err := parseAgeBuckets(opts.AgeBuckets); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid --group-by value: %q (supported: age)", opts.GroupBy)
+	}
+	switch opts.ClusterBy {
+	case "":
+	case "repo", "milestone":
+		if opts.Format != "dot" && opts.Format != "svg" {
+			return fmt.Errorf("--cluster-by only supports the dot and svg formats, got %q", opts.Format)
+		}
+		if opts.GroupBy != "" {
+			return fmt.Errorf("--cluster-by and --group-by are mutually exclusive")
+		}
+		if opts.TreeFrom != "" || opts.Layout == "roadmap" || opts.MilestonesOnly {
+			return fmt.Errorf("--cluster-by cannot be combined with --tree-from, --milestones-only or the roadmap layout")
+		}
+	default:
+		return fmt.Errorf("invalid --cluster-by value: %q (supported: repo, milestone)", opts.ClusterBy)
+	}
+	if opts.BlockedOnly && opts.ReadyOnly {
+		return fmt.Errorf("--blocked-only and --ready-only are mutually exclusive")
+	}
+	if _, err := opts.estimator(); err != nil {
+		return err
+	}
+	if opts.CriticalPathOnly && opts.MilestonesOnly {
+		return fmt.Errorf("--critical-path-only cannot be combined with --milestones-only")
+	}
+	if opts.Mine && len(opts.Targets) == 0 {
+		return fmt.Errorf("--mine requires at least one target to resolve the providers")
+	}
+	for format := range opts.Fields {
+		formatOpts := opts
+		formatOpts.Format = format
+		formatOpts.IssueFields = nil
+		if _, err := formatOpts.fields(); err != nil {
+			return fmt.Errorf("invalid 'fields' section of the config file: %v", err)
+		}
+	}
+	if _, err := opts.fields(); err != nil {
+		return err
+	}
+	if err := compute.ValidateTypeRules(opts.Types); err != nil {
+		return err
+	}
+	if opts.Format == "svg" {
+		for _, rule := range opts.Types {
+			if !svgShapes[rule.Shape] {
+				return fmt.Errorf("the svg format does not support the %q shape of the %q type (supported: box, rect, ellipse, oval, circle, diamond)", rule.Shape, rule.Name)
+			}
+		}
+		if opts.GroupBy != "" {
+			return fmt.Errorf("--group-by is not supported by the svg format, use --cluster-by instead")
+		}
+	}
+	switch opts.ColorBy {
+	case "", "type":
+	case "age":
+		if opts.Format != "dot" && opts.Format != "svg" {
+			return fmt.Errorf("--color-by age only supports the dot and svg formats, got %q", opts.Format)
+		}
+		if opts.Layout == "roadmap" || opts.MilestonesOnly {
+			return fmt.Errorf("--color-by age cannot be combined with --milestones-only or the roadmap layout")
+		}
+		if _, err := parseAge(opts.StaleThreshold); err != nil {
+			return fmt.Errorf("invalid --stale-threshold: %v", err)
+		}
+	default:
+		return fmt.Errorf("invalid --color-by value: %q (supported: type, age)", opts.ColorBy)
+	}
+	if opts.GraphTitle != "" && opts.Watermark != "" && opts.Format == "dot" {
+		return fmt.Errorf("--graph-title and --watermark cannot be combined with the dot format, both are the graph label")
+	}
+	if opts.Legend && (opts.Format == "dot" || opts.Format == "svg") && (opts.Layout == "roadmap" || opts.MilestonesOnly) {
+		return fmt.Errorf("--legend cannot be combined with --milestones-only or the roadmap layout")
+	}
+	switch opts.Layout {
+	case "", "pert":
+	case "roadmap":
+		if opts.Format != "dot" || opts.TreeFrom != "" {
+			return fmt.Errorf("the roadmap layout only supports the dot format, without --tree-from")
+		}
+	default:
+		return fmt.Errorf("invalid layout: %q", opts.Layout)
+	}
+	if opts.MilestonesOnly {
+		if opts.Format != "dot" && opts.Format != "graphman-pert" {
+			return fmt.Errorf("--milestones-only only supports the dot and graphman-pert formats")
+		}
+		if opts.TreeFrom != "" || opts.Layout == "roadmap" {
+			return fmt.Errorf("--milestones-only cannot be combined with --tree-from or the roadmap layout")
+		}
+	}
+	if opts.Focus != "" {
+		if opts.Format != "dot" && opts.Format != "svg" {
+			return fmt.Errorf("--focus only supports the dot and svg formats, got %q", opts.Format)
+		}
+		if opts.Layout == "roadmap" || opts.MilestonesOnly {
+			return fmt.Errorf("--focus cannot be combined with --milestones-only or the roadmap layout")
+		}
+		if err := validateFocus(opts.Focus); err != nil {
+			return err
+		}
+	}
+	if opts.TreeFrom != "" {
+		if opts.Format != "dot" {
+			return fmt.Errorf("--tree-from only supports the dot format")
+		}
+		if _, err := model.ParseTarget(opts.TreeFrom); err != nil {
+			return fmt.Errorf("invalid tree root %q: %v", opts.TreeFrom, err)
+		}
+	}
 	return nil
 }
 
+func (opts Options) numberRanges() ([]compute.NumberRange, error) {
+	ranges := []compute.NumberRange{}
+	for _, input := range opts.NumberRanges {
+		r, err := compute.ParseNumberRange(input)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
 func (opts Options) String() string {
 	out, _ := json.Marshal(opts)
 	return string(out)
@@ -50,26 +275,229 @@ func PrintGraph(opts *Options) error {
 		return err
 	}
 
-	fmt.Println(str)
+	out := str
+	if !isBinaryFormat(opts.Format) {
+		out += "\n"
+	}
+	if err := writeOutput(opts.Output, out); err != nil {
+		return err
+	}
+	if opts.Open {
+		return openOutput(str, opts.Format)
+	}
 	return nil
 }
 
+func isBinaryFormat(format string) bool {
+	switch format {
+	case "xlsx":
+		return true
+	}
+	return false
+}
+
 func Graph(opts *Options) (string, error) {
 	zap.L().Debug("Graph", zap.Stringer("opts", *opts))
+	progress := newProgress(opts.Progress)
 
-	db, err := sql.FromOpts(&opts.SQL)
+	computed, err := loadComputed(opts, progress)
 	if err != nil {
 		return "", err
 	}
+	empty := emptyTargets(computed, opts.Targets)
+	for _, target := range empty {
+		zap.L().Info("0 issues matched filters", zap.String("target", target.String()))
+	}
+	if opts.FailOnEmpty && len(empty) > 0 {
+		return "", fmt.Errorf("%d target(s) without any matching issue", len(empty))
+	}
 
-	computed, err := compute.LoadIssuesByTargets(db, opts.Targets)
+	progress.start("rendering")
+	var out string
+	switch opts.Format {
+	case "xlsx":
+		out, err = toXLSX(computed, opts)
+	case "gv-json":
+		out, err = toGraphvizJSON(computed, opts)
+	case "confluence":
+		out, err = toConfluence(computed)
+	case "mermaid":
+		out, err = toMermaid(computed, opts)
+	case "plantuml":
+		out, err = toPlantUML(computed, opts)
+	case "json":
+		out, err = toJSON(computed, opts)
+	case "graphml":
+		out, err = toGraphML(computed)
+	case "cytoscape":
+		out, err = toCytoscape(computed, opts)
+	case "svg":
+		out, err = toSVG(computed, opts)
+	case "gantt":
+		out, err = toGantt(computed, opts)
+	case "gantt-csv":
+		out, err = toGanttCSV(computed, opts)
+	case "dot":
+		switch {
+		case opts.TreeFrom != "":
+			out, err = toTree(computed, opts)
+		case opts.Layout == "roadmap":
+			out, err = toRoadmap(computed, opts)
+		case opts.MilestonesOnly:
+			out, err = toMilestones(computed, opts)
+		default:
+			out, err = toPert(computed, opts, progress)
+		}
+		if err == nil && opts.ShowEmptyTargets {
+			out = insertDOTStatements(out, emptyTargetClusters(empty))
+		}
+		if err == nil {
+			out = insertDOTStatements(out, presentationStatements(opts))
+			out = insertDOTStatements(out, ageLegendStatements(opts))
+			out = insertDOTStatements(out, legendStatements(opts))
+		}
+		if err == nil {
+			out, err = withDOTMetadata(out, computed, opts)
+		}
+	default:
+		if opts.MilestonesOnly {
+			out, err = toMilestones(computed, opts)
+		} else {
+			out, err = toPert(computed, opts, progress)
+		}
+	}
 	if err != nil {
 		return "", err
 	}
+	progress.done("rendering", len(computed.Issues()))
+	return out, nil
+}
+
+// loadComputed loads the issues matching the targets and computes their relationships.
+func loadComputed(opts *Options, progress *progress) (*compute.Computed, error) {
+	db, err := sql.FromOpts(&opts.SQL)
+	if err != nil {
+		return nil, err
+	}
+
+	progress.start("loading issues from database")
+	issues, err := sql.LoadAllIssues(compute.FilterDBByTargets(db, opts.Targets))
+	if err != nil {
+		return nil, err
+	}
+	progress.done("loading issues", len(issues))
+
+	progress.start("resolving relationships")
+	stored, err := sql.LoadAllLinks(db)
+	if err != nil {
+		return nil, err
+	}
+	var links []*model.Link
+	recompute := opts.RecomputeEdges
+	if !recompute && !opts.Parse.DefaultBodyParsing() {
+		zap.L().Info("custom body parsing options, parsing issue bodies instead of using the stored links")
+		recompute = true
+	}
+	if !recompute {
+		links = stored
+		if len(links) == 0 { // database populated before links were persisted
+			zap.L().Warn("no stored links, parsing issue bodies (run 'pull' again to persist them)")
+			links = nil
+		}
+	}
+	computed := compute.ComputeWithLinks(issues, links, opts.Parse)
+	if links == nil {
+		computed.SetLinkTimestamps(stored, time.Now())
+	}
+	computed.DetectTypes(opts.Types)
+	for _, reference := range computed.ResolveDuplicates(opts.RewriteDuplicates) {
+		zap.L().Warn("issue depends on a closed duplicate",
+			zap.String("issue", reference.Issue),
+			zap.String("duplicate", reference.Duplicate),
+			zap.String("canonical", reference.Canonical),
+			zap.Bool("rewritten", reference.Rewritten),
+		)
+	}
+	if len(opts.Targets) > 0 { // no target means the whole database
+		computed.FilterByTargets(opts.Targets)
+		if opts.MaxDepth >= 0 {
+			computed.FilterByDepth(opts.MaxDepth, !opts.ShowAllRelated)
+		}
+	}
+	if len(opts.FilterLabels) > 0 || len(opts.FilterAssignees) > 0 || len(opts.FilterMilestones) > 0 {
+		candidates := computed.Issues()
+		computed.FilterByLabels(opts.FilterLabels)
+		computed.FilterByAssignees(opts.FilterAssignees)
+		computed.FilterByMilestones(opts.FilterMilestones)
+		if opts.ShowAllRelated {
+			computed.ShowNeighbors(candidates)
+		}
+	}
 	// FIXME: if !opts.ShowOrphans { computed.FilterOrphans() }
 	// FIXME: if !opts.ShowAllRelated { computed.FilterAllRelated()
 	// FIXME: if !opts.ShowPRs { computed.FilterPRs()
 	// FIXME: if !opts.ShowClosed { computed.FilterClosed()
+	if opts.View != "" {
+		opts.Views[opts.View].apply(&computed)
+	}
+	ranges, err := opts.numberRanges()
+	if err != nil {
+		return nil, err
+	}
+	computed.FilterByNumberRanges(ranges)
+	computed.FilterByTypes(opts.FilterTypes)
+	if opts.Mine {
+		logins, err := opts.authenticatedLogins()
+		if err != nil {
+			return nil, err
+		}
+		computed.FilterByUsers(logins)
+	}
+	if opts.BlockedOnly {
+		computed.FilterBlockedOnly()
+	}
+	if opts.ReadyOnly {
+		computed.FilterReadyOnly()
+	}
+	if opts.CriticalPathOnly {
+		estimator, err := opts.estimator()
+		if err != nil {
+			return nil, err
+		}
+		if err := filterCriticalPath(&computed, estimator); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Reverse { // after the filters relying on the dependency direction
+		computed.Reverse()
+	}
+	progress.done("resolving relationships", len(computed.Issues()))
+
+	if opts.MaxDiameter > 0 {
+		if length, from, to := computed.Diameter(); length > opts.MaxDiameter {
+			zap.L().Warn("the dependency chains are too long, this usually indicates a planning problem",
+				zap.Int("diameter", length),
+				zap.Int("max-diameter", opts.MaxDiameter),
+				zap.String("from", from),
+				zap.String("to", to),
+			)
+		}
+	}
+
+	return &computed, nil
+}
+
+// toPert renders the graph with graphman, in the graphman-pert or dot formats.
+func toPert(computed *compute.Computed, opts *Options, progress *progress) (string, error) {
+	labeler, err := newLabeler(opts)
+	if err != nil {
+		return "", err
+	}
+
+	pertStates := map[string]bool{}
+	if opts.CollapseFixingPRs {
+		pertStates = collapseFixingPRs(computed, opts.ShowCollapsedPRs)
+	}
 
 	// initialize graph config
 	config := graphman.PertConfig{
@@ -84,16 +512,27 @@ func Graph(opts *Options) (string, error) {
 		if issue.Hidden {
 			continue
 		}
-		config.Actions = append(
-			config.Actions,
-			graphman.PertAction{
-				ID:        issue.URL,
-				Title:     issue.Title,
-				DependsOn: issue.DependsOn,
-				// Estimate
-				// FIXME: set style based on type, active, etc
-			},
-		)
+		if pertStates[issue.URL] { // zero-duration, does not count in estimates
+			config.States = append(
+				config.States,
+				graphman.PertState{
+					ID:        issue.URL,
+					Title:     labeler.label(issue),
+					DependsOn: issue.DependsOn,
+				},
+			)
+			continue
+		}
+		action := graphman.PertAction{
+			ID:        issue.URL,
+			Title:     labeler.label(issue),
+			DependsOn: issue.DependsOn,
+			// FIXME: set style based on type, active, etc
+		}
+		if days, ok := labeler.estimator.estimate(issue); ok {
+			action.Estimate = []float64{days, days, days} // optimistic, realistic, pessimistic
+		}
+		config.Actions = append(config.Actions, action)
 	}
 	for _, milestone := range computed.Milestones() {
 		if milestone.Hidden {
@@ -125,17 +564,43 @@ func Graph(opts *Options) (string, error) {
 		}
 	}
 
+	schedule := pertSchedule(computed, opts)
 	if opts.Format == "graphman-pert" {
-		out, err := yaml.Marshal(config)
+		out, err := yaml.Marshal(struct {
+			graphman.PertConfig `yaml:",inline"`
+			Schedule            map[string]Schedule `yaml:"schedule,omitempty"`
+		}{config, schedule})
 		if err != nil {
 			return "", err
 		}
 		return string(out), nil
 	}
 
+	// decorations
+	decorations, err := opts.decorations(computed)
+	if err != nil {
+		return "", err
+	}
+	scheduleDecorations(schedule, decorations)
+	groups, err := opts.groups(computed)
+	if err != nil {
+		return "", err
+	}
+
+	// the rendering is cached, computing PERT is expensive on big graphs
+	cacheKey, err := pertCacheKey(config, opts, decorations, groups)
+	if err != nil {
+		return "", err
+	}
+	if s, found := pertCache.get(cacheKey); found {
+		zap.L().Debug("PERT cache hit", zap.String("key", cacheKey))
+		return s, nil
+	}
+
 	// initialize graph from config
 	graph := graphman.FromPertConfig(config)
 	if !opts.NoPertEstimates {
+		progress.start("computing PERT")
 		_ = graphman.ComputePert(graph)
 		//for _, e := range graph.Edges() {log.Println("*", e)}
 		shortestPath, _ := graph.FindShortestPath("Start", "Finish")
@@ -143,6 +608,7 @@ func Graph(opts *Options) (string, error) {
 			edge.Dst().SetColor("red")
 			edge.SetColor("red")
 		}
+		progress.done("computing PERT", len(config.Actions))
 	}
 
 	// graph fine tuning
@@ -161,6 +627,7 @@ func Graph(opts *Options) (string, error) {
 	// FIXME: hightlight critical paths
 	// FIXME: highlight other infos
 	// FIXME: highlight target
+	decorations.applyToGraphman(graph)
 
 	// graphviz
 	s, err := viz.ToGraphviz(graph, &viz.Opts{
@@ -169,6 +636,8 @@ func Graph(opts *Options) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	s = insertDOTStatements(s, dotClusters(groups))
 
+	pertCache.set(cacheKey, s)
 	return s, nil
 }
@@ -0,0 +1,64 @@
+package graph
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata/")
+
+const testRepoURL = "https://github.com/moul/depviz"
+
+// testIssue returns an open issue of the test repository.
+func testIssue(path string, title string) *model.Issue {
+	repo := &model.Repository{Base: model.Base{ID: testRepoURL, URL: testRepoURL}}
+	url := testRepoURL + "/" + path
+	return &model.Issue{
+		Base:         model.Base{ID: url, URL: url},
+		Title:        title,
+		State:        "open",
+		IsPR:         strings.HasPrefix(path, "pull/"),
+		Repository:   repo,
+		RepositoryID: repo.ID,
+	}
+}
+
+// testComputed returns a small graph: the closed issue #1 blocks #2, which
+// depends on the pull request #3.
+func testComputed() *compute.Computed {
+	first := testIssue("issues/1", "First")
+	first.State = "closed"
+	second := testIssue("issues/2", "Second")
+	third := testIssue("pull/3", "Third")
+	links := []*model.Link{
+		model.NewLink(first.URL, model.BlocksLink, second.URL, "body"),
+		model.NewLink(second.URL, model.DependsOnLink, third.URL, "body"),
+	}
+	computed := compute.ComputeWithLinks(model.Issues{first, second, third}, links, compute.ParseOptions{})
+	return &computed
+}
+
+// assertGolden compares the output with testdata/<name>, the golden files
+// are rewritten with 'go test -update'.
+func assertGolden(t *testing.T, name string, output string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(path, []byte(output+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	golden, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := strings.TrimSuffix(string(golden), "\n"); output != expected {
+		t.Errorf("unexpected %s output:\n%s\nexpected:\n%s", name, output, expected)
+	}
+}
@@ -0,0 +1,99 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"moul.io/depviz/compute"
+)
+
+// toGraphML renders a GraphML document (Gephi, yEd, ...), with the state, repo
+// and assignees of the issues as node attributes.
+//
+// See http://graphml.graphdrawing.org/specification.html
+func toGraphML(computed *compute.Computed) (string, error) {
+	type graphmlKey struct {
+		ID   string `xml:"id,attr"`
+		For  string `xml:"for,attr"`
+		Name string `xml:"attr.name,attr"`
+		Type string `xml:"attr.type,attr"`
+	}
+	type graphmlData struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+	type graphmlNode struct {
+		ID   string        `xml:"id,attr"`
+		Data []graphmlData `xml:"data"`
+	}
+	type graphmlEdge struct {
+		ID     string        `xml:"id,attr"`
+		Source string        `xml:"source,attr"`
+		Target string        `xml:"target,attr"`
+		Data   []graphmlData `xml:"data"`
+	}
+	type graphmlGraph struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphmlNode `xml:"node"`
+		Edges       []graphmlEdge `xml:"edge"`
+	}
+	type graphmlDocument struct {
+		XMLName xml.Name     `xml:"http://graphml.graphdrawing.org/xmlns graphml"`
+		Keys    []graphmlKey `xml:"key"`
+		Graph   graphmlGraph `xml:"graph"`
+	}
+
+	doc := graphmlDocument{
+		Keys: []graphmlKey{
+			{ID: "label", For: "node", Name: "label", Type: "string"},
+			{ID: "url", For: "node", Name: "url", Type: "string"},
+			{ID: "kind", For: "node", Name: "kind", Type: "string"},
+			{ID: "state", For: "node", Name: "state", Type: "string"},
+			{ID: "repo", For: "node", Name: "repo", Type: "string"},
+			{ID: "assignee", For: "node", Name: "assignee", Type: "string"},
+			{ID: "relationship", For: "edge", Name: "relationship", Type: "string"},
+		},
+		Graph: graphmlGraph{
+			ID:          "G",
+			EdgeDefault: "directed",
+			Nodes:       []graphmlNode{},
+			Edges:       []graphmlEdge{},
+		},
+	}
+
+	nodes, edges := entities(computed)
+	for _, n := range nodes {
+		object := graphmlNode{
+			ID: n.ID,
+			Data: []graphmlData{
+				{Key: "label", Value: n.Title},
+				{Key: "url", Value: n.URL},
+				{Key: "kind", Value: string(n.Kind)},
+			},
+		}
+		if n.issue != nil {
+			data := newLabelData(n.issue, defaultEstimator) // the estimate is not exported
+			object.Data = append(object.Data,
+				graphmlData{Key: "state", Value: data.State},
+				graphmlData{Key: "repo", Value: data.Repo},
+				graphmlData{Key: "assignee", Value: strings.Join(data.Assignees, ", ")},
+			)
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, object)
+	}
+	for _, e := range edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphmlEdge{
+			ID:     e.Src + " -> " + e.Dst,
+			Source: e.Src,
+			Target: e.Dst,
+			Data:   []graphmlData{{Key: "relationship", Value: e.Kind}},
+		})
+	}
+
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(b), nil
+}
@@ -0,0 +1,95 @@
+package graph
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestToGraphMLRoundTrip(t *testing.T) {
+	output, err := toGraphML(testComputed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(output, xml.Header+`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`) {
+		t.Errorf("unexpected header:\n%s", output)
+	}
+
+	var doc struct {
+		Keys []struct {
+			ID  string `xml:"id,attr"`
+			For string `xml:"for,attr"`
+		} `xml:"key"`
+		Graph struct {
+			EdgeDefault string `xml:"edgedefault,attr"`
+			Nodes       []struct {
+				ID   string `xml:"id,attr"`
+				Data []struct {
+					Key   string `xml:"key,attr"`
+					Value string `xml:",chardata"`
+				} `xml:"data"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+				Data   []struct {
+					Key   string `xml:"key,attr"`
+					Value string `xml:",chardata"`
+				} `xml:"data"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal([]byte(output), &doc); err != nil {
+		t.Fatalf("invalid GraphML: %v", err)
+	}
+
+	keys := map[string]bool{}
+	for _, key := range doc.Keys {
+		keys[key.ID] = true
+	}
+	if doc.Graph.EdgeDefault != "directed" {
+		t.Errorf("expected a directed graph, got %q", doc.Graph.EdgeDefault)
+	}
+
+	if len(doc.Graph.Nodes) != 3 {
+		t.Fatalf("expected 3 nodes, got %d", len(doc.Graph.Nodes))
+	}
+	first := doc.Graph.Nodes[0]
+	if first.ID != testRepoURL+"/issues/1" {
+		t.Errorf("unexpected first node %q", first.ID)
+	}
+	values := map[string]string{}
+	for _, data := range first.Data {
+		if !keys[data.Key] {
+			t.Errorf("undeclared key %q", data.Key)
+		}
+		values[data.Key] = data.Value
+	}
+	if values["label"] != "First" || values["state"] != "closed" || values["repo"] != testRepoURL {
+		t.Errorf("unexpected node attributes: %v", values)
+	}
+
+	kinds := map[string]string{}
+	for _, e := range doc.Graph.Edges {
+		for _, data := range e.Data {
+			if !keys[data.Key] {
+				t.Errorf("undeclared key %q", data.Key)
+			}
+			if data.Key == "relationship" {
+				kinds[e.Source+" -> "+e.Target] = data.Value
+			}
+		}
+	}
+	expected := map[string]string{
+		testRepoURL + "/issues/1 -> " + testRepoURL + "/issues/2": "blocks",
+		testRepoURL + "/pull/3 -> " + testRepoURL + "/issues/2":   "depends-on",
+	}
+	if len(kinds) != len(expected) {
+		t.Fatalf("expected %d edges, got %v", len(expected), kinds)
+	}
+	for id, kind := range expected {
+		if kinds[id] != kind {
+			t.Errorf("expected the %q edge to be %q, got %q", id, kind, kinds[id])
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"moul.io/depviz/compute"
+)
+
+// group is a set of nodes rendered as a dot cluster.
+type group struct {
+	ID      string
+	Label   string
+	Attrs   attrs
+	Members []string // node IDs
+}
+
+const defaultAgeBuckets = "1mo,3mo,12mo"
+
+// parseAge parses durations with day-based units: "10d", "2w", "3mo", "1y".
+func parseAge(input string) (time.Duration, error) {
+	units := []struct {
+		suffix string
+		days   int
+	}{{"mo", 30}, {"d", 1}, {"w", 7}, {"y", 365}}
+	for _, unit := range units {
+		if !strings.HasSuffix(input, unit.suffix) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(input, unit.suffix))
+		if err != nil || n <= 0 {
+			break
+		}
+		return time.Duration(n*unit.days) * 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("invalid age %q, expected a number followed by d, w, mo or y", input)
+}
+
+func parseAgeBuckets(input []string) ([]time.Duration, error) {
+	boundaries := []time.Duration{}
+	for _, raw := range input {
+		boundary, err := parseAge(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, err
+		}
+		if len(boundaries) > 0 && boundary <= boundaries[len(boundaries)-1] {
+			return nil, fmt.Errorf("age buckets should be in increasing order")
+		}
+		boundaries = append(boundaries, boundary)
+	}
+	return boundaries, nil
+}
+
+// ageGroups buckets the visible issues by creation age, i.e., with the
+// "1mo,3mo,12mo" boundaries: <1mo, 1mo-3mo, 3mo-12mo and >12mo.
+// The clusters of the older buckets are drawn with warmer colors.
+func ageGroups(computed *compute.Computed, labels []string, boundaries []time.Duration, now time.Time) []group {
+	groups := make([]group, len(boundaries)+1)
+	for idx := range groups {
+		switch {
+		case len(boundaries) == 0:
+			groups[idx].Label = "all"
+		case idx == 0:
+			groups[idx].Label = "< " + labels[0]
+		case idx == len(boundaries):
+			groups[idx].Label = "> " + labels[idx-1]
+		default:
+			groups[idx].Label = labels[idx-1] + " - " + labels[idx]
+		}
+		groups[idx].ID = fmt.Sprintf("cluster_age_%d", idx)
+		groups[idx].Attrs = attrs{"label": groups[idx].Label, "style": "rounded"}
+	}
+	// draw attention to the oldest buckets
+	if n := len(groups); n > 1 {
+		groups[n-1].Attrs["color"] = "red"
+		groups[n-1].Attrs["penwidth"] = "2"
+		if n > 2 {
+			groups[n-2].Attrs["color"] = "orange"
+		}
+	}
+
+	for _, issue := range computed.Issues() {
+		if issue.CreatedAt.IsZero() {
+			continue
+		}
+		age := now.Sub(issue.CreatedAt)
+		bucket := len(boundaries)
+		for idx, boundary := range boundaries {
+			if age < boundary {
+				bucket = idx
+				break
+			}
+		}
+		groups[bucket].Members = append(groups[bucket].Members, issue.URL)
+	}
+	return groups
+}
+
+// clusterGroups returns a group per repository or per milestone (--cluster-by),
+// sorted by label. The issues without milestone are not clustered.
+func clusterGroups(computed *compute.Computed, by string) []group {
+	byKey := map[string]*group{}
+	add := func(key string, label string, member string) {
+		if _, found := byKey[key]; !found {
+			byKey[key] = &group{
+				Label: label,
+				Attrs: attrs{"label": label, "style": "rounded"},
+			}
+		}
+		byKey[key].Members = append(byKey[key].Members, member)
+	}
+	switch by {
+	case "repo":
+		for _, issue := range computed.Issues() {
+			if issue.Repository != nil {
+				add(issue.Repository.URL, trimScheme(issue.Repository.URL), issue.URL)
+			}
+		}
+		for _, repo := range computed.Repos() {
+			if _, found := byKey[repo.URL]; found && len(computed.Repos()) > 1 { // repos are only drawn when there are several
+				byKey[repo.URL].Members = append(byKey[repo.URL].Members, repo.URL)
+			}
+		}
+	case "milestone":
+		for _, issue := range computed.Issues() {
+			if issue.Milestone != nil {
+				add(issue.Milestone.URL, issue.Milestone.Title, issue.URL)
+			}
+		}
+		for _, milestone := range computed.Milestones() {
+			if _, found := byKey[milestone.URL]; found {
+				byKey[milestone.URL].Members = append(byKey[milestone.URL].Members, milestone.URL)
+			}
+		}
+	}
+
+	keys := []string{}
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if byKey[keys[i]].Label != byKey[keys[j]].Label {
+			return byKey[keys[i]].Label < byKey[keys[j]].Label
+		}
+		return keys[i] < keys[j]
+	})
+	groups := []group{}
+	for idx, key := range keys {
+		g := *byKey[key]
+		g.ID = fmt.Sprintf("cluster_%s_%d", by, idx)
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+func trimScheme(url string) string {
+	for _, scheme := range []string{"https://", "http://"} {
+		url = strings.TrimPrefix(url, scheme)
+	}
+	return url
+}
+
+// groups returns the clusters configured with --group-by or --cluster-by.
+func (opts Options) groups(computed *compute.Computed) ([]group, error) {
+	if opts.ClusterBy != "" {
+		return clusterGroups(computed, opts.ClusterBy), nil
+	}
+	switch opts.GroupBy {
+	case "":
+		return nil, nil
+	case "age":
+		boundaries, err := parseAgeBuckets(opts.AgeBuckets)
+		if err != nil {
+			return nil, err
+		}
+		return ageGroups(computed, opts.AgeBuckets, boundaries, time.Now()), nil
+	}
+	return nil, fmt.Errorf("invalid --group-by value: %q", opts.GroupBy)
+}
+
+// dotClusters returns the dot statements of the non-empty groups.
+func dotClusters(groups []group) []string {
+	statements := []string{}
+	for _, g := range groups {
+		if len(g.Members) == 0 {
+			continue
+		}
+		members := []string{}
+		for _, member := range g.Members {
+			members = append(members, dotQuote(member)+";")
+		}
+		statements = append(statements, fmt.Sprintf(
+			"subgraph %s { graph %s; %s }",
+			dotQuote(g.ID), g.Attrs.dot(), strings.Join(members, " "),
+		))
+	}
+	return statements
+}
+
+// insertDOTStatements appends statements at the end of the main graph of a
+// dot output.
+func insertDOTStatements(dot string, statements []string) string {
+	if len(statements) == 0 {
+		return dot
+	}
+	idx := strings.LastIndex(dot, "}")
+	if idx == -1 {
+		return dot
+	}
+	return dot[:idx] + "\t" + strings.Join(statements, "\n\t") + "\n" + dot[idx:]
+}
@@ -0,0 +1,88 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"encoding/json"
+
+	"moul.io/depviz/compute"
+)
+
+// toGraphvizJSON renders the graph using the schema of Graphviz' own JSON
+// output (as produced by `dot -Tdot_json`), without layout information.
+//
+// See https://graphviz.org/docs/outputs/json/
+func toGraphvizJSON(computed *compute.Computed, opts *Options) (string, error) {
+	type gvObject map[string]interface{}
+	type gvGraph struct {
+		Name        string     `json:"name"`
+		Directed    bool       `json:"directed"`
+		Strict      bool       `json:"strict"`
+		SubgraphCnt int        `json:"_subgraph_cnt"`
+		Rankdir     string     `json:"rankdir"`
+		Objects     []gvObject `json:"objects"`
+		Edges       []gvObject `json:"edges"`
+	}
+
+	fields, err := opts.fields()
+	if err != nil {
+		return "", err
+	}
+	nodes, edges := entities(computed)
+	out := gvGraph{
+		Name:     "G",
+		Directed: true,
+		Rankdir:  "LR",
+		Objects:  []gvObject{},
+		Edges:    []gvObject{},
+	}
+	if opts.Vertical {
+		out.Rankdir = "TB"
+	}
+	gvids := map[string]int{}
+	for idx, n := range nodes {
+		gvids[n.ID] = idx
+		object := gvObject{
+			"_gvid": idx,
+			"name":  n.ID,
+		}
+		if n.issue != nil {
+			for _, name := range fields {
+				object[gvAttribute(name)] = issueFields[name].value(n.issue)
+			}
+		} else {
+			object["label"] = n.Title
+			object["URL"] = n.URL
+		}
+		switch {
+		case n.Kind != issueNode:
+			object["shape"] = "box"
+		case n.State == "closed":
+			object["color"] = "grey"
+		}
+		out.Objects = append(out.Objects, object)
+	}
+	for idx, e := range edges {
+		out.Edges = append(out.Edges, gvObject{
+			"_gvid": idx,
+			"tail":  gvids[e.Src],
+			"head":  gvids[e.Dst],
+			"label": e.Kind,
+		})
+	}
+
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// gvAttribute returns the Graphviz attribute name of an issue field.
+func gvAttribute(field string) string {
+	switch field {
+	case "title":
+		return "label"
+	case "url":
+		return "URL"
+	}
+	return field
+}
@@ -0,0 +1,70 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"encoding/json"
+
+	"moul.io/depviz/compute"
+)
+
+// toJSON renders the visible nodes and edges, sorted by ID, in a schema
+// meant to be consumed by other tools.
+func toJSON(computed *compute.Computed, opts *Options) (string, error) {
+	type jsonNode struct {
+		ID        string    `json:"id"`
+		Kind      nodeKind  `json:"kind"`
+		URL       string    `json:"url"`
+		Title     string    `json:"title"`
+		State     string    `json:"state,omitempty"`
+		IsPR      bool      `json:"is-pr,omitempty"`
+		Assignees []string  `json:"assignees,omitempty"`
+		Estimate  float64   `json:"estimate"` // in days, 0 if unknown
+		Schedule  *Schedule `json:"schedule,omitempty"`
+	}
+	type jsonEdge struct {
+		Source string `json:"source"`
+		Target string `json:"target"`
+		Kind   string `json:"kind"`
+	}
+	out := struct {
+		Nodes []jsonNode `json:"nodes"`
+		Edges []jsonEdge `json:"edges"`
+	}{
+		Nodes: []jsonNode{},
+		Edges: []jsonEdge{},
+	}
+
+	estimator, err := opts.estimator()
+	if err != nil {
+		return "", err
+	}
+	schedule := pertSchedule(computed, opts)
+	nodes, edges := entities(computed)
+	for _, n := range nodes {
+		object := jsonNode{
+			ID:    n.ID,
+			Kind:  n.Kind,
+			URL:   n.URL,
+			Title: n.Title,
+			State: n.State,
+			IsPR:  n.IsPR,
+		}
+		if n.issue != nil {
+			data := newLabelData(n.issue, estimator)
+			object.Assignees = data.Assignees
+			object.Estimate = data.Estimate
+			if entry, found := schedule[n.ID]; found {
+				object.Schedule = &entry
+			}
+		}
+		out.Nodes = append(out.Nodes, object)
+	}
+	for _, e := range edges {
+		out.Edges = append(out.Edges, jsonEdge{Source: e.Src, Target: e.Dst, Kind: e.Kind})
+	}
+
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
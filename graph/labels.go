@@ -0,0 +1,133 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	"moul.io/depviz/compute"
+)
+
+// defaultLabelTemplate is the issue title, followed by the sub-tasks
+// completion when the issue has a checklist.
+const defaultLabelTemplate = "{{.Title}}{{if .TasksTotal}} [{{.TasksDone}}/{{.TasksTotal}}]{{end}}"
+
+// defaultPlainLabelTemplate is the label of the svg, mermaid and plantuml
+// formats, drawn without the Graphviz tooltips.
+const defaultPlainLabelTemplate = "{{.Ref}}: {{.Title}}"
+
+// labelData is the data available in --node-label-template.
+type labelData struct {
+	Number     int
+	Ref        string // short reference, i.e., "moul/depviz#42"
+	Title      string
+	State      string
+	URL        string
+	Repo       string
+	IsPR       bool
+	Type       string   // detected type, see the 'types' section of the config file
+	Assignee   string   // first assignee login, if any
+	Assignees  []string // all assignee logins
+	Estimate   float64  // estimate in days, 0 if unknown
+	TasksDone  int      // checked checklist items
+	TasksTotal int      // checklist items
+}
+
+// nodeLabelTemplate returns the --node-label-template, or the deprecated
+// --label-template if set.
+func (opts Options) nodeLabelTemplate() string {
+	if opts.LabelTemplate != "" {
+		return opts.LabelTemplate
+	}
+	return opts.NodeLabelTemplate
+}
+
+// parseLabelTemplate parses the template, or the fallback if empty. The
+// template is executed once on empty data, so the unknown fields are reported
+// before rendering.
+func parseLabelTemplate(input string, fallback string) (*template.Template, error) {
+	if input == "" {
+		input = fallback
+	}
+	tmpl, err := template.New("label").Option("missingkey=error").Parse(input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node label template: %v", err)
+	}
+	if err := tmpl.Execute(ioutil.Discard, labelData{Assignees: []string{}}); err != nil {
+		return nil, fmt.Errorf("invalid node label template: %v", err)
+	}
+	return tmpl, nil
+}
+
+func newLabelData(issue *compute.ComputedIssue, estimator *estimator) labelData {
+	data := labelData{
+		Number:     issue.Number(),
+		Ref:        shortReference(issue),
+		Title:      issue.Title,
+		State:      issue.State,
+		URL:        issue.WebURL(),
+		Repo:       issue.RepositoryID,
+		IsPR:       issue.IsPR,
+		Type:       issue.Type,
+		Assignees:  []string{},
+		TasksDone:  issue.TasksDone,
+		TasksTotal: issue.TasksTotal,
+	}
+	for _, assignee := range issue.Assignees {
+		data.Assignees = append(data.Assignees, assignee.Login)
+	}
+	if len(data.Assignees) > 0 {
+		data.Assignee = data.Assignees[0]
+	}
+	data.Estimate, _ = estimator.estimate(issue)
+	return data
+}
+
+// labeler renders node labels with the --node-label-template.
+type labeler struct {
+	tmpl      *template.Template
+	estimator *estimator
+}
+
+// newLabeler returns the labeler of the Graphviz formats.
+func newLabeler(opts *Options) (*labeler, error) {
+	return newLabelerWithDefault(opts, defaultLabelTemplate)
+}
+
+// newPlainLabeler returns the labeler of the svg, mermaid, plantuml and
+// cytoscape formats.
+func newPlainLabeler(opts *Options) (*labeler, error) {
+	return newLabelerWithDefault(opts, defaultPlainLabelTemplate)
+}
+
+func newLabelerWithDefault(opts *Options, fallback string) (*labeler, error) {
+	tmpl, err := parseLabelTemplate(opts.nodeLabelTemplate(), fallback)
+	if err != nil {
+		return nil, err
+	}
+	estimator, err := opts.estimator()
+	if err != nil {
+		return nil, err
+	}
+	return &labeler{tmpl: tmpl, estimator: estimator}, nil
+}
+
+func (l *labeler) label(issue *compute.ComputedIssue) string {
+	var b bytes.Buffer
+	if err := l.tmpl.Execute(&b, newLabelData(issue, l.estimator)); err != nil {
+		return issue.Title
+	}
+	return b.String()
+}
+
+// plain returns the label of a node, without double quotes as they are not
+// supported in quoted Mermaid and PlantUML labels.
+func (l *labeler) plain(n node) string {
+	label := n.Title
+	if n.issue != nil {
+		label = l.label(n.issue)
+	}
+	return strings.Replace(label, `"`, "'", -1)
+}
@@ -0,0 +1,81 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"strings"
+
+	"moul.io/depviz/compute"
+)
+
+const legendClusterID = "cluster_legend"
+
+// styleStates greys the closed issues and doubles the border of the pull
+// requests, as explained by the --legend.
+func styleStates(computed *compute.Computed, decorations *decorations) {
+	for _, issue := range computed.Issues() {
+		if issue.State == "closed" {
+			decorations.node(issue.URL)["color"] = "grey"
+			decorations.node(issue.URL)["fontcolor"] = "grey"
+		}
+		if issue.IsPR {
+			decorations.node(issue.URL)["peripheries"] = "2"
+		}
+	}
+}
+
+type legendEdge struct {
+	label string
+	attrs attrs
+}
+
+// legendEdges returns the edge styles of the rendered layout.
+func legendEdges(opts *Options) []legendEdge {
+	edges := []legendEdge{
+		{"dependency", attrs{}},
+		{"checked in a task list", attrs{"style": "dashed"}},
+	}
+	switch {
+	case opts.TreeFrom != "":
+		edges = append(edges, legendEdge{"other dependency", attrs{"style": "dashed", "color": "orange"}})
+	case !opts.NoPertEstimates:
+		edges = append(edges, legendEdge{"critical path", attrs{"color": "red"}})
+	}
+	return edges
+}
+
+// legendStatements returns the dot statements of the --legend, a cluster
+// apart from the graph, explaining the node and edge styles.
+func legendStatements(opts *Options) []string {
+	if !opts.Legend {
+		return nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "subgraph %s { graph %s; node %s; edge %s;",
+		dotQuote(legendClusterID),
+		attrs{"label": "Legend", "style": "dashed", "color": "grey", "fontsize": "10"}.dot(),
+		attrs{"fontsize": "10"}.dot(),
+		attrs{"fontsize": "10"}.dot(),
+	)
+	nodes := []struct {
+		id    string
+		attrs attrs
+	}{
+		{"legend_open", attrs{"label": "open issue"}},
+		{"legend_closed", attrs{"label": "closed issue", "color": "grey", "fontcolor": "grey"}},
+		{"legend_pr", attrs{"label": "pull request", "peripheries": "2"}},
+	}
+	for _, n := range nodes {
+		fmt.Fprintf(&b, " %s %s;", dotQuote(n.id), n.attrs.dot())
+	}
+	for idx, e := range legendEdges(opts) {
+		src, dst := fmt.Sprintf("legend_edge_%d_src", idx), fmt.Sprintf("legend_edge_%d_dst", idx)
+		point := attrs{"shape": "point", "label": ""}.dot()
+		edgeAttrs := attrs{"label": e.label}
+		for key, value := range e.attrs {
+			edgeAttrs[key] = value
+		}
+		fmt.Fprintf(&b, " %s %s; %s %s; %s -> %s %s;", dotQuote(src), point, dotQuote(dst), point, dotQuote(src), dotQuote(dst), edgeAttrs.dot())
+	}
+	b.WriteString(" }")
+	return []string{b.String()}
+}
@@ -0,0 +1,61 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"moul.io/depviz/compute"
+)
+
+var unsafeIDRegex = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// toMermaid renders a Mermaid flowchart, milestones and repos are drawn as
+// rounded nodes and closed issues use the 'closed' class.
+//
+// See https://mermaid.js.org/syntax/flowchart.html
+func toMermaid(computed *compute.Computed, opts *Options) (string, error) {
+	labeler, err := newPlainLabeler(opts)
+	if err != nil {
+		return "", err
+	}
+	nodes, edges := entities(computed)
+
+	var b strings.Builder
+	if opts.Vertical {
+		b.WriteString("graph TD\n")
+	} else {
+		b.WriteString("graph LR\n")
+	}
+	hasClosed := false
+	for _, n := range nodes {
+		id := safeID(n.ID)
+		label := labeler.plain(n)
+		switch {
+		case n.Kind != issueNode:
+			fmt.Fprintf(&b, "  %s([\"%s\"])\n", id, label)
+		default:
+			fmt.Fprintf(&b, "  %s[\"%s\"]\n", id, label)
+		}
+		if n.State == "closed" {
+			fmt.Fprintf(&b, "  class %s closed\n", id)
+			hasClosed = true
+		}
+		if n.URL != "" {
+			fmt.Fprintf(&b, "  click %s \"%s\"\n", id, strings.Replace(n.URL, `"`, "%22", -1))
+		}
+	}
+	for _, e := range edges {
+		fmt.Fprintf(&b, "  %s --> %s\n", safeID(e.Src), safeID(e.Dst))
+	}
+	if hasClosed {
+		b.WriteString("  classDef closed fill:#eee,stroke:#999,color:#999\n")
+	}
+	return b.String(), nil
+}
+
+// safeID returns a node identifier made of [a-zA-Z0-9_], as expected by
+// Mermaid and PlantUML.
+func safeID(id string) string {
+	return "n_" + strings.Trim(unsafeIDRegex.ReplaceAllString(id, "_"), "_")
+}
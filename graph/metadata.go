@@ -0,0 +1,98 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"moul.io/depviz/compute"
+)
+
+// dotMetadata returns the header and the per-node comments of --dot-metadata.
+//
+// Each comment is a single line starting with "// depviz:" (header) or
+// "// depviz-node:" followed by a JSON object, i.e.:
+//
+//	// depviz: {"generated-at":"2019-10-01T00:00:00Z","targets":["moul/depviz"],"filters":{"show-closed":true}}
+//	// depviz-node: {"id":"https://github.com/moul/depviz/issues/42","url":"https://github.com/moul/depviz/issues/42","state":"open"}
+func dotMetadata(computed *compute.Computed, opts *Options, now time.Time) (string, []string, error) {
+	targets := []string{}
+	for _, target := range opts.Targets {
+		targets = append(targets, target.String())
+	}
+	header := struct {
+		GeneratedAt string                 `json:"generated-at"`
+		Targets     []string               `json:"targets"`
+		Filters     map[string]interface{} `json:"filters"`
+	}{
+		GeneratedAt: now.UTC().Format(time.RFC3339),
+		Targets:     targets,
+		Filters:     opts.appliedFilters(),
+	}
+	b, err := json.Marshal(header)
+	if err != nil {
+		return "", nil, err
+	}
+
+	nodes := []string{}
+	for _, issue := range computed.Issues() {
+		line, err := json.Marshal(map[string]string{
+			"id":    issue.URL,
+			"url":   issue.URL,
+			"state": issue.State,
+		})
+		if err != nil {
+			return "", nil, err
+		}
+		nodes = append(nodes, fmt.Sprintf("// depviz-node: %s", line))
+	}
+	return fmt.Sprintf("// depviz: %s", b), nodes, nil
+}
+
+// appliedFilters returns the filtering options that differ from the defaults.
+func (opts Options) appliedFilters() map[string]interface{} {
+	filters := map[string]interface{}{}
+	for key, enabled := range map[string]bool{
+		"show-closed":      opts.ShowClosed,
+		"show-orphans":     opts.ShowOrphans,
+		"show-prs":         opts.ShowPRs,
+		"show-all-related": opts.ShowAllRelated,
+		"blocked-only":     opts.BlockedOnly,
+		"ready-only":       opts.ReadyOnly,
+	} {
+		if enabled {
+			filters[key] = true
+		}
+	}
+	for key, value := range map[string]string{
+		"view":      opts.View,
+		"tree-from": opts.TreeFrom,
+	} {
+		if value != "" {
+			filters[key] = value
+		}
+	}
+	for key, values := range map[string][]string{
+		"number-range":     opts.NumberRanges,
+		"filter-label":     opts.FilterLabels,
+		"filter-assignee":  opts.FilterAssignees,
+		"filter-milestone": opts.FilterMilestones,
+	} {
+		if len(values) > 0 {
+			filters[key] = values
+		}
+	}
+	return filters
+}
+
+// withDOTMetadata adds the --dot-metadata comments to a dot output.
+func withDOTMetadata(dot string, computed *compute.Computed, opts *Options) (string, error) {
+	if !opts.DOTMetadata {
+		return dot, nil
+	}
+	header, nodes, err := dotMetadata(computed, opts, time.Now())
+	if err != nil {
+		return "", err
+	}
+	return header + "\n" + insertDOTStatements(dot, nodes), nil
+}
@@ -0,0 +1,181 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+	"moul.io/depviz/compute"
+	"moul.io/graphman"
+	"moul.io/graphman/viz"
+)
+
+// milestoneDependsOnRegex matches the "Depends on: v2.0, v1.9" convention in
+// milestone descriptions.
+var milestoneDependsOnRegex = regexp.MustCompile(`(?im)^\s*depends\s+on\s*:\s*(.+)$`)
+
+// declaredMilestoneDependencies returns the dependencies between the visible
+// milestones, keyed by milestone URL. They are declared by title, in the
+// 'milestone-dependencies' section of the config file, i.e.:
+//
+//	milestone-dependencies:
+//	  v2.1: [v2.0]
+//
+// or with a "Depends on: v2.0" line in the milestone description.
+func declaredMilestoneDependencies(computed *compute.Computed, config map[string][]string) map[string][]string {
+	byTitle := map[string]string{}
+	for _, milestone := range computed.Milestones() {
+		byTitle[strings.ToLower(milestone.Title)] = milestone.URL
+	}
+	declared := map[string][]string{}
+	add := func(milestone string, titles []string) {
+		for _, title := range titles {
+			url, found := byTitle[strings.ToLower(strings.TrimSpace(title))]
+			if !found {
+				zap.L().Debug("unknown milestone dependency", zap.String("milestone", milestone), zap.String("dependency", title))
+				continue
+			}
+			if url != milestone && !contains(declared[milestone], url) {
+				declared[milestone] = append(declared[milestone], url)
+			}
+		}
+	}
+	for _, milestone := range computed.Milestones() {
+		for _, match := range milestoneDependsOnRegex.FindAllStringSubmatch(milestone.Description, -1) {
+			add(milestone.URL, strings.Split(match[1], ","))
+		}
+	}
+	for title, dependencies := range config {
+		if url, found := byTitle[strings.ToLower(title)]; found {
+			add(url, dependencies)
+		}
+	}
+	return declared
+}
+
+// impliedMilestoneDependencies rolls the dependencies between issues of
+// different milestones up: milestone A depends on milestone B if an issue of A
+// depends on an issue of B.
+func impliedMilestoneDependencies(computed *compute.Computed) map[string][]string {
+	milestoneOf := map[string]string{}
+	for _, issue := range computed.Issues() {
+		if issue.Milestone != nil {
+			milestoneOf[issue.URL] = issue.Milestone.URL
+		}
+	}
+	implied := map[string][]string{}
+	for _, issue := range computed.Issues() {
+		milestone, found := milestoneOf[issue.URL]
+		if !found {
+			continue
+		}
+		for _, dependency := range issue.DependsOn {
+			other, found := milestoneOf[dependency]
+			if !found || other == milestone || contains(implied[milestone], other) {
+				continue
+			}
+			implied[milestone] = append(implied[milestone], other)
+		}
+	}
+	return implied
+}
+
+func contains(slice []string, value string) bool {
+	for _, item := range slice {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// toMilestones renders the dependency graph of the milestones only, in the dot
+// or graphman-pert formats. The estimate of a milestone is the sum of the
+// estimates of its open issues, in days, one day for the unestimated ones.
+//
+// With --rollup-milestone-dependencies, the dependencies implied by the issues
+// are added to the declared ones, and the undeclared ones are reported.
+func toMilestones(computed *compute.Computed, opts *Options) (string, error) {
+	declared := declaredMilestoneDependencies(computed, opts.MilestoneDependencies)
+	dependencies := declared
+	if opts.RollupMilestoneDependencies {
+		dependencies = map[string][]string{}
+		for milestone, urls := range declared {
+			dependencies[milestone] = append([]string{}, urls...)
+		}
+		for milestone, urls := range impliedMilestoneDependencies(computed) {
+			for _, url := range urls {
+				if contains(declared[milestone], url) {
+					continue
+				}
+				zap.L().Warn("undeclared milestone dependency implied by issues",
+					zap.String("milestone", milestone),
+					zap.String("depends-on", url),
+				)
+				dependencies[milestone] = append(dependencies[milestone], url)
+			}
+		}
+	}
+
+	estimator, err := opts.estimator()
+	if err != nil {
+		return "", err
+	}
+	open := map[string]int{}
+	total := map[string]int{}
+	days := map[string]float64{}
+	for _, issue := range computed.Issues() {
+		if issue.Milestone == nil {
+			continue
+		}
+		total[issue.Milestone.URL]++
+		days[issue.Milestone.URL] += estimator.duration(issue)
+		if issue.State != "closed" {
+			open[issue.Milestone.URL]++
+		}
+	}
+
+	config := graphman.PertConfig{
+		Actions: []graphman.PertAction{},
+		States:  []graphman.PertState{},
+	}
+	for _, milestone := range computed.Milestones() {
+		dependsOn := dependencies[milestone.URL]
+		sort.Strings(dependsOn)
+		config.Actions = append(config.Actions, graphman.PertAction{
+			ID:        milestone.URL,
+			Title:     fmt.Sprintf("%s (%d/%d open)", milestone.Title, open[milestone.URL], total[milestone.URL]),
+			DependsOn: dependsOn,
+			Estimate:  []float64{days[milestone.URL], days[milestone.URL], days[milestone.URL]}, // optimistic, realistic, pessimistic
+		})
+	}
+
+	if opts.Format == "graphman-pert" {
+		out, err := yaml.Marshal(config)
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	}
+
+	graph := graphman.FromPertConfig(config)
+	if !opts.NoPertEstimates {
+		_ = graphman.ComputePert(graph)
+		shortestPath, _ := graph.FindShortestPath("Start", "Finish")
+		for _, edge := range shortestPath {
+			edge.Dst().SetColor("red")
+			edge.SetColor("red")
+		}
+	}
+	graph.GetVertex("Start").SetColor("blue")
+	graph.GetVertex("Finish").SetColor("blue")
+	if opts.Vertical {
+		graph.Attrs["rankdir"] = "TB"
+	}
+	return viz.ToGraphviz(graph, &viz.Opts{
+		CommentsInLabel: true,
+	})
+}
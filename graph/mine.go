@@ -0,0 +1,62 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"moul.io/depviz/github"
+	"moul.io/depviz/gitlab"
+	"moul.io/depviz/model"
+	"moul.io/multipmuri"
+)
+
+// authenticatedLogins resolves the logins of the owners of the tokens, for
+// each provider of the targets.
+func (opts Options) authenticatedLogins() ([]string, error) {
+	type multipmuriService interface {
+		ServiceEntity() multipmuri.Entity
+	}
+	logins := []string{}
+	resolved := map[string]bool{}
+	for _, target := range opts.Targets {
+		service := string(target.Provider())
+		if withService, ok := target.(multipmuriService); ok {
+			service = withService.ServiceEntity().String()
+		}
+		if resolved[service] {
+			continue
+		}
+		resolved[service] = true
+
+		var (
+			login string
+			err   error
+		)
+		switch {
+		case model.HostDriver(model.EntityHost(target)) == model.GiteaDriver:
+			continue // FIXME: support Gitea
+		case model.HostDriver(model.EntityHost(target)) == model.JiraDriver:
+			continue // FIXME: support Jira
+		case model.HostDriver(model.EntityHost(target)) == model.BitbucketDriver:
+			continue // FIXME: support Bitbucket
+		case target.Provider() == multipmuri.GitHubProvider:
+			if opts.GithubToken == "" {
+				return nil, fmt.Errorf("--mine requires --github-token")
+			}
+			login, err = github.AuthenticatedLogin(opts.GithubToken, opts.GithubBaseURL)
+		case target.Provider() == multipmuri.GitLabProvider:
+			if opts.GitlabToken == "" {
+				return nil, fmt.Errorf("--mine requires --gitlab-token")
+			}
+			login, err = gitlab.AuthenticatedLogin(service, opts.GitlabToken)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve the authenticated user of %s: %v", service, err)
+		}
+		zap.L().Debug("authenticated user", zap.String("service", service), zap.String("login", login))
+		logins = append(logins, login)
+	}
+	return logins, nil
+}
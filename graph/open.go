@@ -0,0 +1,65 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"runtime"
+
+	"go.uber.org/zap"
+)
+
+// openOutput writes the rendered output to a temporary file and opens it with
+// the default handler of the OS. dot is rendered to SVG with Graphviz first,
+// text formats are not opened.
+func openOutput(out string, format string) error {
+	var (
+		ext  string
+		data = []byte(out)
+	)
+	switch format {
+	case "dot":
+		binary, err := exec.LookPath("dot")
+		if err != nil {
+			return fmt.Errorf("--open requires graphviz: %v", err)
+		}
+		cmd := exec.Command(binary, "-Tsvg") // guardrails-disable-line
+		cmd.Stdin = bytes.NewBufferString(out)
+		if data, err = cmd.Output(); err != nil {
+			return fmt.Errorf("graphviz: %v", err)
+		}
+		ext = ".svg"
+	case "svg":
+		ext = ".svg"
+	case "xlsx":
+		ext = ".xlsx"
+	default:
+		zap.L().Warn("--open is ignored for text formats", zap.String("format", format))
+		return nil
+	}
+
+	f, err := ioutil.TempFile("", "depviz-*"+ext)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", f.Name()) // guardrails-disable-line
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", f.Name()) // guardrails-disable-line
+	default:
+		cmd = exec.Command("xdg-open", f.Name()) // guardrails-disable-line
+	}
+	zap.L().Debug("opening output", zap.String("path", f.Name()))
+	return cmd.Start()
+}
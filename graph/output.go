@@ -0,0 +1,27 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+)
+
+// writeOutput writes the rendered output to stdout, or to the --output file
+// (truncated), creating its parent directories.
+func writeOutput(path string, out string) error {
+	if path == "" || path == "-" {
+		_, err := fmt.Print(out)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot create the output directory: %v", err)
+	}
+	if err := ioutil.WriteFile(path, []byte(out), 0644); err != nil {
+		return fmt.Errorf("cannot write the output: %v", err)
+	}
+	zap.L().Debug("output written", zap.String("path", path), zap.Int("bytes", len(out)))
+	return nil
+}
@@ -0,0 +1,61 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+	"moul.io/graphman"
+)
+
+// pertCacheMaxEntries bounds the memory used by the cache, it is flushed when
+// full.
+const pertCacheMaxEntries = 64
+
+// renderCache stores the dot renderings of the PERT graphs, keyed on a hash of
+// everything they depend on (nodes, edges, estimates and styles), so
+// long-running processes (i.e., 'web') only recompute PERT when the graph
+// changes.
+type renderCache struct {
+	mu      sync.Mutex
+	entries map[string]string
+}
+
+var pertCache = &renderCache{entries: map[string]string{}}
+
+func (c *renderCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out, found := c.entries[key]
+	return out, found
+}
+
+func (c *renderCache) set(key, out string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= pertCacheMaxEntries {
+		c.entries = map[string]string{}
+	}
+	c.entries[key] = out
+}
+
+// InvalidatePertCache flushes the cached PERT renderings.
+func InvalidatePertCache() {
+	pertCache.mu.Lock()
+	defer pertCache.mu.Unlock()
+	pertCache.entries = map[string]string{}
+}
+
+func pertCacheKey(config graphman.PertConfig, opts *Options, decorations *decorations, groups []group) (string, error) {
+	in, err := yaml.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	_, _ = h.Write(in)
+	// fmt prints the maps sorted by key
+	fmt.Fprintf(h, "%t|%t|%v|%v|%v", opts.NoPertEstimates, opts.Vertical, decorations.nodes, decorations.edges, groups)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
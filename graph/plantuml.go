@@ -0,0 +1,48 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"strings"
+
+	"moul.io/depviz/compute"
+)
+
+// toPlantUML renders a PlantUML diagram with a rectangle per node, closed
+// issues are grey and milestones and repos are drawn as cards.
+//
+// See https://plantuml.com/deployment-diagram
+func toPlantUML(computed *compute.Computed, opts *Options) (string, error) {
+	labeler, err := newPlainLabeler(opts)
+	if err != nil {
+		return "", err
+	}
+	nodes, edges := entities(computed)
+
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	if opts.Vertical {
+		b.WriteString("top to bottom direction\n")
+	} else {
+		b.WriteString("left to right direction\n")
+	}
+	for _, n := range nodes {
+		element := "rectangle"
+		if n.Kind != issueNode {
+			element = "card"
+		}
+		label := strings.Replace(labeler.plain(n), "]", ")", -1)
+		fmt.Fprintf(&b, "%s \"%s\" as %s", element, label, safeID(n.ID))
+		if n.URL != "" {
+			fmt.Fprintf(&b, " [[%s]]", n.URL)
+		}
+		if n.State == "closed" {
+			b.WriteString(" #lightgrey")
+		}
+		b.WriteString("\n")
+	}
+	for _, e := range edges {
+		fmt.Fprintf(&b, "%s --> %s\n", safeID(e.Src), safeID(e.Dst))
+	}
+	b.WriteString("@enduml")
+	return b.String(), nil
+}
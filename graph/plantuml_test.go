@@ -0,0 +1,24 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToPlantUML(t *testing.T) {
+	output, err := toPlantUML(testComputed(), &Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "plantuml.puml", output)
+}
+
+func TestToPlantUMLVertical(t *testing.T) {
+	output, err := toPlantUML(testComputed(), &Options{Vertical: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, "\ntop to bottom direction\n") {
+		t.Errorf("expected a top to bottom diagram, got:\n%s", output)
+	}
+}
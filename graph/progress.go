@@ -0,0 +1,38 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// progress reports the duration of the graph build phases on stderr (--progress).
+type progress struct {
+	enabled bool
+	started time.Time
+	last    time.Time
+}
+
+func newProgress(enabled bool) *progress {
+	now := time.Now()
+	return &progress{enabled: enabled, started: now, last: now}
+}
+
+// start announces a phase, so long phases do not look like a hang.
+func (p *progress) start(phase string) {
+	if !p.enabled {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "progress: %s...\n", phase)
+}
+
+// done reports the end of a phase, with the number of processed items.
+func (p *progress) done(phase string, items int) {
+	if !p.enabled {
+		return
+	}
+	now := time.Now()
+	fmt.Fprintf(os.Stderr, "progress: %-24s %6d items  %10s  (total: %s)\n",
+		phase, items, now.Sub(p.last).Round(time.Millisecond), now.Sub(p.started).Round(time.Millisecond))
+	p.last = now
+}
@@ -0,0 +1,131 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"moul.io/depviz/compute"
+)
+
+const unscheduledMilestone = "unscheduled"
+
+// toRoadmap renders the milestones as clusters laid out from left to right by
+// due date, with their issues inside, in the dot format.
+//
+// The dependencies between issues of different milestones are drawn between
+// the clusters, and highlighted in red when they flow backward in time: an
+// issue depending on an issue of a milestone due later.
+// The issues without milestone are in a last "unscheduled" cluster.
+func toRoadmap(computed *compute.Computed, opts *Options) (string, error) {
+	labeler, err := newLabeler(opts)
+	if err != nil {
+		return "", err
+	}
+
+	// milestones ordered by due date, the ones without due date last
+	milestones := computed.Milestones()
+	sort.SliceStable(milestones, func(i, j int) bool {
+		a, b := milestones[i].DueOn, milestones[j].DueOn
+		if a.IsZero() != b.IsZero() {
+			return b.IsZero()
+		}
+		return a.Before(b)
+	})
+	dueOn := map[string]time.Time{}
+	members := map[string][]*compute.ComputedIssue{}
+	milestoneOf := map[string]string{}
+	order := []string{}
+	for _, milestone := range milestones {
+		dueOn[milestone.URL] = milestone.DueOn
+		order = append(order, milestone.URL)
+	}
+	order = append(order, unscheduledMilestone)
+	for _, issue := range computed.Issues() {
+		key := unscheduledMilestone
+		if issue.Milestone != nil {
+			if _, found := dueOn[issue.Milestone.URL]; found {
+				key = issue.Milestone.URL
+			}
+		}
+		milestoneOf[issue.URL] = key
+		members[key] = append(members[key], issue)
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph roadmap {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tcompound=true;\n")
+	b.WriteString("\tnode [shape=box];\n")
+	anchors := []string{}
+	for idx, key := range order {
+		issues := members[key]
+		if len(issues) == 0 {
+			continue
+		}
+		label := "Unscheduled"
+		for _, milestone := range milestones {
+			if milestone.URL == key {
+				label = milestone.Title
+				if !milestone.DueOn.IsZero() {
+					label += fmt.Sprintf(" (%s)", milestone.DueOn.Format("2006-01-02"))
+				}
+			}
+		}
+		anchor := fmt.Sprintf("anchor_%d", idx)
+		anchors = append(anchors, anchor)
+		fmt.Fprintf(&b, "\tsubgraph %s {\n", dotQuote(fmt.Sprintf("cluster_milestone_%d", idx)))
+		fmt.Fprintf(&b, "\t\tgraph %s;\n", attrs{"label": label, "style": "rounded"}.dot())
+		fmt.Fprintf(&b, "\t\t%s %s;\n", dotQuote(anchor), attrs{"style": "invis", "shape": "point", "width": "0"}.dot())
+		for _, issue := range issues {
+			nodeAttrs := attrs{"label": labeler.label(issue), "URL": issue.URL}
+			if issue.State == "closed" {
+				nodeAttrs["color"] = "grey"
+			}
+			fmt.Fprintf(&b, "\t\t%s %s;\n", dotQuote(issue.URL), nodeAttrs.dot())
+		}
+		b.WriteString("\t}\n")
+	}
+
+	// time axis
+	for idx := 1; idx < len(anchors); idx++ {
+		fmt.Fprintf(&b, "\t%s -> %s %s;\n", dotQuote(anchors[idx-1]), dotQuote(anchors[idx]), attrs{"style": "invis", "weight": "100"}.dot())
+	}
+
+	// dependencies
+	for _, issue := range computed.Issues() {
+		for _, dependency := range issue.DependsOn {
+			dependencyMilestone, found := milestoneOf[dependency]
+			if !found {
+				continue
+			}
+			edgeAttrs := attrs{}
+			if dependencyMilestone != milestoneOf[issue.URL] {
+				edgeAttrs["constraint"] = "false"
+				if isBackward(dueOn, dependencyMilestone, milestoneOf[issue.URL]) {
+					edgeAttrs["color"] = "red"
+					edgeAttrs["penwidth"] = "2"
+					edgeAttrs["tooltip"] = "depends on a later milestone"
+				}
+			}
+			fmt.Fprintf(&b, "\t%s -> %s %s;\n", dotQuote(dependency), dotQuote(issue.URL), edgeAttrs.dot())
+		}
+	}
+	b.WriteString("}")
+	return b.String(), nil
+}
+
+// isBackward returns true if an issue of the dependent milestone depends on an
+// issue of a milestone due later (or unscheduled).
+func isBackward(dueOn map[string]time.Time, dependency, dependent string) bool {
+	dependentDue := dueOn[dependent]
+	if dependent == unscheduledMilestone || dependentDue.IsZero() {
+		return false
+	}
+	dependencyDue := dueOn[dependency]
+	if dependency == unscheduledMilestone || dependencyDue.IsZero() {
+		return true
+	}
+	return dependencyDue.After(dependentDue)
+}
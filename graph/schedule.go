@@ -0,0 +1,105 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"strconv"
+
+	"go.uber.org/zap"
+	"moul.io/depviz/compute"
+)
+
+// Schedule is the PERT schedule of an issue, in days, relative to the start of
+// the project.
+type Schedule struct {
+	EarliestStart float64 `json:"earliest-start" yaml:"earliest-start"`
+	LatestStart   float64 `json:"latest-start" yaml:"latest-start"`
+	Slack         float64 `json:"slack" yaml:"slack"`       // how much the issue can be delayed without delaying the project
+	Critical      bool    `json:"critical" yaml:"critical"` // no slack, the issue is on a critical path
+}
+
+// ComputeSchedule returns the schedule of the issues keyed by URL, with the
+// same estimates as ComputeCriticalPath. The dependencies outside of the set
+// are ignored, an error is returned if they contain a cycle.
+func ComputeSchedule(issues []*compute.ComputedIssue) (map[string]Schedule, error) {
+	return computeSchedule(issues, defaultEstimator)
+}
+
+func computeSchedule(issues []*compute.ComputedIssue, estimator *estimator) (map[string]Schedule, error) {
+	order, err := topologicalOrder(issues)
+	if err != nil {
+		return nil, err
+	}
+
+	// forward pass: earliest starts
+	earliestFinish := map[string]float64{}
+	dependents := map[string][]string{}
+	schedule := map[string]Schedule{}
+	end := 0.0
+	for _, issue := range order {
+		start := 0.0
+		for _, dependency := range issue.DependsOn {
+			finish, found := earliestFinish[dependency] // dependencies come first
+			if !found {
+				continue
+			}
+			dependents[dependency] = append(dependents[dependency], issue.URL)
+			if finish > start {
+				start = finish
+			}
+		}
+		earliestFinish[issue.URL] = start + estimator.duration(issue)
+		if earliestFinish[issue.URL] > end {
+			end = earliestFinish[issue.URL]
+		}
+		schedule[issue.URL] = Schedule{EarliestStart: start}
+	}
+
+	// backward pass: latest starts
+	for idx := len(order) - 1; idx >= 0; idx-- {
+		issue := order[idx]
+		finish := end
+		for _, dependent := range dependents[issue.URL] {
+			if start := schedule[dependent].LatestStart; start < finish {
+				finish = start
+			}
+		}
+		entry := schedule[issue.URL]
+		entry.LatestStart = finish - estimator.duration(issue)
+		entry.Slack = entry.LatestStart - entry.EarliestStart
+		entry.Critical = entry.Slack == 0
+		schedule[issue.URL] = entry
+	}
+	return schedule, nil
+}
+
+// pertSchedule computes the schedule of the visible issues, unless the PERT
+// estimates are disabled. A graph with cycles has no schedule.
+func pertSchedule(computed *compute.Computed, opts *Options) map[string]Schedule {
+	if opts.NoPertEstimates {
+		return nil
+	}
+	estimator, err := opts.estimator()
+	if err != nil {
+		zap.L().Warn("cannot compute the PERT schedule", zap.Error(err))
+		return nil
+	}
+	schedule, err := computeSchedule(computed.Issues(), estimator)
+	if err != nil {
+		zap.L().Warn("cannot compute the PERT schedule", zap.Error(err))
+		return nil
+	}
+	return schedule
+}
+
+// scheduleDecorations adds the schedule as node attributes.
+func scheduleDecorations(schedule map[string]Schedule, decorations *decorations) {
+	format := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	for url, entry := range schedule {
+		nodeAttrs := decorations.node(url)
+		nodeAttrs["earliest_start"] = format(entry.EarliestStart)
+		nodeAttrs["latest_start"] = format(entry.LatestStart)
+		nodeAttrs["slack"] = format(entry.Slack)
+		if entry.Critical {
+			nodeAttrs["critical"] = "true"
+		}
+	}
+}
@@ -0,0 +1,441 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"moul.io/depviz/compute"
+)
+
+const (
+	svgNodeWidth  = 240
+	svgNodeHeight = 44
+	svgRankGap    = 80
+	svgNodeGap    = 24
+	svgMargin     = 20
+	svgMaxLabel   = 34
+	svgClusterPad = 12  // around the nodes of a --cluster-by band
+	svgClusterTop = 28  // room for the cluster label
+	svgLegendSize = 24  // height of the --color-by age legend and the --legend
+	svgLegendItem = 100 // width of the node samples of the --legend
+	svgTitleSize  = 36  // room for the --graph-title
+
+	// svgLegendWidth is the width of the --legend: the node styles and an edge
+	svgLegendWidth = 3*(svgLegendItem+svgNodeGap) + 140
+
+	svgOpenFill     = "#fff"
+	svgOpenStroke   = "#333"
+	svgClosedFill   = "#eee"
+	svgClosedStroke = "#bebebe" // "grey" in Graphviz
+	svgEdgeStroke   = "#555"
+	svgCritical     = "red" // the issues and edges of the critical path
+
+	// maxSVGNodes is the size above which the built-in layout gives up, the
+	// result would not be readable anyway.
+	maxSVGNodes = 2000
+)
+
+// toSVG renders an SVG image with a simple built-in layered layout, so the
+// Graphviz binary is not required: the nodes are ranked by their longest chain
+// of dependencies, and ordered in their rank by the mean position of their
+// dependencies. The cycles are broken by laying out their closing edge
+// backwards. The decorations of the dot format (--color-by, --focus,
+// --highlight-changes-since, type shapes, critical path) are mapped to SVG.
+// Use the dot format and Graphviz for complex graphs.
+func toSVG(computed *compute.Computed, opts *Options) (string, error) {
+	labeler, err := newPlainLabeler(opts)
+	if err != nil {
+		return "", err
+	}
+	nodes, edges := entities(computed)
+	if len(nodes) > maxSVGNodes {
+		return "", fmt.Errorf("the graph is too large for the built-in SVG layout (%d nodes, max %d), use --format=dot and Graphviz instead", len(nodes), maxSVGNodes)
+	}
+
+	// ranks, on the acyclic layout edges
+	layoutEdges := acyclicEdges(nodes, edges)
+	dependencies := map[string][]string{}
+	for _, e := range layoutEdges {
+		dependencies[e.Dst] = append(dependencies[e.Dst], e.Src)
+	}
+	rank := map[string]int{}
+	for {
+		changed := false
+		for _, e := range layoutEdges {
+			if rank[e.Dst] < rank[e.Src]+1 {
+				rank[e.Dst] = rank[e.Src] + 1
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	decorations, err := opts.decorations(computed)
+	if err != nil {
+		return "", err
+	}
+	scheduleDecorations(pertSchedule(computed, opts), decorations)
+
+	// --color-by age legend, the nodes are filled by the decorations
+	var threshold time.Duration
+	if opts.ColorBy == "age" {
+		if threshold, err = parseAge(opts.StaleThreshold); err != nil {
+			return "", err
+		}
+	}
+
+	// --cluster-by: each cluster is drawn as a band across the ranks, the
+	// nodes without cluster are drawn after them
+	groups := []group{}
+	if opts.ClusterBy != "" {
+		groups = clusterGroups(computed, opts.ClusterBy)
+	}
+	band := map[string]int{}
+	for _, n := range nodes {
+		band[n.ID] = len(groups)
+	}
+	for idx, g := range groups {
+		for _, member := range g.Members {
+			band[member] = idx
+		}
+	}
+
+	// positions in ranks
+	ranks := [][]node{}
+	for _, n := range nodes {
+		for len(ranks) <= rank[n.ID] {
+			ranks = append(ranks, []node{})
+		}
+		ranks[rank[n.ID]] = append(ranks[rank[n.ID]], n)
+	}
+	position := map[string]float64{}
+	for _, nodes := range ranks {
+		weight := map[string]float64{}
+		for idx, n := range nodes {
+			weight[n.ID] = float64(idx)
+			if len(dependencies[n.ID]) > 0 {
+				sum := 0.0
+				for _, dependency := range dependencies[n.ID] {
+					sum += position[dependency]
+				}
+				weight[n.ID] = sum / float64(len(dependencies[n.ID]))
+			}
+		}
+		sort.SliceStable(nodes, func(i, j int) bool {
+			if band[nodes[i].ID] != band[nodes[j].ID] {
+				return band[nodes[i].ID] < band[nodes[j].ID]
+			}
+			return weight[nodes[i].ID] < weight[nodes[j].ID]
+		})
+		for idx, n := range nodes {
+			position[n.ID] = float64(idx)
+		}
+	}
+
+	// slots in the bands, and offset of the bands
+	slot := map[string]int{}
+	bandSize := make([]int, len(groups)+1)
+	for _, nodes := range ranks {
+		count := make([]int, len(groups)+1)
+		for _, n := range nodes {
+			slot[n.ID] = count[band[n.ID]]
+			count[band[n.ID]]++
+		}
+		for b := range count {
+			if count[b] > bandSize[b] {
+				bandSize[b] = count[b]
+			}
+		}
+	}
+	pitch, before, top := svgNodeHeight+svgNodeGap, svgClusterTop, 0
+	if opts.Vertical { // the bands are columns, their labels are on top
+		pitch, before = svgNodeWidth+svgNodeGap, svgClusterPad
+		if len(groups) > 0 {
+			top = svgClusterTop
+		}
+	}
+	bandOffset := make([]int, len(groups)+1)
+	offset := 0
+	for b, size := range bandSize {
+		clustered := b < len(groups) && size > 0
+		if clustered {
+			offset += before
+		}
+		bandOffset[b] = offset
+		offset += size * pitch
+		if clustered {
+			offset += svgClusterPad
+		}
+	}
+
+	// coordinates of the top-left corners
+	type point struct{ x, y int }
+	coords := map[string]point{}
+	width, height := 0, 0
+	for r, nodes := range ranks {
+		for _, n := range nodes {
+			p := point{
+				x: svgMargin + r*(svgNodeWidth+svgRankGap),
+				y: svgMargin + bandOffset[band[n.ID]] + slot[n.ID]*pitch,
+			}
+			if opts.Vertical {
+				p = point{
+					x: svgMargin + bandOffset[band[n.ID]] + slot[n.ID]*pitch,
+					y: svgMargin + top + r*(svgNodeHeight+svgRankGap),
+				}
+			}
+			coords[n.ID] = p
+			if p.x+svgNodeWidth+svgMargin > width {
+				width = p.x + svgNodeWidth + svgMargin
+			}
+			if p.y+svgNodeHeight+svgMargin > height {
+				height = p.y + svgNodeHeight + svgMargin
+			}
+		}
+	}
+
+	legendTop := height
+	if threshold > 0 { // below the nodes and the clusters
+		height += svgLegendSize + svgMargin/2
+		if width < svgNodeWidth+2*svgMargin {
+			width = svgNodeWidth + 2*svgMargin
+		}
+	}
+	stylesTop := height
+	if opts.Legend { // below the --color-by age legend
+		height += svgLegendSize + svgMargin/2
+		if width < svgLegendWidth+2*svgMargin {
+			width = svgLegendWidth + 2*svgMargin
+		}
+	}
+	titleHeight := 0
+	if opts.GraphTitle != "" { // the graph is moved down
+		titleHeight = svgTitleSize
+		height += titleHeight
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n", width, height, width, height)
+	b.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto"><path d="M0,0 L10,5 L0,10 z" fill="#555"/></marker></defs>` + "\n")
+	if opts.BgColor != "" {
+		fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", svgEscape(opts.BgColor))
+	}
+	if titleHeight > 0 {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" dominant-baseline="middle" font-size="20">%s</text>`+"\n", width/2, svgMargin/2+titleHeight/2, svgEscape(opts.GraphTitle))
+		fmt.Fprintf(&b, `<g transform="translate(0,%d)">`+"\n", titleHeight)
+	}
+	for idx, g := range groups {
+		if bandSize[idx] == 0 {
+			continue
+		}
+		extent := bandSize[idx]*pitch - svgNodeGap + 2*svgClusterPad
+		x, y, w, h := svgMargin/2, svgMargin+bandOffset[idx]-svgClusterTop+svgClusterPad/2, width-svgMargin, extent+svgClusterTop-svgClusterPad-svgClusterPad/2
+		if opts.Vertical {
+			x, y, w, h = svgMargin+bandOffset[idx]-svgClusterPad, svgMargin/2, extent, legendTop-svgMargin
+		}
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" rx="8" fill="none" stroke="#999" stroke-dasharray="4 2"/>`, x, y, w, h)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" fill="#555">%s</text>`+"\n", x+8, y+16, svgEscape(g.Label))
+	}
+	for _, e := range edges {
+		src, dst := coords[e.Src], coords[e.Dst]
+		x1, y1 := src.x+svgNodeWidth, src.y+svgNodeHeight/2
+		x2, y2 := dst.x, dst.y+svgNodeHeight/2
+		if opts.Vertical {
+			x1, y1 = src.x+svgNodeWidth/2, src.y+svgNodeHeight
+			x2, y2 = dst.x+svgNodeWidth/2, dst.y
+		}
+		stroke, strokeWidth, dash := svgEdgeStroke, "1", ""
+		if decorations.nodes[e.Src]["critical"] == "true" && decorations.nodes[e.Dst]["critical"] == "true" {
+			stroke = svgCritical
+		}
+		edgeAttrs := decorations.edges[[2]string{e.Src, e.Dst}]
+		if edgeAttrs["color"] != "" {
+			stroke = svgColor(edgeAttrs["color"])
+		}
+		if strings.Contains(edgeAttrs["style"], "bold") {
+			strokeWidth = "2"
+		}
+		if edgeAttrs["penwidth"] != "" {
+			strokeWidth = edgeAttrs["penwidth"]
+		}
+		if strings.Contains(edgeAttrs["style"], "dashed") {
+			dash = ` stroke-dasharray="4 2"`
+		}
+		fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="%s"%s marker-end="url(#arrow)"/>`+"\n", x1, y1, x2, y2, svgEscape(stroke), svgEscape(strokeWidth), dash)
+	}
+	for _, n := range nodes {
+		p := coords[n.ID]
+		fill, stroke, rx, strokeWidth, textColor := svgOpenFill, svgOpenStroke, 4, "1", "#000"
+		if n.State == "closed" {
+			fill = svgClosedFill
+		}
+		if n.Kind != issueNode {
+			rx = svgNodeHeight / 2
+		}
+		nodeAttrs := decorations.nodes[n.ID]
+		if nodeAttrs["critical"] == "true" {
+			stroke, strokeWidth = svgCritical, "2"
+		}
+		if strings.Contains(nodeAttrs["style"], "filled") && nodeAttrs["fillcolor"] != "" {
+			fill = svgColor(nodeAttrs["fillcolor"])
+		}
+		if nodeAttrs["color"] != "" {
+			stroke = svgColor(nodeAttrs["color"])
+		}
+		if nodeAttrs["fontcolor"] != "" {
+			textColor = svgColor(nodeAttrs["fontcolor"])
+		}
+		if nodeAttrs["penwidth"] != "" {
+			strokeWidth = nodeAttrs["penwidth"]
+		}
+		fill, stroke, textColor, strokeWidth = svgEscape(fill), svgEscape(stroke), svgEscape(textColor), svgEscape(strokeWidth)
+		label := labeler.plain(n)
+		if runes := []rune(label); len(runes) > svgMaxLabel {
+			label = string(runes[:svgMaxLabel-1]) + "…"
+		}
+		fmt.Fprintf(&b, `<a xlink:href="%s"><title>%s</title>`, svgEscape(n.URL), svgEscape(labeler.plain(n)))
+		b.WriteString(svgShape(nodeAttrs["shape"], p.x, p.y, svgNodeWidth, svgNodeHeight, rx, fill, stroke, strokeWidth))
+		if nodeAttrs["peripheries"] == "2" {
+			b.WriteString(svgShape(nodeAttrs["shape"], p.x+3, p.y+3, svgNodeWidth-6, svgNodeHeight-6, rx, "none", stroke, "1"))
+		}
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" dominant-baseline="middle" fill="%s">%s</text></a>`+"\n", p.x+svgNodeWidth/2, p.y+svgNodeHeight/2, textColor, svgEscape(label))
+	}
+	if threshold > 0 {
+		y := legendTop
+		fmt.Fprintf(&b, `<linearGradient id="age"><stop offset="0" stop-color="%s"/><stop offset="1" stop-color="%s"/></linearGradient>`, ageColor(0, 1), ageColor(1, 1))
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="url(#age)" stroke="#333"/>`, svgMargin, y, svgNodeWidth, svgLegendSize)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" dominant-baseline="middle" font-size="10">%s</text>`+"\n", svgMargin+svgNodeWidth/2, y+svgLegendSize/2, svgEscape(ageLegendLabel(opts.StaleThreshold)))
+	}
+	if opts.Legend {
+		y, x := stylesTop, svgMargin
+		items := []struct {
+			label, fill, stroke string
+			pr                  bool
+		}{
+			{"open issue", svgOpenFill, svgOpenStroke, false},
+			{"closed issue", svgClosedFill, svgClosedStroke, false},
+			{"pull request", svgOpenFill, svgOpenStroke, true},
+		}
+		for _, item := range items {
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" rx="4" fill="%s" stroke="%s"/>`, x, y, svgLegendItem, svgLegendSize, item.fill, item.stroke)
+			if item.pr {
+				b.WriteString(svgInnerBorder(x, y, svgLegendItem, svgLegendSize, 4, item.stroke))
+			}
+			fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" dominant-baseline="middle" font-size="10">%s</text>`+"\n", x+svgLegendItem/2, y+svgLegendSize/2, item.label)
+			x += svgLegendItem + svgNodeGap
+		}
+		fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#555" marker-end="url(#arrow)"/>`, x, y+svgLegendSize/2, x+60, y+svgLegendSize/2)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" dominant-baseline="middle" font-size="10">dependency</text>`+"\n", x+66, y+svgLegendSize/2)
+	}
+	if titleHeight > 0 {
+		b.WriteString("</g>\n")
+	}
+	if opts.Watermark != "" {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end" font-size="14" fill="#999" fill-opacity="0.6">%s</text>`+"\n", width-svgMargin, height-svgMargin/2, svgEscape(opts.Watermark))
+	}
+	b.WriteString("</svg>")
+	return b.String(), nil
+}
+
+// svgShapes are the Graphviz node shapes supported by the svg format, see
+// the 'shape' of the type rules.
+var svgShapes = map[string]bool{
+	"": true, "box": true, "rect": true, "rectangle": true,
+	"ellipse": true, "oval": true, "circle": true,
+	"diamond": true,
+}
+
+// svgShape returns the outline of a node in the box of the given size, shape
+// is one of svgShapes.
+func svgShape(shape string, x, y, width, height, rx int, fill, stroke, strokeWidth string) string {
+	switch shape {
+	case "ellipse", "oval", "circle":
+		return fmt.Sprintf(`<ellipse cx="%d" cy="%d" rx="%d" ry="%d" fill="%s" stroke="%s" stroke-width="%s"/>`, x+width/2, y+height/2, width/2, height/2, fill, stroke, strokeWidth)
+	case "diamond":
+		return fmt.Sprintf(`<polygon points="%d,%d %d,%d %d,%d %d,%d" fill="%s" stroke="%s" stroke-width="%s"/>`, x+width/2, y, x+width, y+height/2, x+width/2, y+height, x, y+height/2, fill, stroke, strokeWidth)
+	case "rect", "rectangle":
+		rx = 0
+	}
+	return fmt.Sprintf(`<rect x="%d" y="%d" width="%d" height="%d" rx="%d" fill="%s" stroke="%s" stroke-width="%s"/>`, x, y, width, height, rx, fill, stroke, strokeWidth)
+}
+
+// svgColors are the Graphviz colors of the decorations unknown or different
+// in SVG.
+var svgColors = map[string]string{
+	"grey":   svgClosedStroke,
+	"grey70": "#b3b3b3",
+	"grey80": "#ccc",
+}
+
+// svgColor converts a Graphviz color of the decorations to SVG.
+func svgColor(color string) string {
+	if converted, found := svgColors[color]; found {
+		return converted
+	}
+	return color
+}
+
+// acyclicEdges returns the edges used by the layout: the edges closing a
+// cycle, found by a depth-first search from the nodes in ID order, are
+// reversed.
+func acyclicEdges(nodes []node, edges []edge) []edge {
+	successors := map[string][]int{}
+	for idx, e := range edges {
+		successors[e.Src] = append(successors[e.Src], idx)
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	reversed := map[int]bool{}
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		for _, idx := range successors[id] {
+			switch state[edges[idx].Dst] {
+			case visiting:
+				reversed[idx] = true
+			case 0:
+				visit(edges[idx].Dst)
+			}
+		}
+		state[id] = visited
+	}
+	for _, n := range nodes {
+		if state[n.ID] == 0 {
+			visit(n.ID)
+		}
+	}
+	if len(reversed) == 0 {
+		return edges
+	}
+	zap.L().Warn("the graph has dependency cycles, they are laid out with a reversed edge", zap.Int("reversed-edges", len(reversed)))
+	layout := make([]edge, len(edges))
+	for idx, e := range edges {
+		if reversed[idx] {
+			e.Src, e.Dst = e.Dst, e.Src
+		}
+		layout[idx] = e
+	}
+	return layout
+}
+
+// svgInnerBorder returns the second border of the pull requests, like the
+// peripheries=2 of the dot format.
+func svgInnerBorder(x, y, width, height, rx int, stroke string) string {
+	return fmt.Sprintf(`<rect x="%d" y="%d" width="%d" height="%d" rx="%d" fill="none" stroke="%s"/>`, x+3, y+3, width-6, height-6, rx, stroke)
+}
+
+func svgEscape(input string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(input))
+	return b.String()
+}
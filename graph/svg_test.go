@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+)
+
+func TestAcyclicEdges(t *testing.T) {
+	nodes := []node{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	edges := []edge{
+		{Src: "a", Dst: "b"},
+		{Src: "b", Dst: "c"},
+		{Src: "c", Dst: "a"},
+	}
+	layout := acyclicEdges(nodes, edges)
+	expected := []edge{
+		{Src: "a", Dst: "b"},
+		{Src: "b", Dst: "c"},
+		{Src: "a", Dst: "c"},
+	}
+	for idx := range expected {
+		if layout[idx] != expected[idx] {
+			t.Errorf("expected %v, got %v", expected, layout)
+			break
+		}
+	}
+	if edges[2].Src != "c" {
+		t.Errorf("the input edges should not be modified")
+	}
+}
+
+func TestToSVGCycle(t *testing.T) {
+	first := testIssue("issues/1", "First")
+	second := testIssue("issues/2", "Second")
+	links := []*model.Link{
+		model.NewLink(first.URL, model.BlocksLink, second.URL, "body"),
+		model.NewLink(second.URL, model.BlocksLink, first.URL, "body"),
+	}
+	computed := compute.ComputeWithLinks(model.Issues{first, second}, links, compute.ParseOptions{})
+	output, err := toSVG(&computed, &Options{})
+	if err != nil {
+		t.Fatalf("expected the cycle to be laid out, got %v", err)
+	}
+	if count := strings.Count(output, "<line "); count != 2 {
+		t.Errorf("expected the 2 edges of the cycle, got %d", count)
+	}
+}
+
+func TestToSVGDecorations(t *testing.T) {
+	output, err := toSVG(testComputed(), &Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the closed issue is greyed like in the dot format
+	if !strings.Contains(output, `stroke="`+svgClosedStroke+`"`) {
+		t.Errorf("expected the closed issue to be greyed:\n%s", output)
+	}
+}
+
+func TestValidateSVGShapes(t *testing.T) {
+	opts := Options{Format: "svg", Types: []compute.TypeRule{{Name: "epic", Shape: "hexagon"}}}
+	if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), "hexagon") {
+		t.Errorf("expected the hexagon shape to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+)
+
+// toTree renders the breakdown of a single issue as a top-down tree, in the
+// dot format.
+//
+// The tree is built with a BFS from the root following the depends-on edges
+// (parent-of links are resolved as depends-on edges), each BFS level is a rank.
+// When a dependency is shared, it is attached to the first parent reaching it
+// and the other edges are drawn as dashed cross-edges that do not constrain
+// the layout.
+func toTree(computed *compute.Computed, opts *Options) (string, error) {
+	root, err := model.ParseTarget(opts.TreeFrom)
+	if err != nil {
+		return "", err
+	}
+	visible := map[string]*compute.ComputedIssue{}
+	for _, issue := range computed.Issues() {
+		visible[issue.URL] = issue
+	}
+	if _, found := visible[root.String()]; !found {
+		return "", fmt.Errorf("tree root %q is not in the graph (check the targets and filters)", opts.TreeFrom)
+	}
+
+	labeler, err := newLabeler(opts)
+	if err != nil {
+		return "", err
+	}
+	decorations, err := opts.decorations(computed)
+	if err != nil {
+		return "", err
+	}
+
+	// BFS
+	levels := map[string]int{root.String(): 0}
+	ranks := [][]string{{root.String()}}
+	treeEdges := [][2]string{}
+	crossEdges := [][2]string{}
+	for queue := []string{root.String()}; len(queue) > 0; queue = queue[1:] {
+		parent := queue[0]
+		children := append([]string{}, visible[parent].DependsOn...)
+		sort.Strings(children)
+		for _, child := range children {
+			if _, found := visible[child]; !found { // hidden or missing
+				continue
+			}
+			if _, found := levels[child]; found {
+				crossEdges = append(crossEdges, [2]string{parent, child})
+				continue
+			}
+			level := levels[parent] + 1
+			levels[child] = level
+			if len(ranks) == level {
+				ranks = append(ranks, []string{})
+			}
+			ranks[level] = append(ranks[level], child)
+			treeEdges = append(treeEdges, [2]string{parent, child})
+			queue = append(queue, child)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph tree {\n")
+	b.WriteString("\trankdir=TB;\n")
+	b.WriteString("\tnode [shape=box];\n")
+	for _, rank := range ranks {
+		for _, url := range rank {
+			issue := visible[url]
+			nodeAttrs := attrs{
+				"label": labeler.label(issue),
+				"URL":   issue.URL,
+			}
+			if issue.State == "closed" {
+				nodeAttrs["color"] = "grey"
+			}
+			for key, value := range decorations.nodes[url] {
+				nodeAttrs[key] = value
+			}
+			fmt.Fprintf(&b, "\t%s %s;\n", dotQuote(url), nodeAttrs.dot())
+		}
+	}
+	for _, rank := range ranks[1:] {
+		quoted := []string{}
+		for _, url := range rank {
+			quoted = append(quoted, dotQuote(url))
+		}
+		fmt.Fprintf(&b, "\t{ rank=same; %s; }\n", strings.Join(quoted, "; "))
+	}
+	for _, e := range treeEdges {
+		edgeAttrs := attrs{}
+		// edges are stored in the scheduling order (dependency first)
+		for key, value := range decorations.edges[[2]string{e[1], e[0]}] {
+			edgeAttrs[key] = value
+		}
+		fmt.Fprintf(&b, "\t%s -> %s %s;\n", dotQuote(e[0]), dotQuote(e[1]), edgeAttrs.dot())
+	}
+	for _, e := range crossEdges {
+		edgeAttrs := attrs{"style": "dashed", "color": "orange", "constraint": "false"}
+		for key, value := range decorations.edges[[2]string{e[1], e[0]}] {
+			edgeAttrs[key] = value
+		}
+		fmt.Fprintf(&b, "\t%s -> %s %s;\n", dotQuote(e[0]), dotQuote(e[1]), edgeAttrs.dot())
+	}
+	b.WriteString("}")
+
+	groups, err := opts.groups(computed)
+	if err != nil {
+		return "", err
+	}
+	return insertDOTStatements(b.String(), dotClusters(groups)), nil
+}
+
+// dot returns the attributes in the dot syntax, sorted by key.
+func (a attrs) dot() string {
+	keys := []string{}
+	for key := range a {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := []string{}
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, dotQuote(a[key])))
+	}
+	return "[" + strings.Join(pairs, ", ") + "]"
+}
+
+// dotEscaper escapes the backslashes, quotes and line breaks, the newlines are
+// kept as Graphviz line breaks.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r\n", `\n`, "\n", `\n`, "\r", "")
+
+func dotQuote(input string) string {
+	return `"` + dotEscaper.Replace(input) + `"`
+}
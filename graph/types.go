@@ -0,0 +1,48 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"sort"
+
+	"moul.io/depviz/compute"
+)
+
+// typePalette are the fill colors of --color-by type, assigned in the order
+// of the type names.
+var typePalette = []string{
+	"lightblue", "lightsalmon", "palegreen", "khaki", "plum",
+	"lightpink", "lightcyan", "wheat", "lavender", "honeydew",
+}
+
+// styleTypes applies the shapes of the type rules, and fills the nodes with a
+// color per type if colorize is true.
+func styleTypes(computed *compute.Computed, rules []compute.TypeRule, colorize bool, decorations *decorations) {
+	shapes := map[string]string{}
+	for _, rule := range rules {
+		if rule.Shape != "" {
+			shapes[rule.Name] = rule.Shape
+		}
+	}
+	types := map[string]bool{}
+	for _, issue := range computed.Issues() {
+		types[issue.Type] = true
+	}
+	names := []string{}
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	colors := map[string]string{}
+	for idx, name := range names {
+		colors[name] = typePalette[idx%len(typePalette)]
+	}
+
+	for _, issue := range computed.Issues() {
+		if shape, found := shapes[issue.Type]; found {
+			decorations.node(issue.URL)["shape"] = shape
+		}
+		if colorize {
+			decorations.node(issue.URL)["style"] = "filled"
+			decorations.node(issue.URL)["fillcolor"] = colors[issue.Type]
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"moul.io/depviz/compute"
+)
+
+// View is a named combination of filters, defined in the config file, i.e.:
+//
+//	views:
+//	  frontend-blockers:
+//	    labels: [frontend, blocker]
+//	    states: [open]
+//	  release-critical:
+//	    milestones: [v2.0]
+type View struct {
+	Labels     []string `mapstructure:"labels"`
+	Milestones []string `mapstructure:"milestones"`
+	States     []string `mapstructure:"states"`
+}
+
+func (opts Options) validateView() error {
+	if opts.View == "" {
+		return nil
+	}
+	if _, found := opts.Views[opts.View]; found {
+		return nil
+	}
+	available := []string{}
+	for name := range opts.Views {
+		available = append(available, name)
+	}
+	sort.Strings(available)
+	if len(available) == 0 {
+		return fmt.Errorf("unknown view %q, no views are defined in the config file", opts.View)
+	}
+	return fmt.Errorf("unknown view %q, available views: %s", opts.View, strings.Join(available, ", "))
+}
+
+func (v View) apply(computed *compute.Computed) {
+	computed.FilterByLabels(v.Labels)
+	computed.FilterByMilestones(v.Milestones)
+	computed.FilterByStates(v.States)
+}
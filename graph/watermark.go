@@ -0,0 +1,30 @@
+package graph // import "moul.io/depviz/graph"
+
+import "fmt"
+
+// presentationStatements returns the dot statements of --bg-color,
+// --graph-title and --watermark. The title is the graph label at the top, the
+// watermark an unobtrusive one at the bottom right, drawn by Graphviz on every
+// rendered image (svg, png, pdf, ...).
+func presentationStatements(opts *Options) []string {
+	graphAttrs := attrs{}
+	if opts.BgColor != "" {
+		graphAttrs["bgcolor"] = opts.BgColor
+	}
+	if opts.GraphTitle != "" {
+		graphAttrs["label"] = opts.GraphTitle
+		graphAttrs["labelloc"] = "t"
+		graphAttrs["fontsize"] = "20"
+	}
+	if opts.Watermark != "" {
+		graphAttrs["label"] = opts.Watermark
+		graphAttrs["labelloc"] = "b"
+		graphAttrs["labeljust"] = "r"
+		graphAttrs["fontcolor"] = "#00000055"
+		graphAttrs["fontsize"] = "28"
+	}
+	if len(graphAttrs) == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("graph %s;", graphAttrs.dot())}
+}
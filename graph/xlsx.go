@@ -0,0 +1,191 @@
+package graph // import "moul.io/depviz/graph"
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"moul.io/depviz/compute"
+)
+
+// toXLSX generates an Office Open XML workbook with an "Issues", a
+// "Dependencies" and a "Milestones" sheet, based on the computed (and already
+// filtered) issues. The columns of the "Issues" sheet are the configured fields.
+//
+// The workbook is written by hand with the minimal set of parts required by
+// spreadsheet readers, hyperlinks are implemented with HYPERLINK() formulas.
+func toXLSX(computed *compute.Computed, opts *Options) (string, error) {
+	fields, err := opts.fields()
+	if err != nil {
+		return "", err
+	}
+	issues := xlsxSheet{name: "Issues"}
+	headers := []xlsxCell{}
+	for _, name := range fields {
+		headers = append(headers, xlsxString(issueFields[name].header))
+	}
+	issues.addRow(headers...)
+	dependencies := xlsxSheet{name: "Dependencies"}
+	dependencies.addRow(xlsxString("Issue"), xlsxString("Depends On"))
+	for _, issue := range computed.Issues() {
+		cells := []xlsxCell{}
+		for _, name := range fields {
+			field := issueFields[name]
+			value := field.value(issue)
+			if field.link {
+				cells = append(cells, xlsxLink(value, value))
+			} else {
+				cells = append(cells, xlsxString(value))
+			}
+		}
+		issues.addRow(cells...)
+		for _, dependency := range issue.DependsOn {
+			dependencies.addRow(xlsxLink(issue.URL, issue.URL), xlsxLink(dependency, dependency))
+		}
+	}
+
+	milestones := xlsxSheet{name: "Milestones"}
+	milestones.addRow(
+		xlsxString("URL"), xlsxString("Title"), xlsxString("Due On"),
+		xlsxString("Closed At"), xlsxString("Issues"),
+	)
+	for _, milestone := range computed.Milestones() {
+		milestones.addRow(
+			xlsxLink(milestone.URL, milestone.URL),
+			xlsxString(milestone.Title),
+			xlsxDate(milestone.DueOn),
+			xlsxDate(milestone.ClosedAt),
+			xlsxString(fmt.Sprintf("%d", len(milestone.DependsOn))),
+		)
+	}
+
+	return writeXLSX([]xlsxSheet{issues, dependencies, milestones})
+}
+
+//
+// minimal xlsx writer
+//
+
+type xlsxCell struct {
+	value string
+	link  string
+}
+
+func xlsxString(value string) xlsxCell { return xlsxCell{value: value} }
+
+func xlsxLink(url, value string) xlsxCell { return xlsxCell{value: value, link: url} }
+
+func xlsxDate(t time.Time) xlsxCell {
+	if t.IsZero() {
+		return xlsxCell{}
+	}
+	return xlsxCell{value: t.UTC().Format(time.RFC3339)}
+}
+
+type xlsxSheet struct {
+	name string
+	rows [][]xlsxCell
+}
+
+func (s *xlsxSheet) addRow(cells ...xlsxCell) {
+	s.rows = append(s.rows, cells)
+}
+
+func (s xlsxSheet) xml() []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for y, row := range s.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, y+1)
+		for x, cell := range row {
+			ref := fmt.Sprintf("%s%d", xlsxColumnName(x), y+1)
+			switch {
+			case cell.link != "":
+				formula := fmt.Sprintf("HYPERLINK(%s,%s)", xlsxFormulaString(cell.link), xlsxFormulaString(cell.value))
+				fmt.Fprintf(&b, `<c r="%s" t="str"><f>%s</f><v>%s</v></c>`, ref, xlsxEscape(formula), xlsxEscape(cell.value))
+			case cell.value != "":
+				fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xlsxEscape(cell.value))
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.Bytes()
+}
+
+type xlsxEntry struct {
+	path    string
+	content []byte
+}
+
+func writeXLSX(sheets []xlsxSheet) (string, error) {
+	var (
+		contentTypes bytes.Buffer
+		workbook     bytes.Buffer
+		workbookRels bytes.Buffer
+		sheetEntries []xlsxEntry
+	)
+	contentTypes.WriteString(xml.Header)
+	contentTypes.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	contentTypes.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	contentTypes.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	contentTypes.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	workbook.WriteString(xml.Header)
+	workbook.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(xml.Header)
+	workbookRels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for idx, sheet := range sheets {
+		path := fmt.Sprintf("xl/worksheets/sheet%d.xml", idx+1)
+		sheetEntries = append(sheetEntries, xlsxEntry{path, sheet.xml()})
+		fmt.Fprintf(&contentTypes, `<Override PartName="/%s" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, path)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xlsxEscape(sheet.name), idx+1, idx+1)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, idx+1, idx+1)
+	}
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRels.WriteString(`</Relationships>`)
+
+	var out bytes.Buffer
+	w := zip.NewWriter(&out)
+	entries := []xlsxEntry{
+		{"[Content_Types].xml", contentTypes.Bytes()},
+		{"_rels/.rels", []byte(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`)},
+		{"xl/workbook.xml", workbook.Bytes()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.Bytes()},
+	}
+	for _, entry := range append(entries, sheetEntries...) {
+		f, err := w.Create(entry.path)
+		if err != nil {
+			return "", err
+		}
+		if _, err := f.Write(entry.content); err != nil {
+			return "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func xlsxColumnName(idx int) string {
+	name := ""
+	for idx >= 0 {
+		name = string(rune('A'+idx%26)) + name
+		idx = idx/26 - 1
+	}
+	return name
+}
+
+func xlsxEscape(input string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(input))
+	return b.String()
+}
+
+func xlsxFormulaString(input string) string {
+	return `"` + strings.Replace(input, `"`, `""`, -1) + `"`
+}
@@ -0,0 +1,130 @@
+package jira // import "moul.io/depviz/jira"
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// the subset of the Jira REST API v2 used by depviz, see
+// https://developer.atlassian.com/cloud/jira/platform/rest/v2/
+
+type apiUser struct {
+	AccountID    string            `json:"accountId"` // Jira Cloud
+	Name         string            `json:"name"`      // Jira Server
+	DisplayName  string            `json:"displayName"`
+	EmailAddress string            `json:"emailAddress"`
+	AvatarURLs   map[string]string `json:"avatarUrls"`
+}
+
+type apiLinkedIssue struct {
+	Key string `json:"key"`
+}
+
+type apiIssueLink struct {
+	Type struct {
+		Name    string `json:"name"`
+		Inward  string `json:"inward"`  // i.e., "is blocked by"
+		Outward string `json:"outward"` // i.e., "blocks"
+	} `json:"type"`
+	InwardIssue  *apiLinkedIssue `json:"inwardIssue"`
+	OutwardIssue *apiLinkedIssue `json:"outwardIssue"`
+}
+
+type apiFields struct {
+	Summary     string `json:"summary"`
+	Description string `json:"description"`
+	Status      struct {
+		Name           string `json:"name"`
+		StatusCategory struct {
+			Key string `json:"key"` // new, indeterminate or done
+		} `json:"statusCategory"`
+	} `json:"status"`
+	IssueType struct {
+		Name string `json:"name"`
+	} `json:"issuetype"`
+	Assignee       *apiUser       `json:"assignee"`
+	Reporter       *apiUser       `json:"reporter"`
+	Labels         []string       `json:"labels"`
+	IssueLinks     []apiIssueLink `json:"issuelinks"`
+	Created        apiTime        `json:"created"`
+	Updated        apiTime        `json:"updated"`
+	ResolutionDate *apiTime       `json:"resolutiondate"`
+}
+
+type apiIssue struct {
+	Key    string          `json:"key"` // i.e., "PROJ-123"
+	Fields json.RawMessage `json:"fields"`
+}
+
+type apiSearchResult struct {
+	StartAt    int         `json:"startAt"`
+	MaxResults int         `json:"maxResults"`
+	Total      int         `json:"total"`
+	Issues     []*apiIssue `json:"issues"`
+}
+
+// apiTime is a Jira date, i.e., "2019-03-01T10:20:30.000+0000".
+type apiTime struct{ time.Time }
+
+func (t *apiTime) UnmarshalJSON(data []byte) error {
+	var input string
+	if err := json.Unmarshal(data, &input); err != nil || input == "" {
+		return err
+	}
+	parsed, err := time.Parse("2006-01-02T15:04:05.000-0700", input)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+type client struct {
+	httpClient *http.Client
+	baseURL    string // i.e., "https://example.atlassian.net"
+	user       string
+	token      string
+}
+
+func newClient(httpClient *http.Client, baseURL, user, token string) *client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &client{httpClient: httpClient, baseURL: baseURL, user: user, token: token}
+}
+
+// get decodes the response of GET /rest/api/2{path} in out. The Jira Cloud API
+// tokens are sent with the user (basic auth), the Jira Server personal access
+// tokens alone.
+func (c *client) get(path string, out interface{}) error {
+	req, err := http.NewRequest("GET", c.baseURL+"/rest/api/2"+path, nil)
+	if err != nil {
+		return err
+	}
+	switch {
+	case c.user != "":
+		req.SetBasicAuth(c.user, c.token)
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			ErrorMessages []string `json:"errorMessages"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		if len(apiErr.ErrorMessages) > 0 {
+			return fmt.Errorf("%s: %s", resp.Status, strings.Join(apiErr.ErrorMessages, ", "))
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,128 @@
+package jira // import "moul.io/depviz/jira"
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"go.uber.org/zap"
+	"moul.io/depviz/model"
+	"moul.io/depviz/sql"
+	"moul.io/multipmuri"
+)
+
+const pageSize = 100
+
+// Config are the Jira options of 'pull'.
+type Config struct {
+	User             string // the Jira Cloud API tokens are sent with the user
+	Token            string
+	StoryPointsField string // i.e., "customfield_10016", depends on the instance
+}
+
+// Pull fetches the issues of a Jira project with JQL, with the "is blocked by"
+// links as dependencies.
+//
+// Jira targets ("jira:PROJ") are parsed as GitHub entities on the host
+// registered with model.RegisterJiraBaseURL.
+func Pull(input multipmuri.Entity, wg *sync.WaitGroup, config Config, fetchOpts model.FetchOptions, db *gorm.DB, out chan<- []*model.Issue) {
+	defer wg.Done()
+	type multipmuriMinimalInterface interface {
+		Repo() *multipmuri.GitHubRepo
+	}
+	target, ok := input.(multipmuriMinimalInterface)
+	if !ok {
+		zap.L().Warn("invalid input", zap.String("input", fmt.Sprintf("%v", input.String())))
+		return
+	}
+	repo := target.Repo()
+	u, err := url.Parse(repo.String())
+	if err != nil {
+		zap.L().Warn("invalid input", zap.String("input", repo.String()), zap.Error(err))
+		return
+	}
+	serviceURL := fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+	project := repo.RepoID()
+	client := newClient(fetchOpts.HTTPClient, serviceURL, config.User, config.Token)
+
+	jql := fmt.Sprintf("project = %q", project)
+	startedAt := time.Now()
+	since := sql.PullSince(db, projectURL(serviceURL, project), fetchOpts.Full)
+	if !since.IsZero() {
+		// the JQL dates are in the timezone of the user, one day of margin
+		jql += fmt.Sprintf(" AND updated >= %q", since.Add(-24*time.Hour).Format("2006/01/02 15:04"))
+	}
+	jql += " ORDER BY key ASC"
+	fields := []string{"summary", "description", "status", "issuetype", "assignee", "reporter", "labels", "issuelinks", "created", "updated", "resolutiondate"}
+	if config.StoryPointsField != "" {
+		fields = append(fields, config.StoryPointsField)
+	}
+
+	total := 0
+	for startAt := 0; ; {
+		var result apiSearchResult
+		query := url.Values{}
+		query.Set("jql", jql)
+		query.Set("startAt", fmt.Sprintf("%d", startAt))
+		query.Set("maxResults", fmt.Sprintf("%d", pageSize))
+		query.Set("fields", strings.Join(fields, ","))
+		err := model.Retry(fetchOpts.Retries, func() error {
+			err := client.get("/search?"+query.Encode(), &result)
+			if err != nil {
+				zap.L().Debug("failed to pull issues, retrying", zap.String("project", repo.String()), zap.Int("start-at", startAt), zap.Error(err))
+			}
+			return err
+		})
+		if err != nil {
+			zap.L().Error("failed to pull issues", zap.String("project", repo.String()), zap.Error(err))
+			fetchOpts.Failures.Add(model.FetchFailure{Provider: "jira", Repo: repo.String(), Page: startAt/pageSize + 1, Err: err})
+			return
+		}
+		total += len(result.Issues)
+		zap.L().Debug("paginate",
+			zap.String("provider", "jira"),
+			zap.String("project", repo.String()),
+			zap.Int("new-issues", len(result.Issues)),
+			zap.Int("total-issues", total),
+		)
+		normalizedIssues := []*model.Issue{}
+		for _, issue := range result.Issues {
+			normalizedIssue, err := fromAPIIssue(issue, config.StoryPointsField, serviceURL)
+			if err != nil {
+				zap.L().Warn("invalid Jira issue", zap.String("issue", issue.Key), zap.Error(err))
+				continue
+			}
+			normalizedIssues = append(normalizedIssues, normalizedIssue)
+		}
+		out <- normalizedIssues
+		startAt += len(result.Issues)
+		if len(result.Issues) == 0 || startAt >= result.Total {
+			break
+		}
+	}
+	fetchOpts.Synced.Add(model.SyncedRepo{ID: projectURL(serviceURL, project), StartedAt: startedAt, Full: since.IsZero()})
+}
+
+// fromAPIIssue decodes the fields of an issue, the story points field is only
+// known at runtime.
+func fromAPIIssue(input *apiIssue, storyPointsField, serviceURL string) (*model.Issue, error) {
+	var fields apiFields
+	if err := json.Unmarshal(input.Fields, &fields); err != nil {
+		return nil, err
+	}
+	storyPoints := 0.0
+	if storyPointsField != "" {
+		var custom map[string]json.RawMessage
+		if err := json.Unmarshal(input.Fields, &custom); err != nil {
+			return nil, err
+		}
+		if raw, found := custom[storyPointsField]; found {
+			_ = json.Unmarshal(raw, &storyPoints) // null or not a number: no estimate
+		}
+	}
+	return FromIssue(input, &fields, storyPoints, serviceURL)
+}
@@ -0,0 +1,137 @@
+package jira // import "moul.io/depviz/jira"
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"moul.io/depviz/model"
+)
+
+// issueID returns the ID of an issue key (i.e., "PROJ-123"), with the GitHub
+// layout used to store the Jira issues, see model.RegisterJiraBaseURL. It is
+// displayed as "<base>/browse/PROJ-123", see model.WebURL.
+func issueID(serviceURL, key string) (string, error) {
+	idx := strings.LastIndex(key, "-")
+	if idx <= 0 {
+		return "", fmt.Errorf("invalid Jira issue key %q", key)
+	}
+	if _, err := strconv.Atoi(key[idx+1:]); err != nil {
+		return "", fmt.Errorf("invalid Jira issue key %q", key)
+	}
+	return fmt.Sprintf("%s/issues/%s", projectURL(serviceURL, key[:idx]), key[idx+1:]), nil
+}
+
+func projectURL(serviceURL, project string) string {
+	return fmt.Sprintf("%s/projects/%s", serviceURL, strings.ToUpper(project))
+}
+
+// FromIssue converts a Jira issue, the 'done' status category is closed, the
+// other ones are open. The story points are the estimate of the issue (one
+// point is one day) and the "is blocked by" links are its dependencies.
+func FromIssue(input *apiIssue, fields *apiFields, storyPoints float64, serviceURL string) (*model.Issue, error) {
+	url, err := issueID(serviceURL, input.Key)
+	if err != nil {
+		return nil, err
+	}
+	service := FromServiceURL(serviceURL)
+	repo := FromRepositoryURL(service, url[:strings.LastIndex(url, "/issues/")])
+	issue := &model.Issue{
+		Base: model.Base{
+			ID:        url,
+			URL:       url,
+			CreatedAt: fields.Created.Time,
+			UpdatedAt: fields.Updated.Time,
+		},
+		Title:        fields.Summary,
+		State:        "open",
+		Body:         fields.Description,
+		Repository:   repo,
+		Service:      service,
+		Labels:       make([]*model.Label, 0),
+		Assignees:    make([]*model.Account, 0),
+		Author:       FromUser(service, fields.Reporter),
+		Dependencies: []string{},
+	}
+	if fields.Status.StatusCategory.Key == "done" {
+		issue.State = "closed"
+	}
+	if fields.ResolutionDate != nil {
+		issue.CompletedAt = fields.ResolutionDate.Time
+	}
+	if fields.Assignee != nil {
+		issue.Assignees = append(issue.Assignees, FromUser(service, fields.Assignee))
+	}
+	for _, label := range fields.Labels {
+		issue.Labels = append(issue.Labels, FromLabel(repo, label))
+	}
+	if storyPoints > 0 {
+		issue.Estimate = strconv.FormatFloat(storyPoints, 'f', -1, 64) + "d"
+	}
+	for _, link := range fields.IssueLinks {
+		// "PROJ-1 is blocked by PROJ-2" is an inward link on PROJ-1, the
+		// outward ones ("PROJ-1 blocks PROJ-3") are stored on the other side
+		if !strings.EqualFold(link.Type.Name, "Blocks") || link.InwardIssue == nil {
+			continue
+		}
+		blocker, err := issueID(serviceURL, link.InwardIssue.Key)
+		if err != nil {
+			continue
+		}
+		issue.Dependencies = append(issue.Dependencies, blocker)
+	}
+	return issue, nil
+}
+
+func FromServiceURL(input string) *model.Provider {
+	return &model.Provider{
+		Base: model.Base{
+			ID:  input,
+			URL: input,
+		},
+		Driver: string(model.JiraDriver),
+	}
+}
+
+func FromRepositoryURL(service *model.Provider, input string) *model.Repository {
+	return &model.Repository{
+		Base: model.Base{
+			ID:  input,
+			URL: input,
+		},
+		Provider: service,
+	}
+}
+
+func FromUser(service *model.Provider, input *apiUser) *model.Account {
+	if input == nil {
+		return nil
+	}
+	id := input.AccountID
+	if id == "" {
+		id = input.Name
+	}
+	url := fmt.Sprintf("%s/users/%s", service.URL, id)
+	return &model.Account{
+		Base: model.Base{
+			ID:  url,
+			URL: url,
+		},
+		Login:     id,
+		FullName:  input.DisplayName,
+		Email:     input.EmailAddress,
+		AvatarURL: input.AvatarURLs["48x48"],
+		Provider:  service,
+	}
+}
+
+func FromLabel(repository *model.Repository, name string) *model.Label {
+	url := fmt.Sprintf("%s/labels/%s", repository.URL, name)
+	return &model.Label{
+		Base: model.Base{
+			ID:  url,
+			URL: url,
+		},
+		Name: name,
+	}
+}
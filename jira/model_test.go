@@ -0,0 +1,136 @@
+package jira
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testServiceURL = "https://example.atlassian.net"
+
+func testAPIIssue(key, fields string) *apiIssue {
+	return &apiIssue{Key: key, Fields: []byte(fields)}
+}
+
+func TestFromIssueStatusCategory(t *testing.T) {
+	tests := []struct {
+		category string
+		state    string
+	}{
+		{"new", "open"},
+		{"indeterminate", "open"},
+		{"done", "closed"},
+		{"", "open"},
+	}
+	for _, test := range tests {
+		t.Run(test.category, func(t *testing.T) {
+			input := testAPIIssue("PROJ-1", `{"summary": "Story", "status": {"name": "Whatever", "statusCategory": {"key": "`+test.category+`"}}}`)
+			issue, err := fromAPIIssue(input, "", testServiceURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if issue.State != test.state {
+				t.Errorf("expected %q, got %q", test.state, issue.State)
+			}
+		})
+	}
+}
+
+func TestFromIssue(t *testing.T) {
+	input := testAPIIssue("PROJ-42", `{
+		"summary": "Epic",
+		"status": {"name": "Done", "statusCategory": {"key": "done"}},
+		"labels": ["backend"],
+		"assignee": {"accountId": "abc", "displayName": "Alice"},
+		"resolutiondate": "2020-01-03T10:20:30.000+0000",
+		"customfield_10016": 2.5,
+		"issuelinks": [
+			{"type": {"name": "Blocks", "inward": "is blocked by", "outward": "blocks"}, "inwardIssue": {"key": "PROJ-7"}},
+			{"type": {"name": "Blocks", "inward": "is blocked by", "outward": "blocks"}, "outwardIssue": {"key": "PROJ-8"}},
+			{"type": {"name": "Relates", "inward": "relates to", "outward": "relates to"}, "inwardIssue": {"key": "PROJ-9"}},
+			{"type": {"name": "Blocks", "inward": "is blocked by", "outward": "blocks"}, "inwardIssue": {"key": "OTHER-3"}}
+		]
+	}`)
+	issue, err := fromAPIIssue(input, "customfield_10016", testServiceURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "https://example.atlassian.net/projects/PROJ/issues/42"; issue.ID != expected || issue.URL != expected {
+		t.Errorf("expected the ID and URL %q, got %q and %q", expected, issue.ID, issue.URL)
+	}
+	if expected := "https://example.atlassian.net/projects/PROJ"; issue.Repository.ID != expected {
+		t.Errorf("expected the project %q, got %q", expected, issue.Repository.ID)
+	}
+	if issue.State != "closed" || issue.CompletedAt.IsZero() {
+		t.Errorf("expected a completed closed issue, got %q completed at %s", issue.State, issue.CompletedAt)
+	}
+	if issue.Estimate != "2.5d" {
+		t.Errorf("expected the story points as the estimate, got %q", issue.Estimate)
+	}
+	if len(issue.Labels) != 1 || issue.Labels[0].Name != "backend" {
+		t.Errorf("expected the backend label only, got %v", issue.Labels)
+	}
+	if len(issue.Assignees) != 1 || issue.Assignees[0].Login != "abc" {
+		t.Errorf("expected the abc assignee, got %v", issue.Assignees)
+	}
+	expectedDependencies := []string{
+		"https://example.atlassian.net/projects/PROJ/issues/7",
+		"https://example.atlassian.net/projects/OTHER/issues/3",
+	}
+	if !reflect.DeepEqual(issue.Dependencies, expectedDependencies) {
+		t.Errorf("expected the dependencies %v, got %v", expectedDependencies, issue.Dependencies)
+	}
+}
+
+func TestFromIssueStoryPoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    string
+		value    string
+		expected string
+	}{
+		{"integer", "customfield_10016", "3", "3d"},
+		{"null", "customfield_10016", "null", ""},
+		{"not a number", "customfield_10016", `"L"`, ""},
+		{"zero", "customfield_10016", "0", ""},
+		{"field not configured", "", "3", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := testAPIIssue("PROJ-1", `{"summary": "Story", "customfield_10016": `+test.value+`}`)
+			issue, err := fromAPIIssue(input, test.field, testServiceURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if issue.Estimate != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, issue.Estimate)
+			}
+			for _, label := range issue.Labels {
+				t.Errorf("expected no label, got %q", label.Name)
+			}
+		})
+	}
+}
+
+func TestIssueID(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected string
+		valid    bool
+	}{
+		{"PROJ-123", "https://example.atlassian.net/projects/PROJ/issues/123", true},
+		{"proj-1", "https://example.atlassian.net/projects/PROJ/issues/1", true},
+		{"PROJ", "", false},
+		{"-1", "", false},
+		{"PROJ-x", "", false},
+	}
+	for _, test := range tests {
+		id, err := issueID(testServiceURL, test.key)
+		if test.valid != (err == nil) {
+			t.Errorf("issueID(%q): unexpected error: %v", test.key, err)
+			continue
+		}
+		if id != test.expected {
+			t.Errorf("issueID(%q): expected %q, got %q", test.key, test.expected, id)
+		}
+	}
+}
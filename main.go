@@ -15,6 +15,8 @@ import (
 	"moul.io/depviz/airtable"
 	"moul.io/depviz/cli"
 	"moul.io/depviz/graph"
+	"moul.io/depviz/metrics"
+	"moul.io/depviz/model"
 	"moul.io/depviz/pull"
 	"moul.io/depviz/run"
 	"moul.io/depviz/sql"
@@ -64,6 +66,9 @@ func newRootCommand() *cobra.Command {
 	for name, command := range airtable.Commands() {
 		commands[name] = command
 	}
+	for name, command := range metrics.Commands() {
+		commands[name] = command
+	}
 	for name, command := range run.Commands() {
 		commands[name] = command
 	}
@@ -108,6 +113,16 @@ func newRootCommand() *cobra.Command {
 			}
 		}
 
+		// register the hosts of the config file for all the commands reading
+		// the database, graph, pull and run also register their flags
+		if err := model.RegisterHosts(
+			viper.GetString("github-base-url"),
+			viper.GetStringSlice("gitea-hosts"),
+			viper.GetString("jira-base-url"),
+		); err != nil {
+			return err
+		}
+
 		return nil
 	}
 	for name, command := range commands {
@@ -0,0 +1,104 @@
+package metrics // import "moul.io/depviz/metrics"
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"moul.io/depviz/cli"
+	"moul.io/depviz/compute"
+	"moul.io/depviz/graph"
+	"moul.io/depviz/model"
+	"moul.io/depviz/sql"
+	"moul.io/multipmuri"
+)
+
+func Commands() cli.Commands {
+	return cli.Commands{
+		"metrics":          &metricsCommand{},
+		"metrics append":   &appendCommand{},
+		"metrics workload": &workloadCommand{},
+		"metrics delivery": &deliveryCommand{},
+		"stale":            &staleCommand{},
+	}
+}
+
+type metricsCommand struct{}
+
+func (cmd *metricsCommand) LoadDefaultOptions() error { return nil }
+
+func (cmd *metricsCommand) ParseFlags(flags *pflag.FlagSet) {}
+
+func (cmd *metricsCommand) CobraCommand(commands cli.Commands) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "metrics",
+		Short: "Backlog health metrics",
+	}
+	command.AddCommand(commands["metrics append"].CobraCommand(commands))
+	command.AddCommand(commands["metrics workload"].CobraCommand(commands))
+	command.AddCommand(commands["metrics delivery"].CobraCommand(commands))
+	return command
+}
+
+type AppendOptions struct {
+	SQL     sql.Options         `mapstructure:"sql"`     // inherited with sql.GetOptions()
+	Targets []multipmuri.Entity `mapstructure:"targets"` // parsed from Args
+	Path    string              `mapstructure:"path"`    // parsed from Args
+
+	EstimateLabelPrefix string `mapstructure:"estimate-label-prefix"`
+
+	Parse compute.ParseOptions `mapstructure:",squash"`
+}
+
+func (opts AppendOptions) Validate() error {
+	return opts.SQL.Validate()
+}
+
+func (opts AppendOptions) String() string {
+	out, _ := json.Marshal(opts)
+	return string(out)
+}
+
+type appendCommand struct{ opts AppendOptions }
+
+func (cmd *appendCommand) CobraCommand(commands cli.Commands) *cobra.Command {
+	cc := &cobra.Command{
+		Use:   "append <file.csv> <targets...>",
+		Short: "Append a timestamped row of the current metrics to a CSV file",
+		Long: `Compute the current metrics of the targets and append them to a CSV file,
+running it on a schedule builds a dataset for trend charts.
+
+Columns: timestamp, open, closed, blockers, critical_path, unestimated.
+The header is written when the file is created.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			opts := cmd.opts
+			opts.SQL = sql.GetOptions(commands)
+			opts.Path = args[0]
+			targets, err := model.ParseTargets(args[1:])
+			if err != nil {
+				return err
+			}
+			opts.Targets = targets
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return Append(&opts)
+		},
+	}
+	cmd.ParseFlags(cc.Flags())
+	commands["sql"].ParseFlags(cc.Flags())
+	return cc
+}
+
+func (cmd *appendCommand) LoadDefaultOptions() error { return viper.Unmarshal(&cmd.opts) }
+
+func (cmd *appendCommand) ParseFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&cmd.opts.EstimateLabelPrefix, "estimate-label-prefix", "", graph.DefaultEstimateLabelPrefix, "prefix of the labels giving the estimate of an issue, i.e., estimate:3d")
+	cmd.opts.Parse.ParseFlags(flags)
+	if err := viper.BindPFlags(flags); err != nil {
+		zap.L().Warn("failed to bind viper flags", zap.Error(err))
+	}
+}
@@ -0,0 +1,90 @@
+package metrics // import "moul.io/depviz/metrics"
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"moul.io/depviz/cli"
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+	"moul.io/depviz/sql"
+	"moul.io/multipmuri"
+)
+
+type DeliveryOptions struct {
+	SQL     sql.Options         `mapstructure:"sql"`     // inherited with sql.GetOptions()
+	Targets []multipmuri.Entity `mapstructure:"targets"` // parsed from Args
+
+	Since  string `mapstructure:"delivery-since"`
+	Format string `mapstructure:"delivery-format"`
+
+	Parse compute.ParseOptions `mapstructure:",squash"`
+}
+
+func (opts DeliveryOptions) Validate() error {
+	if err := opts.SQL.Validate(); err != nil {
+		return err
+	}
+	switch opts.Format {
+	case "text", "json":
+	default:
+		return fmt.Errorf("invalid format: %q", opts.Format)
+	}
+	if opts.Since != "" {
+		if _, err := parseSince(opts.Since); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (opts DeliveryOptions) String() string {
+	out, _ := json.Marshal(opts)
+	return string(out)
+}
+
+type deliveryCommand struct{ opts DeliveryOptions }
+
+func (cmd *deliveryCommand) CobraCommand(commands cli.Commands) *cobra.Command {
+	cc := &cobra.Command{
+		Use:   "delivery <targets...>",
+		Short: "Compute the median lead time and cycle time of the closed issues, per repo and overall",
+		Long: `Compute the median lead time (created to closed) and cycle time (first
+assigned to closed) of the closed issues, per repo and overall.
+
+The first assignment is only recorded by the webhook of 'web', the issues
+without it are counted but have no cycle time.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			opts := cmd.opts
+			opts.SQL = sql.GetOptions(commands)
+			targets, err := model.ParseTargets(args)
+			if err != nil {
+				return err
+			}
+			opts.Targets = targets
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return PrintDelivery(&opts)
+		},
+	}
+	cmd.ParseFlags(cc.Flags())
+	commands["sql"].ParseFlags(cc.Flags())
+	return cc
+}
+
+func (cmd *deliveryCommand) LoadDefaultOptions() error { return viper.Unmarshal(&cmd.opts) }
+
+func (cmd *deliveryCommand) ParseFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&cmd.opts.Since, "delivery-since", "", "", "only count the issues closed since this date (RFC3339 or YYYY-MM-DD)")
+	flags.StringVarP(&cmd.opts.Format, "delivery-format", "", "text", "output format (text, json)")
+	cmd.opts.Parse.ParseFlags(flags)
+	if err := viper.BindPFlags(flags); err != nil {
+		zap.L().Warn("failed to bind viper flags", zap.Error(err))
+	}
+}
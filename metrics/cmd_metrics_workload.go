@@ -0,0 +1,106 @@
+package metrics // import "moul.io/depviz/metrics"
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"moul.io/depviz/cli"
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+	"moul.io/depviz/sql"
+	"moul.io/multipmuri"
+)
+
+type WorkloadOptions struct {
+	SQL     sql.Options         `mapstructure:"sql"`     // inherited with sql.GetOptions()
+	Targets []multipmuri.Entity `mapstructure:"targets"` // parsed from Args
+
+	WIPLimit         int      `mapstructure:"wip-limit"`
+	WIPLimits        []string `mapstructure:"wip-limits"` // "login=N"
+	DedupeAccountsBy string   `mapstructure:"dedupe-accounts-by"`
+	Format           string   `mapstructure:"workload-format"`
+
+	Parse compute.ParseOptions `mapstructure:",squash"`
+}
+
+func (opts WorkloadOptions) Validate() error {
+	if err := opts.SQL.Validate(); err != nil {
+		return err
+	}
+	switch opts.Format {
+	case "text", "json":
+	default:
+		return fmt.Errorf("invalid format: %q", opts.Format)
+	}
+	if _, err := opts.limits(); err != nil {
+		return err
+	}
+	return model.ValidateDedupeAccountsBy(opts.DedupeAccountsBy)
+}
+
+// limits parses the per-person WIP limits, keyed by lowercase login.
+func (opts WorkloadOptions) limits() (map[string]int, error) {
+	limits := map[string]int{}
+	for _, entry := range opts.WIPLimits {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid WIP limit %q, expected 'login=N'", entry)
+		}
+		limit, err := strconv.Atoi(parts[1])
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid WIP limit %q, expected 'login=N'", entry)
+		}
+		limits[strings.ToLower(parts[0])] = limit
+	}
+	return limits, nil
+}
+
+func (opts WorkloadOptions) String() string {
+	out, _ := json.Marshal(opts)
+	return string(out)
+}
+
+type workloadCommand struct{ opts WorkloadOptions }
+
+func (cmd *workloadCommand) CobraCommand(commands cli.Commands) *cobra.Command {
+	cc := &cobra.Command{
+		Use:   "workload <targets...>",
+		Short: "Count the open issues assigned to each person, and flag the ones over their WIP limit",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			opts := cmd.opts
+			opts.SQL = sql.GetOptions(commands)
+			targets, err := model.ParseTargets(args)
+			if err != nil {
+				return err
+			}
+			opts.Targets = targets
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return PrintWorkload(&opts)
+		},
+	}
+	cmd.ParseFlags(cc.Flags())
+	commands["sql"].ParseFlags(cc.Flags())
+	return cc
+}
+
+func (cmd *workloadCommand) LoadDefaultOptions() error { return viper.Unmarshal(&cmd.opts) }
+
+func (cmd *workloadCommand) ParseFlags(flags *pflag.FlagSet) {
+	flags.IntVarP(&cmd.opts.WIPLimit, "wip-limit", "", 0, "maximum number of open issues per person (0 means no limit)")
+	flags.StringSliceVarP(&cmd.opts.WIPLimits, "wip-limits", "", []string{}, "per-person WIP limits, overriding --wip-limit (i.e., 'moul=5,alice=3')")
+	flags.StringVarP(&cmd.opts.DedupeAccountsBy, "dedupe-accounts-by", "", "", "merge the assignees sharing the same login or email (login, email)")
+	flags.StringVarP(&cmd.opts.Format, "workload-format", "", "text", "output format (text, json)")
+	cmd.opts.Parse.ParseFlags(flags)
+	if err := viper.BindPFlags(flags); err != nil {
+		zap.L().Warn("failed to bind viper flags", zap.Error(err))
+	}
+}
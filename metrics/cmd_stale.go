@@ -0,0 +1,86 @@
+package metrics // import "moul.io/depviz/metrics"
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"moul.io/depviz/cli"
+	"moul.io/depviz/model"
+	"moul.io/depviz/sql"
+)
+
+type StaleOptions struct {
+	SQL sql.Options `mapstructure:"sql"` // inherited with sql.GetOptions()
+
+	Threshold string   `mapstructure:"threshold"`
+	Repos     []string `mapstructure:"repo"`
+	Labels    []string `mapstructure:"label"`
+	Assignees []string `mapstructure:"assignee"`
+	Format    string   `mapstructure:"stale-format"`
+}
+
+func (opts StaleOptions) Validate() error {
+	if err := opts.SQL.Validate(); err != nil {
+		return err
+	}
+	switch opts.Format {
+	case "text", "json":
+	default:
+		return fmt.Errorf("invalid --stale-format value: %q (supported: text, json)", opts.Format)
+	}
+	if _, err := parseThreshold(opts.Threshold); err != nil {
+		return err
+	}
+	if _, err := model.ParseTargets(opts.Repos); err != nil {
+		return fmt.Errorf("invalid --repo value: %v", err)
+	}
+	return nil
+}
+
+func (opts StaleOptions) String() string {
+	out, _ := json.Marshal(opts)
+	return string(out)
+}
+
+type staleCommand struct{ opts StaleOptions }
+
+func (cmd *staleCommand) CobraCommand(commands cli.Commands) *cobra.Command {
+	cc := &cobra.Command{
+		Use:   "stale",
+		Short: "List the open issues without activity for a while, the stalest first",
+		Long: `List the open issues of the database not updated since --threshold, the
+stalest first.
+
+The command exits with an error when a stale issue is found, so it can flag
+them in a CI job.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, args []string) error {
+			opts := cmd.opts
+			opts.SQL = sql.GetOptions(commands)
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return PrintStale(&opts)
+		},
+	}
+	cmd.ParseFlags(cc.Flags())
+	commands["sql"].ParseFlags(cc.Flags())
+	return cc
+}
+
+func (cmd *staleCommand) LoadDefaultOptions() error { return viper.Unmarshal(&cmd.opts) }
+
+func (cmd *staleCommand) ParseFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&cmd.opts.Threshold, "threshold", "", "30d", "inactivity after which an open issue is stale (units: d, w, mo, y)")
+	flags.StringSliceVarP(&cmd.opts.Repos, "repo", "", []string{}, "only list the issues of these repositories or owners (i.e., moul/depviz)")
+	flags.StringSliceVarP(&cmd.opts.Labels, "label", "", []string{}, "only list the issues with one of these labels")
+	flags.StringSliceVarP(&cmd.opts.Assignees, "assignee", "", []string{}, "only list the issues assigned to one of these logins")
+	flags.StringVarP(&cmd.opts.Format, "stale-format", "", "text", "output format (text, json)")
+	if err := viper.BindPFlags(flags); err != nil {
+		zap.L().Warn("failed to bind viper flags", zap.Error(err))
+	}
+}
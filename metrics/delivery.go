@@ -0,0 +1,147 @@
+package metrics // import "moul.io/depviz/metrics"
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"go.uber.org/zap"
+	"moul.io/depviz/compute"
+)
+
+// Delivery are the lead time and cycle time of the closed issues of a repo, or
+// of all of them with the "overall" repo. The medians are in hours.
+type Delivery struct {
+	Repo       string   `json:"repo"`
+	Closed     int      `json:"closed"`
+	LeadTime   *float64 `json:"lead-time-hours,omitempty"`  // created to closed
+	CycleTime  *float64 `json:"cycle-time-hours,omitempty"` // first assigned to closed
+	Unassigned int      `json:"unassigned"`                 // closed without known first assignment
+}
+
+const overallRepo = "overall"
+
+func parseSince(input string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, input); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, expected RFC3339 or YYYY-MM-DD", input)
+}
+
+func PrintDelivery(opts *DeliveryOptions) error {
+	zap.L().Debug("PrintDelivery", zap.Stringer("opts", *opts))
+
+	computed, err := loadComputed(opts.SQL, opts.Targets, opts.Parse)
+	if err != nil {
+		return err
+	}
+	var since time.Time
+	if opts.Since != "" {
+		if since, err = parseSince(opts.Since); err != nil {
+			return err
+		}
+	}
+	deliveries := computeDeliveries(computed, since)
+
+	if opts.Format == "json" {
+		out, err := json.MarshalIndent(deliveries, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "REPO\tCLOSED\tLEAD TIME\tCYCLE TIME\tUNASSIGNED\t")
+	for _, delivery := range deliveries {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%d\t\n", delivery.Repo, delivery.Closed, formatHours(delivery.LeadTime), formatHours(delivery.CycleTime), delivery.Unassigned)
+	}
+	return w.Flush()
+}
+
+// computeDeliveries computes the medians of the visible issues closed since the
+// given date (zero for all), per repo sorted by name, then overall. The pull
+// requests are skipped, and so are the issues without creation or closing
+// date.
+func computeDeliveries(computed *compute.Computed, since time.Time) []Delivery {
+	type samples struct {
+		closed, unassigned int
+		lead, cycle        []time.Duration
+	}
+	byRepo := map[string]*samples{}
+	overall := &samples{}
+	for _, issue := range computed.Issues() {
+		if issue.IsPR || issue.State != "closed" || issue.CompletedAt.IsZero() || issue.CreatedAt.IsZero() {
+			continue
+		}
+		if issue.CompletedAt.Before(since) {
+			continue
+		}
+		repo, found := byRepo[issue.RepositoryID]
+		if !found {
+			repo = &samples{}
+			byRepo[issue.RepositoryID] = repo
+		}
+		for _, s := range []*samples{repo, overall} {
+			s.closed++
+			s.lead = append(s.lead, issue.CompletedAt.Sub(issue.CreatedAt))
+			// assigned after the closing, i.e., for the record: no cycle
+			if issue.AssignedAt.IsZero() || issue.AssignedAt.After(issue.CompletedAt) {
+				s.unassigned++
+				continue
+			}
+			s.cycle = append(s.cycle, issue.CompletedAt.Sub(issue.AssignedAt))
+		}
+	}
+
+	toDelivery := func(name string, s *samples) Delivery {
+		return Delivery{
+			Repo:       name,
+			Closed:     s.closed,
+			LeadTime:   medianHours(s.lead),
+			CycleTime:  medianHours(s.cycle),
+			Unassigned: s.unassigned,
+		}
+	}
+	names := []string{}
+	for name := range byRepo {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	deliveries := []Delivery{}
+	for _, name := range names {
+		deliveries = append(deliveries, toDelivery(name, byRepo[name]))
+	}
+	return append(deliveries, toDelivery(overallRepo, overall))
+}
+
+// medianHours returns the median of the durations in hours, nil if empty.
+func medianHours(durations []time.Duration) *float64 {
+	if len(durations) == 0 {
+		return nil
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	median := durations[len(durations)/2]
+	if len(durations)%2 == 0 {
+		median = (durations[len(durations)/2-1] + median) / 2
+	}
+	hours := median.Hours()
+	return &hours
+}
+
+// formatHours formats a median in days above two days, "-" if unknown.
+func formatHours(hours *float64) string {
+	switch {
+	case hours == nil:
+		return "-"
+	case *hours >= 48:
+		return fmt.Sprintf("%.1fd", *hours/24)
+	}
+	return fmt.Sprintf("%.1fh", *hours)
+}
@@ -0,0 +1,85 @@
+package metrics // import "moul.io/depviz/metrics"
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"moul.io/depviz/compute"
+	"moul.io/depviz/graph"
+	"moul.io/depviz/sql"
+	"moul.io/multipmuri"
+)
+
+var csvHeader = []string{"timestamp", "open", "closed", "blockers", "critical_path", "unestimated"}
+
+func Append(opts *AppendOptions) error {
+	zap.L().Debug("Append", zap.Stringer("opts", *opts))
+
+	computed, err := loadComputed(opts.SQL, opts.Targets, opts.Parse)
+	if err != nil {
+		return err
+	}
+	metrics := computed.Metrics(func(issue *compute.ComputedIssue) bool {
+		return graph.HasEstimate(issue, opts.EstimateLabelPrefix)
+	})
+
+	f, err := os.OpenFile(opts.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	if stat.Size() == 0 {
+		if err := w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	row := []string{
+		time.Now().UTC().Format(time.RFC3339),
+		strconv.Itoa(metrics.Open),
+		strconv.Itoa(metrics.Closed),
+		strconv.Itoa(metrics.Blockers),
+		strconv.Itoa(metrics.CriticalPath),
+		strconv.Itoa(metrics.Unestimated),
+	}
+	if err := w.Write(row); err != nil {
+		return err
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	zap.L().Info("metrics appended", zap.String("path", opts.Path), zap.Strings("row", row))
+	return nil
+}
+
+// loadComputed loads the issues matching the targets, with the links resolved
+// at fetch time.
+func loadComputed(sqlOpts sql.Options, targets []multipmuri.Entity, parseOpts compute.ParseOptions) (*compute.Computed, error) {
+	db, err := sql.FromOpts(&sqlOpts)
+	if err != nil {
+		return nil, err
+	}
+	issues, err := sql.LoadAllIssues(compute.FilterDBByTargets(db, targets))
+	if err != nil {
+		return nil, err
+	}
+	links, err := sql.LoadAllLinks(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) == 0 { // database populated before links were persisted
+		links = nil
+	}
+	computed := compute.ComputeWithLinks(issues, links, parseOpts)
+	computed.FilterByTargets(targets)
+	return &computed, nil
+}
@@ -0,0 +1,165 @@
+package metrics // import "moul.io/depviz/metrics"
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go.uber.org/zap"
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+	"moul.io/depviz/sql"
+)
+
+// StaleIssue is an open issue not updated since the threshold.
+type StaleIssue struct {
+	URL       string    `json:"url"`
+	Title     string    `json:"title"`
+	Repo      string    `json:"repo"`
+	UpdatedAt time.Time `json:"updated-at"`
+	Days      int       `json:"days"` // since the last update
+	Assignees []string  `json:"assignees"`
+	Labels    []string  `json:"labels"`
+}
+
+// parseThreshold parses a number of days, weeks, months or years, i.e., "30d".
+func parseThreshold(input string) (time.Duration, error) {
+	units := []struct {
+		suffix string
+		days   int
+	}{{"mo", 30}, {"d", 1}, {"w", 7}, {"y", 365}}
+	for _, unit := range units {
+		if !strings.HasSuffix(input, unit.suffix) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(input, unit.suffix))
+		if err != nil || n <= 0 {
+			break
+		}
+		return time.Duration(n*unit.days) * 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("invalid --threshold value %q, expected a number followed by d, w, mo or y", input)
+}
+
+func PrintStale(opts *StaleOptions) error {
+	zap.L().Debug("PrintStale", zap.Stringer("opts", *opts))
+
+	threshold, err := parseThreshold(opts.Threshold)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	stale, err := loadStale(opts, now.Add(-threshold))
+	if err != nil {
+		return err
+	}
+
+	if opts.Format == "json" {
+		out, err := json.MarshalIndent(toStaleIssues(stale, now), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "DAYS\tISSUE\tTITLE\tASSIGNEES\t")
+		for _, issue := range toStaleIssues(stale, now) {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t\n", issue.Days, issue.URL, issue.Title, strings.Join(issue.Assignees, ", "))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(stale) > 0 {
+		return fmt.Errorf("%d stale issue(s), not updated for %s", len(stale), opts.Threshold)
+	}
+	return nil
+}
+
+// loadStale returns the open issues updated before the cutoff, matching the
+// filters, the stalest first. The state, date and repositories are filtered by
+// the query.
+func loadStale(opts *StaleOptions, cutoff time.Time) (model.Issues, error) {
+	db, err := sql.FromOpts(&opts.SQL)
+	if err != nil {
+		return nil, err
+	}
+	repos, err := model.ParseTargets(opts.Repos)
+	if err != nil {
+		return nil, err
+	}
+	query := compute.FilterDBByTargets(db, repos).
+		Where("state = ?", "open").
+		Where("updated_at < ?", cutoff)
+	issues, err := sql.LoadAllIssues(query)
+	if err != nil {
+		return nil, err
+	}
+
+	stale := model.Issues{}
+	for _, issue := range issues {
+		if !hasLabel(issue, opts.Labels) || !hasAssignee(issue, opts.Assignees) {
+			continue
+		}
+		stale = append(stale, issue)
+	}
+	sort.SliceStable(stale, func(i, j int) bool { return stale[i].UpdatedAt.Before(stale[j].UpdatedAt) })
+	return stale, nil
+}
+
+func hasLabel(issue *model.Issue, names []string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, label := range issue.Labels {
+		for _, name := range names {
+			if strings.EqualFold(label.Name, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasAssignee(issue *model.Issue, logins []string) bool {
+	if len(logins) == 0 {
+		return true
+	}
+	for _, assignee := range issue.Assignees {
+		for _, login := range logins {
+			if strings.EqualFold(assignee.Login, login) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func toStaleIssues(issues model.Issues, now time.Time) []StaleIssue {
+	stale := []StaleIssue{}
+	for _, issue := range issues {
+		entry := StaleIssue{
+			URL:       issue.URL,
+			Title:     issue.Title,
+			Repo:      issue.RepositoryID,
+			UpdatedAt: issue.UpdatedAt,
+			Days:      int(now.Sub(issue.UpdatedAt).Hours() / 24),
+			Assignees: []string{},
+			Labels:    []string{},
+		}
+		for _, assignee := range issue.Assignees {
+			entry.Assignees = append(entry.Assignees, assignee.Login)
+		}
+		for _, label := range issue.Labels {
+			entry.Labels = append(entry.Labels, label.Name)
+		}
+		stale = append(stale, entry)
+	}
+	return stale
+}
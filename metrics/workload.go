@@ -0,0 +1,123 @@
+package metrics // import "moul.io/depviz/metrics"
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"go.uber.org/zap"
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+)
+
+// Workload is the number of open issues assigned to a person.
+type Workload struct {
+	Login   string   `json:"login"`
+	Name    string   `json:"name,omitempty"`
+	Open    int      `json:"open"`
+	Limit   int      `json:"limit,omitempty"` // 0 means no limit
+	OverWIP bool     `json:"over-wip"`
+	Issues  []string `json:"issues"`
+}
+
+func PrintWorkload(opts *WorkloadOptions) error {
+	zap.L().Debug("PrintWorkload", zap.Stringer("opts", *opts))
+
+	computed, err := loadComputed(opts.SQL, opts.Targets, opts.Parse)
+	if err != nil {
+		return err
+	}
+	limits, err := opts.limits()
+	if err != nil {
+		return err
+	}
+	workloads := computeWorkloads(computed, opts.DedupeAccountsBy, opts.WIPLimit, limits)
+
+	if opts.Format == "json" {
+		out, err := json.MarshalIndent(workloads, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ASSIGNEE\tOPEN\tLIMIT\t")
+	for _, workload := range workloads {
+		limit := "-"
+		if workload.Limit > 0 {
+			limit = fmt.Sprintf("%d", workload.Limit)
+		}
+		flag := ""
+		if workload.OverWIP {
+			flag = "OVER WIP LIMIT"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", workload.Login, workload.Open, limit, flag)
+	}
+	return w.Flush()
+}
+
+// computeWorkloads counts the visible open issues per assignee, sorted by
+// decreasing workload.
+func computeWorkloads(computed *compute.Computed, dedupeBy string, globalLimit int, limits map[string]int) []Workload {
+	accounts := []*model.Account{}
+	seen := map[string]bool{}
+	for _, issue := range computed.Issues() {
+		for _, assignee := range issue.Assignees {
+			if !seen[assignee.ID] {
+				seen[assignee.ID] = true
+				accounts = append(accounts, assignee)
+			}
+		}
+	}
+	canonicals := map[string]*model.Account{}
+	if dedupeBy != "" {
+		canonicals, _ = model.DedupeAccounts(accounts, dedupeBy)
+	}
+
+	byAccount := map[string]*Workload{}
+	for _, issue := range computed.Issues() {
+		if issue.State == "closed" {
+			continue
+		}
+		counted := map[string]bool{}
+		for _, assignee := range issue.Assignees {
+			account := assignee
+			if canonical, found := canonicals[assignee.ID]; found {
+				account = canonical
+			}
+			if counted[account.ID] {
+				continue
+			}
+			counted[account.ID] = true
+			workload, found := byAccount[account.ID]
+			if !found {
+				workload = &Workload{Login: account.Login, Name: account.FullName, Issues: []string{}}
+				byAccount[account.ID] = workload
+			}
+			workload.Open++
+			workload.Issues = append(workload.Issues, issue.URL)
+		}
+	}
+
+	workloads := []Workload{}
+	for _, workload := range byAccount {
+		workload.Limit = globalLimit
+		if limit, found := limits[strings.ToLower(workload.Login)]; found {
+			workload.Limit = limit
+		}
+		workload.OverWIP = workload.Limit > 0 && workload.Open > workload.Limit
+		workloads = append(workloads, *workload)
+	}
+	sort.Slice(workloads, func(i, j int) bool {
+		if workloads[i].Open != workloads[j].Open {
+			return workloads[i].Open > workloads[j].Open
+		}
+		return workloads[i].Login < workloads[j].Login
+	})
+	return workloads
+}
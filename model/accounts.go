@@ -0,0 +1,127 @@
+package model // import "moul.io/depviz/model"
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	DedupeAccountsByLogin = "login"
+	DedupeAccountsByEmail = "email"
+)
+
+// ValidateDedupeAccountsBy checks that the value passed to --dedupe-accounts-by is supported.
+func ValidateDedupeAccountsBy(by string) error {
+	switch by {
+	case "", DedupeAccountsByLogin, DedupeAccountsByEmail:
+		return nil
+	}
+	return fmt.Errorf("invalid --dedupe-accounts-by value: %q (expected %q or %q)", by, DedupeAccountsByLogin, DedupeAccountsByEmail)
+}
+
+// accountDedupeKey returns the normalized identity used to merge accounts.
+//
+// GitHub "noreply" addresses (<id>+<login>@users.noreply.github.com) are
+// normalized to the login they belong to, accounts without email fall back
+// on their login.
+func accountDedupeKey(account *Account, by string) string {
+	login := strings.ToLower(strings.TrimSpace(account.Login))
+	if by == DedupeAccountsByLogin {
+		return login
+	}
+	email := strings.ToLower(strings.TrimSpace(account.Email))
+	if strings.HasSuffix(email, "@users.noreply.github.com") {
+		local := strings.TrimSuffix(email, "@users.noreply.github.com")
+		if idx := strings.Index(local, "+"); idx != -1 {
+			local = local[idx+1:]
+		}
+		return local
+	}
+	if email == "" {
+		return login
+	}
+	return email
+}
+
+// DedupeAccounts merges accounts sharing the same normalized login or email.
+//
+// It returns a map of every input account ID to its canonical (merged)
+// account, and the number of accounts that were collapsed. Merged accounts
+// keep the ID of the first account (sorted by ID) and store every source
+// identifier in Aliases.
+func DedupeAccounts(accounts []*Account, by string) (map[string]*Account, int) {
+	sorted := make([]*Account, len(accounts))
+	copy(sorted, accounts)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	groups := map[string][]*Account{}
+	keys := []string{}
+	for _, account := range sorted {
+		key := accountDedupeKey(account, by)
+		if key == "" { // nothing to match on, keep it as is
+			key = "id:" + account.ID
+		}
+		if _, found := groups[key]; !found {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], account)
+	}
+
+	canonicals := map[string]*Account{}
+	collapsed := 0
+	for _, key := range keys {
+		group := groups[key]
+		merged := *group[0]
+		aliases := map[string]bool{}
+		for _, alias := range merged.Aliases {
+			aliases[alias] = true
+		}
+		for _, account := range group {
+			for _, identifier := range []string{account.ID, account.Login, account.Email} {
+				if identifier != "" {
+					aliases[identifier] = true
+				}
+			}
+			for _, alias := range account.Aliases {
+				aliases[alias] = true
+			}
+			// fill missing fields with the ones of the other identities
+			if merged.FullName == "" || merged.FullName == merged.Login {
+				if account.FullName != "" && account.FullName != account.Login {
+					merged.FullName = account.FullName
+				}
+			}
+			if merged.Email == "" {
+				merged.Email = account.Email
+			}
+			if merged.AvatarURL == "" {
+				merged.AvatarURL = account.AvatarURL
+			}
+			if merged.HTMLURL == "" {
+				merged.HTMLURL = account.HTMLURL
+			}
+			if merged.Location == "" {
+				merged.Location = account.Location
+			}
+			if merged.Company == "" {
+				merged.Company = account.Company
+			}
+			if merged.Blog == "" {
+				merged.Blog = account.Blog
+			}
+		}
+		if len(group) > 1 {
+			merged.Aliases = nil
+			for alias := range aliases {
+				merged.Aliases = append(merged.Aliases, alias)
+			}
+			sort.Strings(merged.Aliases)
+			collapsed += len(group) - 1
+		}
+		for _, account := range group {
+			canonicals[account.ID] = &merged
+		}
+	}
+	return canonicals, collapsed
+}
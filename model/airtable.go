@@ -35,11 +35,14 @@ func copyFields(cache airtabledb.DB, src reflect.Value, dst reflect.Value) {
 			continue
 		}
 		sFV := src.FieldByName(fieldName)
-		if fieldName == "Errors" {
+		if fieldName == "Errors" || fieldName == "Aliases" {
 			dFV.Set(reflect.ValueOf(strings.Join(sFV.Interface().(pq.StringArray), ", ")))
 			continue
 		}
 		// log.Println("dFV.Type", dFV.Type().String(), "; fieldName", fieldName, "; sFV", sFV)
+		// the relationships are linked-record fields: the Airtable IDs of the
+		// records found in cache, the ones not created yet are skipped and
+		// linked by a second pass of the sync
 		if dFV.Type().String() == "[]string" {
 			if sFV.Pointer() != 0 {
 				tableIndex := 0
@@ -52,12 +55,16 @@ func copyFields(cache airtabledb.DB, src reflect.Value, dst reflect.Value) {
 					for i := 0; i < sFV.Len(); i++ {
 						idV := sFV.Index(i).Elem().FieldByName("ID")
 						id := idV.String()
-						dFV.Set(reflect.Append(dFV, reflect.ValueOf(cache.Tables[tableIndex].FindByID(id))))
+						if recordID := cache.Tables[tableIndex].FindByID(id); recordID != "" {
+							dFV.Set(reflect.Append(dFV, reflect.ValueOf(recordID)))
+						}
 					}
 				} else {
 					idV := sFV.Elem().FieldByName("ID")
 					id := idV.String()
-					dFV.Set(reflect.ValueOf([]string{cache.Tables[tableIndex].FindByID(id)}))
+					if recordID := cache.Tables[tableIndex].FindByID(id); recordID != "" {
+						dFV.Set(reflect.ValueOf([]string{recordID}))
+					}
 				}
 			}
 		} else {
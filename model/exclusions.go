@@ -0,0 +1,63 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"moul.io/multipmuri"
+)
+
+// ExcludedTarget is a negative target ("-github.com/myorg/archive-me"), it
+// removes the matching entities from the other targets, whatever their order.
+// The exclusions apply after the expansion of the owner and glob targets, use
+// "--" before them on the command line ("depviz pull -- myorg -myorg/old").
+type ExcludedTarget struct {
+	multipmuri.Entity
+}
+
+func (t *ExcludedTarget) String() string { return "-" + t.Entity.String() }
+
+// SplitTargets separates the targets from the exclusions.
+func SplitTargets(targets []multipmuri.Entity) (included []multipmuri.Entity, excluded []multipmuri.Entity) {
+	for _, target := range targets {
+		if exclusion, ok := target.(*ExcludedTarget); ok {
+			excluded = append(excluded, exclusion.Entity)
+			continue
+		}
+		included = append(included, target)
+	}
+	return included, excluded
+}
+
+// IsExcluded reports whether an entity is matched by one of the exclusions
+// returned by SplitTargets.
+func IsExcluded(entity multipmuri.Entity, excluded []multipmuri.Entity) bool {
+	for _, exclusion := range excluded {
+		if entity.Equals(exclusion) || exclusion.Contains(entity) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkExclusions returns an error if the exclusions remove every target.
+func checkExclusions(targets []multipmuri.Entity) error {
+	included, excluded := SplitTargets(targets)
+	if len(excluded) == 0 {
+		return nil
+	}
+	for _, target := range included {
+		if !IsExcluded(target, excluded) {
+			return nil
+		}
+	}
+	return fmt.Errorf("the exclusions remove every target: %s", targetsString(targets))
+}
+
+func targetsString(targets []multipmuri.Entity) string {
+	names := []string{}
+	for _, target := range targets {
+		names = append(names, target.String())
+	}
+	return strings.Join(names, " ")
+}
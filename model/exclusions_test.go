@@ -0,0 +1,61 @@
+package model
+
+import "testing"
+
+func TestExclusionsPrecedence(t *testing.T) {
+	// the exclusions apply whatever their position in the arguments
+	for _, args := range [][]string{
+		{"moul", "-moul/depviz", "-moul/old#1"},
+		{"-moul/depviz", "moul", "-moul/old#1"},
+	} {
+		targets, err := ParseTargets(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		included, excluded := SplitTargets(targets)
+		if len(included) != 1 || len(excluded) != 2 {
+			t.Fatalf("%v: expected 1 target and 2 exclusions, got %v and %v", args, included, excluded)
+		}
+		tests := []struct {
+			arg      string
+			excluded bool
+		}{
+			{"moul/depviz", true},
+			{"moul/depviz#42", true}, // the exclusion of a repository contains its issues
+			{"moul/old", false},      // only the excluded issue
+			{"moul/old#1", true},
+			{"moul/old#2", false},
+			{"moul/other", false},
+		}
+		for _, test := range tests {
+			entity, err := ParseTarget(test.arg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := IsExcluded(entity, excluded); got != test.excluded {
+				t.Errorf("%v: IsExcluded(%q): expected %v, got %v", args, test.arg, test.excluded, got)
+			}
+		}
+	}
+}
+
+func TestExclusionsRemoveEveryTarget(t *testing.T) {
+	tests := []struct {
+		args  []string
+		valid bool
+	}{
+		{[]string{"moul/depviz", "-moul/depviz"}, false},
+		{[]string{"moul/depviz#1", "-moul/depviz"}, false},
+		{[]string{"moul/depviz", "moul/other", "-moul/depviz"}, true},
+		{[]string{"-moul/depviz"}, true}, // no target, the commands check it
+	}
+	for _, test := range tests {
+		_, err := ParseTargets(test.args)
+		if test.valid && err != nil {
+			t.Errorf("%v: unexpected error: %v", test.args, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%v: expected an error", test.args)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FetchOptions are the options shared by the provider fetchers.
+type FetchOptions struct {
+	HTTPClient   *http.Client
+	Retries      int            // number of retries of a failing request
+	Failures     *FetchFailures // collects the requests still failing after the retries
+	ScanComments bool           // fetch the issue comments to parse links
+
+	GithubBaseURL string // GitHub Enterprise API URL, empty for github.com
+	NoCache       bool   // do not send conditional requests, see HTTPCacheEntry
+	Full          bool   // fetch every issue, not only the ones updated since the last sync
+	Synced        *SyncedRepos
+}
+
+// FetchFailure is a provider request that kept failing after the retries.
+type FetchFailure struct {
+	Provider string
+	Repo     string
+	Page     int
+	Err      error
+}
+
+func (f FetchFailure) String() string {
+	return fmt.Sprintf("%s %s page %d: %v", f.Provider, f.Repo, f.Page, f.Err)
+}
+
+// FetchFailures collects the failures of the concurrent fetchers.
+type FetchFailures struct {
+	mu    sync.Mutex
+	items []FetchFailure
+}
+
+func (f *FetchFailures) Add(failure FetchFailure) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.items = append(f.items, failure)
+}
+
+func (f *FetchFailures) List() []FetchFailure {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FetchFailure{}, f.items...)
+}
+
+// SyncedRepo is a repository whose issues were fetched without error.
+type SyncedRepo struct {
+	ID        string
+	StartedAt time.Time // the next pull fetches the issues updated since
+	Full      bool      // every issue was fetched, not only the updated ones
+}
+
+// SyncedRepos collects the repositories successfully fetched by the concurrent
+// fetchers, their sync is only recorded once the issues are saved.
+type SyncedRepos struct {
+	mu    sync.Mutex
+	items []SyncedRepo
+}
+
+func (s *SyncedRepos) Add(repo SyncedRepo) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = append(s.items, repo)
+}
+
+func (s *SyncedRepos) List() []SyncedRepo {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SyncedRepo{}, s.items...)
+}
+
+// Retry calls fn until it succeeds, at most retries+1 times, with a growing
+// delay between the attempts.
+func Retry(retries int, fn func() error) error {
+	err := fn()
+	for attempt := 1; err != nil && attempt <= retries; attempt++ {
+		time.Sleep(time.Duration(attempt) * time.Second)
+		err = fn()
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"moul.io/multipmuri"
+)
+
+// GlobTarget is a target matching the repositories of an owner by name, i.e.,
+// "github.com/myorg/service-*". Only '*' (any sequence of characters) and '?'
+// (any single character) are supported, in the repository name only.
+//
+// It behaves like the owner target, except it only contains the matching
+// repositories: the 'pull' command expands it with the repositories of the
+// owner, the other commands filter the stored issues.
+type GlobTarget struct {
+	multipmuri.Entity // the owner
+
+	Pattern string // i.e., "service-*"
+}
+
+// parseGlobTarget parses "owner/pattern" targets, the caller checks the
+// argument contains a glob.
+func parseGlobTarget(arg string) (*GlobTarget, error) {
+	idx := strings.LastIndex(arg, "/")
+	if idx == -1 {
+		return nil, fmt.Errorf("invalid target %q: the patterns are only supported in the repository names, i.e., 'myorg/service-*'", arg)
+	}
+	prefix, pattern := arg[:idx], arg[idx+1:]
+	if strings.ContainsAny(prefix, "*?") {
+		return nil, fmt.Errorf("invalid target %q: the patterns are only supported in the repository names, i.e., 'myorg/service-*'", arg)
+	}
+	if strings.ContainsAny(pattern, `[]\`) {
+		return nil, fmt.Errorf("invalid target %q: only '*' and '?' are supported in the patterns", arg)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid target %q: %v", arg, err)
+	}
+	owner, err := ParseTarget(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if login := strings.Trim(ownerPath(owner), "/"); owner.Provider() != multipmuri.GitHubProvider || login == "" || strings.Contains(login, "/") {
+		return nil, fmt.Errorf("invalid target %q: the patterns are only supported in the GitHub repository names, i.e., 'myorg/service-*'", arg)
+	}
+	return &GlobTarget{Entity: owner, Pattern: pattern}, nil
+}
+
+func (t *GlobTarget) String() string {
+	return strings.TrimSuffix(t.Entity.String(), "/") + "/" + t.Pattern
+}
+
+// OwnerEntity returns the owner of the matched repositories.
+func (t *GlobTarget) OwnerEntity() multipmuri.Entity { return t.Entity }
+
+// Equals is always false, a pattern is not an entity.
+func (t *GlobTarget) Equals(other multipmuri.Entity) bool { return false }
+
+// Contains reports whether the repository of other matches the pattern.
+func (t *GlobTarget) Contains(other multipmuri.Entity) bool {
+	repo := multipmuri.RepoEntity(other)
+	if repo == nil {
+		return false
+	}
+	return t.MatchRepo(repo.String())
+}
+
+// MatchRepo reports whether a repository URL matches the pattern, the case is
+// ignored.
+func (t *GlobTarget) MatchRepo(repoURL string) bool {
+	matched, _ := path.Match(strings.ToLower(t.String()), strings.ToLower(strings.TrimSuffix(repoURL, "/")))
+	return matched
+}
+
+func ownerPath(entity multipmuri.Entity) string {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(entity.String(), "https://"), "http://")
+	if idx := strings.Index(trimmed, "/"); idx != -1 {
+		return trimmed[idx:]
+	}
+	return ""
+}
@@ -2,6 +2,7 @@ package model // import "moul.io/depviz/model"
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -17,6 +18,10 @@ var AllModels = []interface{}{
 	Issue{},
 	Label{},
 	Account{},
+	Link{},
+	IssueTransfer{},
+	HTTPCacheEntry{},
+	RepositorySync{},
 }
 
 //
@@ -77,13 +82,16 @@ const (
 	UnknownProviderDriver ProviderDriver = "unknown"
 	GithubDriver          ProviderDriver = "github"
 	GitlabDriver          ProviderDriver = "gitlab"
+	GiteaDriver           ProviderDriver = "gitea"
+	JiraDriver            ProviderDriver = "jira"
+	BitbucketDriver       ProviderDriver = "bitbucket"
 )
 
 type Provider struct {
 	Base
 
 	// base fields
-	Driver string `json:"driver"` // github, gitlab, unknown
+	Driver string `json:"driver"` // github, gitlab, gitea, jira, bitbucket, unknown
 }
 
 func (p Provider) ToRecord(cache airtabledb.DB) airtabledb.Record {
@@ -139,9 +147,11 @@ type Issue struct {
 
 	// base fields
 	CompletedAt  time.Time `json:"completed-at"`
+	AssignedAt   time.Time `json:"assigned-at"` // first assignment, only recorded by the webhook
 	Title        string    `json:"title"`
 	State        string    `json:"state"`
 	Body         string    `json:"body"`
+	HTMLURL      string    `json:"html-url"` // web page, when it differs from URL (i.e., Bitbucket pull requests)
 	IsPR         bool      `json:"is-pr"`
 	IsLocked     bool      `json:"is-locked"`
 	NumComments  int       `json:"num-comments"`
@@ -149,6 +159,14 @@ type Issue struct {
 	NumDownvotes int       `json:"num-downvotes"`
 	IsOrphan     bool      `json:"is-orphan"`
 	IsHidden     bool      `json:"is-hidden"`
+	TasksDone    int       `json:"tasks-done"`  // checked plain checklist items
+	TasksTotal   int       `json:"tasks-total"` // plain checklist items
+	Comments     []string  `json:"-" gorm:"-"`  // fetched with --scan-comments, only used to parse links
+	Dependencies []string  `json:"-" gorm:"-"`  // blocking issues declared with the provider dependency feature (Gitea)
+
+	// estimates, edited in Airtable, see 'airtable sync --airtable-direction'
+	Estimate         string `json:"estimate"` // i.e., "3d", overrides the estimate labels
+	AirtableEstimate string `json:"-"`        // the Airtable value at the last sync, to detect the conflicts
 
 	// relationships
 	Repository        *Repository `json:"repository"`
@@ -168,6 +186,15 @@ type Issue struct {
 	Related           []*Issue    `json:"-" gorm:"many2many:issue_related;association_jointable_foreignkey:related_id"`
 }
 
+// WebURL returns the URL of the issue in the web interface of its provider,
+// see HTMLURL and the WebURL function.
+func (i Issue) WebURL() string {
+	if i.HTMLURL != "" {
+		return i.HTMLURL
+	}
+	return WebURL(i.URL)
+}
+
 func (i Issue) String() string {
 	out, _ := json.Marshal(i)
 	return string(out)
@@ -189,6 +216,101 @@ func (i *Issue) Render(w http.ResponseWriter, r *http.Request) error {
 
 type Issues []*Issue
 
+//
+// Link
+//
+
+type LinkKind string
+
+const (
+	BlocksLink      LinkKind = "blocks"
+	FixesLink       LinkKind = "fixes"
+	ClosesLink      LinkKind = "closes"
+	AddressesLink   LinkKind = "addresses"
+	PartOfLink      LinkKind = "part-of"
+	DependsOnLink   LinkKind = "depends-on"
+	ParentOfLink    LinkKind = "parent-of"
+	RelatedWithLink LinkKind = "related-with"
+)
+
+// Link is a relationship declared by an issue (the source) about another one
+// (the target), resolved at fetch time.
+type Link struct {
+	ID         string    `gorm:"primary_key" json:"id"`
+	CreatedAt  time.Time `json:"created-at,omitempty"`
+	SourceID   string    `json:"source-id" gorm:"index"`
+	TargetID   string    `json:"target-id"`
+	Kind       LinkKind  `json:"kind"`
+	Provenance string    `json:"provenance"` // where the link was found, i.e., "body"
+}
+
+func NewLink(sourceID string, kind LinkKind, targetID string, provenance string) *Link {
+	return &Link{
+		ID:         fmt.Sprintf("%s %s %s (%s)", sourceID, kind, targetID, provenance),
+		SourceID:   sourceID,
+		TargetID:   targetID,
+		Kind:       kind,
+		Provenance: provenance,
+	}
+}
+
+// Dependency returns the issue that should be done first and the one
+// depending on it, ok is false for links without scheduling constraint.
+func (l Link) Dependency() (dependency string, dependent string, ok bool) {
+	switch l.Kind {
+	case BlocksLink, FixesLink, ClosesLink, AddressesLink, PartOfLink:
+		return l.SourceID, l.TargetID, true
+	case DependsOnLink, ParentOfLink:
+		return l.TargetID, l.SourceID, true
+	}
+	return "", "", false
+}
+
+func (l Link) String() string {
+	out, _ := json.Marshal(l)
+	return string(out)
+}
+
+//
+// IssueTransfer
+//
+
+// IssueTransfer records that an issue was moved to another repository, the
+// references to its old URL are resolved to the new one.
+type IssueTransfer struct {
+	ID        string    `gorm:"primary_key" json:"id"` // old URL
+	CreatedAt time.Time `json:"created-at,omitempty"`
+	NewID     string    `json:"new-id"`
+}
+
+//
+// RepositorySync
+//
+
+// RepositorySync records the last successful pull of a repository, the next
+// pulls only fetch the issues updated since.
+type RepositorySync struct {
+	ID           string    `gorm:"primary_key" json:"id"` // repository URL
+	LastSyncedAt time.Time `json:"last-synced-at"`
+}
+
+//
+// HTTPCacheEntry
+//
+
+// HTTPCacheEntry is the last response of a provider API request, sent again
+// with conditional headers so unchanged resources are not downloaded twice.
+type HTTPCacheEntry struct {
+	ID           string    `gorm:"primary_key" json:"id"` // hash of the URL and credentials
+	UpdatedAt    time.Time `json:"updated-at,omitempty"`
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag"`
+	LastModified string    `json:"last-modified"`
+	Link         string    `json:"link"` // pagination
+	ContentType  string    `json:"content-type"`
+	Body         []byte    `json:"-"`
+}
+
 //
 // Label
 //
@@ -230,6 +352,10 @@ type Account struct {
 	Blog      string `json:"blog"`
 	Email     string `json:"email"`
 	AvatarURL string `json:"avatar-url"`
+	HTMLURL   string `json:"html-url"` // profile page, URL may be the API one
+
+	// merged identities (see --dedupe-accounts-by)
+	Aliases pq.StringArray `json:"aliases,omitempty" gorm:"type:varchar[]"`
 
 	// relationships
 	Provider   *Provider `json:"provider"`
@@ -1,21 +1,243 @@
 package model
 
-import "moul.io/multipmuri"
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
 
+	"moul.io/multipmuri"
+)
+
+// hosts are the self-hosted instances registered with RegisterHost, and
+// Bitbucket Cloud, their URLs have the same layout as the GitHub ones and are
+// parsed as GitHub entities.
+var (
+	hosts    = map[string]ProviderDriver{"bitbucket.org": BitbucketDriver}
+	jiraHost string // the host of the "jira:" targets, see RegisterJiraBaseURL
+	hostsMu  sync.RWMutex
+)
+
+var jiraKeyRegex = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_]*)(?:-([0-9]+))?$`)
+
+// RegisterHost registers a GitHub Enterprise or Gitea host (i.e.,
+// "github.example.com"), so its targets can be parsed.
+func RegisterHost(host string, driver ProviderDriver) {
+	hostsMu.Lock()
+	defer hostsMu.Unlock()
+	hosts[host] = driver
+}
+
+// HostDriver returns the driver of a host registered with RegisterHost, or
+// UnknownProviderDriver.
+func HostDriver(host string) ProviderDriver {
+	hostsMu.RLock()
+	defer hostsMu.RUnlock()
+	if driver, found := hosts[host]; found {
+		return driver
+	}
+	return UnknownProviderDriver
+}
+
+// EntityHost returns the host of an entity URL.
+func EntityHost(entity multipmuri.Entity) string {
+	u, err := url.Parse(entity.String())
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// RegisterGitHubBaseURL registers the host of a GitHub Enterprise API URL
+// (i.e., "https://github.example.com/api/v3/"), empty for github.com.
+func RegisterGitHubBaseURL(baseURL string) error {
+	if baseURL == "" {
+		return nil
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid GitHub base URL %q, expected i.e., 'https://github.example.com/api/v3/'", baseURL)
+	}
+	RegisterHost(u.Host, GithubDriver)
+	return nil
+}
+
+// RegisterJiraBaseURL registers a Jira instance (i.e.,
+// "https://example.atlassian.net"), for the "jira:PROJ" and "jira:PROJ-123"
+// targets. Its projects and issues are stored with the GitHub URL layout:
+// "https://example.atlassian.net/projects/PROJ/issues/123" for PROJ-123.
+func RegisterJiraBaseURL(baseURL string) error {
+	if baseURL == "" {
+		return nil
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") || strings.Trim(u.Path, "/") != "" {
+		return fmt.Errorf("invalid Jira base URL %q, expected i.e., 'https://example.atlassian.net' (without context path)", baseURL)
+	}
+	RegisterHost(u.Host, JiraDriver)
+	hostsMu.Lock()
+	defer hostsMu.Unlock()
+	jiraHost = u.Host
+	return nil
+}
+
+// parseJiraTarget parses the "jira:PROJ" and "jira:PROJ-123" targets.
+func parseJiraTarget(arg string) (multipmuri.Entity, error) {
+	hostsMu.RLock()
+	host := jiraHost
+	hostsMu.RUnlock()
+	if host == "" {
+		return nil, fmt.Errorf("invalid target %q: the Jira targets require --jira-base-url", arg)
+	}
+	match := jiraKeyRegex.FindStringSubmatch(strings.TrimPrefix(arg, "jira:"))
+	if match == nil {
+		return nil, fmt.Errorf("invalid target %q, expected i.e., 'jira:PROJ' or 'jira:PROJ-123'", arg)
+	}
+	path := "projects/" + strings.ToUpper(match[1])
+	if match[2] != "" {
+		path += "/issues/" + match[2]
+	}
+	return multipmuri.NewGitHubService(host).RelDecodeString(path)
+}
+
+// WebURL returns the URL of an entity in the web interface of its provider:
+// the Jira projects and issues stored with the GitHub layout (see
+// RegisterJiraBaseURL) are "https://example.atlassian.net/browse/PROJ-123"
+// and "https://example.atlassian.net/browse/PROJ". The other URLs are returned
+// as is.
+func WebURL(entityURL string) string {
+	hostsMu.RLock()
+	host := jiraHost
+	hostsMu.RUnlock()
+	if host == "" {
+		return entityURL
+	}
+	u, err := url.Parse(entityURL)
+	if err != nil || u.Host != host {
+		return entityURL
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "projects":
+		return fmt.Sprintf("%s://%s/browse/%s", u.Scheme, u.Host, parts[1])
+	case len(parts) == 4 && parts[0] == "projects" && parts[2] == "issues":
+		return fmt.Sprintf("%s://%s/browse/%s-%s", u.Scheme, u.Host, parts[1], parts[3])
+	}
+	return entityURL
+}
+
+// RegisterGiteaHosts registers the hosts of Gitea instances (i.e.,
+// "gitea.example.com").
+func RegisterGiteaHosts(giteaHosts []string) error {
+	for _, host := range giteaHosts {
+		if host == "" || strings.ContainsAny(host, "/:") {
+			return fmt.Errorf("invalid Gitea host %q, expected i.e., 'gitea.example.com'", host)
+		}
+		RegisterHost(host, GiteaDriver)
+	}
+	return nil
+}
+
+// RegisterHosts registers the GitHub Enterprise, Gitea and Jira hosts, the
+// URLs stored in database can only be parsed once their host is registered.
+func RegisterHosts(githubBaseURL string, giteaHosts []string, jiraBaseURL string) error {
+	if err := RegisterGitHubBaseURL(githubBaseURL); err != nil {
+		return err
+	}
+	if err := RegisterGiteaHosts(giteaHosts); err != nil {
+		return err
+	}
+	return RegisterJiraBaseURL(jiraBaseURL)
+}
+
+// ParseTargets parses the targets of the commands, it is the only target
+// parser, see ParseTarget. The arguments starting with '-' are exclusions, see
+// ExcludedTarget.
 func ParseTargets(args []string) ([]multipmuri.Entity, error) {
 	targets := []multipmuri.Entity{}
-	defaultContext := multipmuri.NewGitHubService("")
 	for _, arg := range args {
-		entity, err := defaultContext.RelDecodeString(arg)
+		target, err := parseTargetArg(arg)
 		if err != nil {
 			return nil, err
 		}
-		targets = append(targets, entity)
+		targets = append(targets, target)
+	}
+	if err := checkExclusions(targets); err != nil {
+		return nil, err
 	}
 	return targets, nil
 }
 
+func parseTargetArg(arg string) (multipmuri.Entity, error) {
+	if strings.HasPrefix(arg, "-") {
+		entity, err := ParseTarget(arg[1:])
+		if err != nil {
+			return nil, err
+		}
+		return &ExcludedTarget{Entity: entity}, nil
+	}
+	return ParseTarget(arg)
+}
+
+// ReadTargetsFile returns the targets of a --targets-file, one per line, the
+// blank lines and the "# comments" are ignored. The lines are validated, the
+// errors report the line number, and are meant to be appended to the targets
+// passed as arguments before calling ParseTargets.
+func ReadTargetsFile(path string) ([]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{}
+	for idx, line := range strings.Split(string(content), "\n") {
+		// '#' also introduces the issue numbers (moul/depviz#42), the comments
+		// start a line or follow a space
+		line = strings.TrimSpace(strings.Replace(line, "\t", " ", -1))
+		if comment := strings.Index(line, " #"); comment != -1 {
+			line = strings.TrimSpace(line[:comment])
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := parseTargetArg(line); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, idx+1, err)
+		}
+		args = append(args, line)
+	}
+	return args, nil
+}
+
+// ParseTargetsWithFile parses the targets passed as arguments and the ones of
+// the targets file, if any.
+func ParseTargetsWithFile(args []string, targetsFile string) ([]multipmuri.Entity, error) {
+	if targetsFile != "" {
+		lines, err := ReadTargetsFile(targetsFile)
+		if err != nil {
+			return nil, err
+		}
+		args = append(append([]string{}, args...), lines...)
+	}
+	return ParseTargets(args)
+}
+
+// ParseTarget parses "owner/repo", "owner/repo#42", full URLs and the URLs of
+// the hosts registered with RegisterHost. The trailing slashes are ignored.
+// The repository names can be patterns, see GlobTarget, the Jira projects and
+// issues are "jira:PROJ" and "jira:PROJ-123", see RegisterJiraBaseURL.
 func ParseTarget(arg string) (multipmuri.Entity, error) {
+	arg = strings.TrimRight(arg, "/")
+	if strings.HasPrefix(arg, "jira:") {
+		return parseJiraTarget(arg)
+	}
+	if strings.ContainsAny(arg, "*?[") {
+		return parseGlobTarget(arg)
+	}
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(arg, "https://"), "http://")
+	if parts := strings.SplitN(trimmed, "/", 2); len(parts) == 2 && HostDriver(parts[0]) != UnknownProviderDriver {
+		return multipmuri.NewGitHubService(parts[0]).RelDecodeString(parts[1])
+	}
 	defaultContext := multipmuri.NewGitHubService("")
 	return defaultContext.RelDecodeString(arg)
 }
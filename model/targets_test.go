@@ -0,0 +1,127 @@
+package model
+
+import "testing"
+
+func TestParseTarget(t *testing.T) {
+	RegisterHost("gitea.example.com", GiteaDriver)
+	if err := RegisterJiraBaseURL("https://example.atlassian.net"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		arg      string
+		expected string
+	}{
+		{"owner/repo", "moul/depviz", "https://github.com/moul/depviz"},
+		{"owner/repo#issue", "moul/depviz#42", "https://github.com/moul/depviz/issues/42"},
+		{"owner", "moul", "https://github.com/moul"},
+		{"full URL", "https://github.com/moul/depviz", "https://github.com/moul/depviz"},
+		{"issue URL", "https://github.com/moul/depviz/issues/42", "https://github.com/moul/depviz/issues/42"},
+		{"trailing slash", "https://github.com/moul/depviz/", "https://github.com/moul/depviz"},
+		{"trailing slashes", "moul/depviz//", "https://github.com/moul/depviz"},
+		{"registered host", "https://gitea.example.com/moul/depviz", "https://gitea.example.com/moul/depviz"},
+		{"registered host without scheme", "gitea.example.com/moul/depviz/", "https://gitea.example.com/moul/depviz"},
+		{"bitbucket", "https://bitbucket.org/moul/depviz", "https://bitbucket.org/moul/depviz"},
+		{"jira project", "jira:proj", "https://example.atlassian.net/projects/PROJ"},
+		{"jira issue", "jira:PROJ-123", "https://example.atlassian.net/projects/PROJ/issues/123"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target, err := ParseTarget(test.arg)
+			if err != nil {
+				t.Fatalf("ParseTarget(%q): %v", test.arg, err)
+			}
+			if target.String() != test.expected {
+				t.Errorf("ParseTarget(%q): expected %q, got %q", test.arg, test.expected, target.String())
+			}
+			// every command parses its targets with ParseTargets
+			targets, err := ParseTargets([]string{test.arg})
+			if err != nil {
+				t.Fatalf("ParseTargets(%q): %v", test.arg, err)
+			}
+			if len(targets) != 1 || targets[0].String() != test.expected {
+				t.Errorf("ParseTargets(%q): expected [%q], got %v", test.arg, test.expected, targets)
+			}
+		})
+	}
+}
+
+func TestParseTargetErrors(t *testing.T) {
+	if err := RegisterJiraBaseURL("https://example.atlassian.net"); err != nil {
+		t.Fatal(err)
+	}
+	for _, arg := range []string{"jira:", "jira:1PROJ", "jira:PROJ-"} {
+		if _, err := ParseTarget(arg); err == nil {
+			t.Errorf("ParseTarget(%q): expected an error", arg)
+		}
+	}
+}
+
+func TestParseTargetsExclusion(t *testing.T) {
+	targets, err := ParseTargets([]string{"moul/depviz", "-moul/depviz#42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 targets, got %d", len(targets))
+	}
+	excluded, ok := targets[1].(*ExcludedTarget)
+	if !ok {
+		t.Fatalf("expected an *ExcludedTarget, got %T", targets[1])
+	}
+	if expected := "https://github.com/moul/depviz/issues/42"; excluded.Entity.String() != expected {
+		t.Errorf("expected %q to be excluded, got %q", expected, excluded.Entity.String())
+	}
+}
+
+func TestWebURL(t *testing.T) {
+	if err := RegisterJiraBaseURL("https://example.atlassian.net"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"https://example.atlassian.net/projects/PROJ/issues/123", "https://example.atlassian.net/browse/PROJ-123"},
+		{"https://example.atlassian.net/projects/PROJ", "https://example.atlassian.net/browse/PROJ"},
+		{"https://example.atlassian.net/users/abc", "https://example.atlassian.net/users/abc"},
+		{"https://github.com/moul/depviz/issues/42", "https://github.com/moul/depviz/issues/42"},
+		{"https://github.com/projects/PROJ/issues/1", "https://github.com/projects/PROJ/issues/1"},
+	}
+	for _, test := range tests {
+		if got := WebURL(test.input); got != test.expected {
+			t.Errorf("WebURL(%q): expected %q, got %q", test.input, test.expected, got)
+		}
+	}
+
+	// the Jira targets are stored with the layout mapped by WebURL
+	target, err := ParseTarget("jira:PROJ-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := WebURL(target.String()); got != "https://example.atlassian.net/browse/PROJ-123" {
+		t.Errorf("expected the browse URL of jira:PROJ-123, got %q", got)
+	}
+}
+
+func TestRegisterHosts(t *testing.T) {
+	if err := RegisterHosts("https://github.example.com/api/v3/", []string{"gitea.example.com"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := HostDriver("github.example.com"); got != GithubDriver {
+		t.Errorf("expected the GitHub driver, got %v", got)
+	}
+	if got := HostDriver("gitea.example.com"); got != GiteaDriver {
+		t.Errorf("expected the Gitea driver, got %v", got)
+	}
+	if _, err := ParseTarget("https://gitea.example.com/moul/depviz/issues/1"); err != nil {
+		t.Errorf("expected the Gitea URL to be parsed: %v", err)
+	}
+	if err := RegisterHosts("", []string{"https://gitea.example.com"}, ""); err == nil {
+		t.Errorf("expected an error for an invalid Gitea host")
+	}
+	if err := RegisterHosts("github.example.com", nil, ""); err == nil {
+		t.Errorf("expected an error for an invalid GitHub base URL")
+	}
+}
@@ -0,0 +1,45 @@
+package pull
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+)
+
+var errBudgetExceeded = errors.New("API call budget exceeded (--max-api-calls)")
+
+// budgetTransport counts the provider API calls and refuses new ones once the
+// budget is exhausted. It is shared by the concurrent fetchers.
+type budgetTransport struct {
+	max       int64 // 0 means unlimited
+	used      int64
+	refused   int64
+	transport http.RoundTripper
+}
+
+func newBudgetTransport(max int) *budgetTransport {
+	return &budgetTransport{
+		max:       int64(max),
+		transport: http.DefaultTransport,
+	}
+}
+
+func (t *budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.max > 0 && atomic.AddInt64(&t.used, 1) > t.max {
+		atomic.AddInt64(&t.refused, 1)
+		return nil, errBudgetExceeded
+	}
+	if t.max <= 0 {
+		atomic.AddInt64(&t.used, 1)
+	}
+	return t.transport.RoundTrip(req)
+}
+
+// calls returns the number of API calls actually performed.
+func (t *budgetTransport) calls() int64 {
+	return atomic.LoadInt64(&t.used) - atomic.LoadInt64(&t.refused)
+}
+
+func (t *budgetTransport) exhausted() bool {
+	return atomic.LoadInt64(&t.refused) > 0
+}
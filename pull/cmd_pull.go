@@ -1,6 +1,8 @@
 package pull // import "moul.io/depviz/pull"
 
 import (
+	"runtime"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -15,7 +17,10 @@ func GetOptions(commands cli.Commands) Options {
 }
 
 func Commands() cli.Commands {
-	return cli.Commands{"pull": &pullCommand{}}
+	return cli.Commands{
+		"pull":       &pullCommand{},
+		"sql import": &importCommand{},
+	}
 }
 
 type pullCommand struct {
@@ -24,12 +29,21 @@ type pullCommand struct {
 
 func (cmd *pullCommand) CobraCommand(commands cli.Commands) *cobra.Command {
 	cc := &cobra.Command{
-		Use:   "pull",
+		Use:   "pull [targets...]",
 		Short: "Pull issues and update database without outputting graph",
-		Args:  cobra.MinimumNArgs(1),
+		Long: `Pull issues and update database without outputting graph.
+
+The targets are repositories (moul/depviz), organizations or users (moul,
+expanded with their repositories), repository patterns (moul/depviz-*) and
+exclusions (-moul/old), the exclusions apply after the expansion and must
+follow "--": depviz pull -- moul -moul/old`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(_ *cobra.Command, args []string) error {
 			opts := cmd.opts
 			opts.SQL = sql.GetOptions(commands)
+			if err := model.RegisterHosts(opts.GithubBaseURL, opts.GiteaHosts, opts.JiraBaseURL); err != nil {
+				return err
+			}
 			targets, err := model.ParseTargets(args)
 			if err != nil {
 				return err
@@ -51,8 +65,40 @@ func (cmd *pullCommand) LoadDefaultOptions() error {
 }
 
 func (cmd *pullCommand) ParseFlags(flags *pflag.FlagSet) {
-	flags.StringVarP(&cmd.opts.GithubToken, "github-token", "", "", "GitHub Token with 'issues' access")
-	flags.StringVarP(&cmd.opts.GitlabToken, "gitlab-token", "", "", "GitLab Token with 'issues' access")
+	if flags.Lookup("github-token") == nil { // shared with 'graph' in 'run'
+		flags.StringVarP(&cmd.opts.GithubToken, "github-token", "", "", "GitHub Token with 'issues' access")
+	}
+	flags.StringSliceVarP(&cmd.opts.GithubTokens, "github-tokens", "", []string{}, "GitHub Tokens by org or repo pattern (i.e., 'my-org=TOKEN,other-org/*-api=TOKEN'), falls back to --github-token")
+	if flags.Lookup("github-base-url") == nil {
+		flags.StringVarP(&cmd.opts.GithubBaseURL, "github-base-url", "", "", "GitHub Enterprise API URL (i.e., 'https://github.example.com/api/v3/')")
+	}
+	if flags.Lookup("gitea-hosts") == nil {
+		flags.StringSliceVarP(&cmd.opts.GiteaHosts, "gitea-hosts", "", []string{}, "hosts of the Gitea instances (i.e., 'gitea.example.com')")
+	}
+	flags.StringVarP(&cmd.opts.GiteaToken, "gitea-token", "", "", "Gitea Token with 'issues' access")
+	flags.StringVarP(&cmd.opts.BitbucketUser, "bitbucket-user", "", "", "Bitbucket Cloud username of the --bitbucket-token app password")
+	flags.StringVarP(&cmd.opts.BitbucketToken, "bitbucket-token", "", "", "Bitbucket Cloud app password with 'issues' and 'pull requests' read access")
+	if flags.Lookup("jira-base-url") == nil {
+		flags.StringVarP(&cmd.opts.JiraBaseURL, "jira-base-url", "", "", "URL of the Jira instance of the 'jira:PROJ' targets (i.e., 'https://example.atlassian.net')")
+	}
+	flags.StringVarP(&cmd.opts.JiraUser, "jira-user", "", "", "Jira user (i.e., email) of the Jira Cloud API token")
+	flags.StringVarP(&cmd.opts.JiraToken, "jira-token", "", "", "Jira Cloud API token, or Jira Server personal access token without --jira-user")
+	flags.StringVarP(&cmd.opts.JiraStoryPointsField, "jira-story-points-field", "", "customfield_10016", "ID of the Jira story points field, converted into estimates (one point is one day)")
+	if flags.Lookup("gitlab-token") == nil {
+		flags.StringVarP(&cmd.opts.GitlabToken, "gitlab-token", "", "", "GitLab Token with 'issues' access")
+	}
+	flags.IntVarP(&cmd.opts.MaxAPICalls, "max-api-calls", "", 0, "stop fetching once this number of provider API calls is reached (0 means unlimited)")
+	flags.BoolVarP(&cmd.opts.ProgressBar, "progress-bar", "", false, "display a progress bar during the fetch (falls back to log lines when stderr is not a terminal)")
+	flags.BoolVarP(&cmd.opts.ScanComments, "scan-comments", "", false, "also parse the issue comments for relationships (one more API call per commented issue)")
+	flags.BoolVarP(&cmd.opts.FollowTransfers, "follow-transfers", "", true, "resolve the references to issues transferred to another repository (one API call per missing reference)")
+	flags.BoolVarP(&cmd.opts.Full, "full", "", false, "fetch every issue instead of the ones updated since the last pull, the deleted issues are removed")
+	flags.BoolVarP(&cmd.opts.NoCache, "no-cache", "", false, "do not send conditional requests to GitHub, unchanged resources are downloaded again")
+	flags.IntVarP(&cmd.opts.FetchRetries, "fetch-retries", "", 2, "number of times the targets with a failing request are fetched again at the end of the crawl")
+	flags.IntVarP(&cmd.opts.Concurrency, "concurrency", "", runtime.NumCPU(), "maximum number of targets fetched in parallel")
+	flags.BoolVarP(&cmd.opts.IncludeArchived, "include-archived", "", false, "also fetch the archived repositories of the organization and user targets")
+	flags.BoolVarP(&cmd.opts.IncludeForks, "include-forks", "", false, "also fetch the forks of the organization and user targets")
+	flags.BoolVarP(&cmd.opts.FailOnFetchErrors, "fail-on-fetch-errors", "", false, "exit with an error if some requests still failed after the retries")
+	cmd.opts.Parse.ParseFlags(flags)
 	if err := viper.BindPFlags(flags); err != nil {
 		zap.L().Warn("failed to bind viper flags", zap.Error(err))
 	}
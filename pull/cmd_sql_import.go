@@ -0,0 +1,203 @@
+package pull
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"moul.io/depviz/cli"
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+	"moul.io/depviz/sql"
+)
+
+// maxNDJSONLineSize is the maximum size of an imported record.
+const maxNDJSONLineSize = 16 * 1024 * 1024
+
+// importOptions are the options of 'sql import', it lives in this package to
+// resolve the links of the imported issues like 'pull' does.
+type importOptions struct {
+	sql       sql.Options          `mapstructure:"sql"`
+	Path      string               `mapstructure:"path"` // parsed from Args
+	BatchSize int                  `mapstructure:"import-batch-size"`
+	Parse     compute.ParseOptions `mapstructure:",squash"`
+}
+
+func (opts *importOptions) Validate() error {
+	if opts.BatchSize < 1 {
+		return fmt.Errorf("invalid batch size: %d", opts.BatchSize)
+	}
+	return opts.sql.Validate()
+}
+
+type importCommand struct{ opts importOptions }
+
+func (cmd *importCommand) CobraCommand(commands cli.Commands) *cobra.Command {
+	cc := &cobra.Command{
+		Use:   "import <file.ndjson>",
+		Short: "Import issues from a newline-delimited JSON file (i.e., 'sql dump --ndjson'), '-' reads stdin",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			opts := cmd.opts
+			opts.sql = sql.GetOptions(commands)
+			opts.Path = args[0]
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return runImport(&opts)
+		},
+	}
+	cmd.ParseFlags(cc.Flags())
+	commands["sql"].ParseFlags(cc.Flags())
+	return cc
+}
+
+func (cmd *importCommand) LoadDefaultOptions() error { return viper.Unmarshal(&cmd.opts) }
+
+func (cmd *importCommand) ParseFlags(flags *pflag.FlagSet) {
+	flags.IntVarP(&cmd.opts.BatchSize, "import-batch-size", "", 500, "number of issues saved per transaction")
+	cmd.opts.Parse.ParseFlags(flags)
+	if err := viper.BindPFlags(flags); err != nil {
+		zap.L().Warn("failed to bind viper flags", zap.Error(err))
+	}
+}
+
+func runImport(opts *importOptions) error {
+	db, err := sql.FromOpts(&opts.sql)
+	if err != nil {
+		return err
+	}
+
+	var input io.Reader = os.Stdin
+	if opts.Path != "-" {
+		f, err := os.Open(opts.Path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		input = f
+	}
+
+	var (
+		imported, skipped, errored int
+		batch                      = []*model.Issue{}
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := saveIssuesBatch(db, batch, opts.Parse)
+		imported += n
+		errored += len(batch) - n
+		batch = batch[:0]
+		return err
+	}
+
+	// records are streamed, only the current batch is kept in memory
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), maxNDJSONLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+		var issue model.Issue
+		if err := json.Unmarshal([]byte(raw), &issue); err != nil {
+			zap.L().Warn("invalid record", zap.Int("line", line), zap.Error(err))
+			errored++
+			continue
+		}
+		if err := validateImportedIssue(&issue); err != nil {
+			zap.L().Warn("skipped record", zap.Int("line", line), zap.Error(err))
+			skipped++
+			continue
+		}
+		batch = append(batch, &issue)
+		if len(batch) >= opts.BatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("imported: %d, skipped: %d, errored: %d\n", imported, skipped, errored)
+	return nil
+}
+
+func validateImportedIssue(issue *model.Issue) error {
+	if issue.ID == "" || issue.URL == "" {
+		return fmt.Errorf("missing id or url")
+	}
+	if _, err := model.ParseTarget(issue.URL); err != nil {
+		return fmt.Errorf("invalid url %q: %v", issue.URL, err)
+	}
+	switch issue.State {
+	case "open", "closed", "opened", "merged", "locked":
+	default:
+		return fmt.Errorf("invalid state %q", issue.State)
+	}
+	return nil
+}
+
+// saveIssuesBatch upserts the issues and the links parsed from their bodies
+// in a single transaction, it returns the number of saved issues. When the
+// transaction fails, the issues are saved one by one and the ones failing
+// again are skipped.
+func saveIssuesBatch(db *gorm.DB, issues []*model.Issue, parseOpts compute.ParseOptions) (int, error) {
+	links := make([][]*model.Link, len(issues))
+	for i, issue := range issues {
+		var errs []error
+		links[i], errs = compute.ParseLinks(issue, parseOpts) // also sets the task list counts
+		for _, err := range errs {
+			zap.L().Debug("failed to parse issue body", zap.String("issue", issue.URL), zap.Error(err))
+		}
+	}
+
+	tx := db.Begin()
+	for _, issue := range issues {
+		if err := tx.Save(issue).Error; err != nil {
+			tx.Rollback()
+			return saveIssuesOneByOne(db, issues, links), nil
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return saveIssuesOneByOne(db, issues, links), nil
+	}
+	for i, issue := range issues {
+		if err := sql.SaveLinks(db, issue.ID, links[i]); err != nil {
+			return len(issues), err
+		}
+	}
+	return len(issues), nil
+}
+
+// saveIssuesOneByOne saves the issues of a failed batch separately, it
+// returns the number of saved issues.
+func saveIssuesOneByOne(db *gorm.DB, issues []*model.Issue, links [][]*model.Link) int {
+	saved := 0
+	for i, issue := range issues {
+		if err := db.Save(issue).Error; err != nil {
+			zap.L().Warn("failed to import record", zap.String("issue", issue.URL), zap.Error(err))
+			continue
+		}
+		if err := sql.SaveLinks(db, issue.ID, links[i]); err != nil {
+			zap.L().Warn("failed to import the links of a record", zap.String("issue", issue.URL), zap.Error(err))
+		}
+		saved++
+	}
+	return saved
+}
@@ -0,0 +1,90 @@
+package pull
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+const progressBarWidth = 30
+
+// progressBar renders the fetch progress on stderr (--progress-bar).
+//
+// It is updated by the concurrent fetchers, all the writes are serialized
+// with a mutex so the bar is never garbled. When stderr is not a terminal,
+// each completed repo is logged instead.
+type progressBar struct {
+	mu          sync.Mutex
+	enabled     bool
+	interactive bool
+	reposTotal  int
+	reposDone   int
+	issues      int
+	finished    bool
+}
+
+func newProgressBar(enabled bool, reposTotal int) *progressBar {
+	return &progressBar{
+		enabled:     enabled,
+		interactive: isTerminal(os.Stderr),
+		reposTotal:  reposTotal,
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+func (b *progressBar) addIssues(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.issues += n
+	b.render()
+}
+
+func (b *progressBar) repoDone(repo string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.finished {
+		return
+	}
+	b.reposDone++
+	if b.enabled && !b.interactive {
+		zap.L().Info("repo fetched", zap.String("repo", repo), zap.Int("repos-done", b.reposDone), zap.Int("repos-total", b.reposTotal))
+	}
+	b.render()
+}
+
+// finish renders the final state and terminates the bar line, it is called
+// once all the fetchers returned (some may not have reported it yet).
+func (b *progressBar) finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reposDone = b.reposTotal
+	b.render()
+	b.finished = true
+	if b.enabled && b.interactive {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// render must be called with the lock held.
+func (b *progressBar) render() {
+	if !b.enabled || !b.interactive || b.finished {
+		return
+	}
+	filled := 0
+	if b.reposTotal > 0 {
+		filled = progressBarWidth * b.reposDone / b.reposTotal
+	}
+	fmt.Fprintf(os.Stderr, "\r[%s%s] %d/%d repos, %d issues",
+		strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled),
+		b.reposDone, b.reposTotal, b.issues)
+}
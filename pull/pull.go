@@ -2,12 +2,22 @@ package pull
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/jinzhu/gorm"
 	"go.uber.org/zap"
+	"moul.io/depviz/bitbucket"
+	"moul.io/depviz/compute"
+	"moul.io/depviz/gitea"
 	"moul.io/depviz/github"
 	"moul.io/depviz/gitlab"
+	"moul.io/depviz/jira"
 	"moul.io/depviz/model"
 	"moul.io/depviz/sql"
 	"moul.io/multipmuri"
@@ -15,11 +25,46 @@ import (
 
 type Options struct {
 	// FIXME: find a way of handling multiple gitlab/github instances, somethine like .netrc maybe?
-	GithubToken string `mapstructure:"github-token"`
-	GitlabToken string `mapstructure:"gitlab-token"`
+	GithubToken  string   `mapstructure:"github-token"`
+	GithubTokens []string `mapstructure:"github-tokens"` // "pattern=token", see githubToken()
+	GitlabToken  string   `mapstructure:"gitlab-token"`
+
+	GithubBaseURL string `mapstructure:"github-base-url"`
+
+	GiteaToken string   `mapstructure:"gitea-token"`
+	GiteaHosts []string `mapstructure:"gitea-hosts"`
+
+	BitbucketUser  string `mapstructure:"bitbucket-user"`
+	BitbucketToken string `mapstructure:"bitbucket-token"`
+
+	JiraBaseURL          string `mapstructure:"jira-base-url"`
+	JiraUser             string `mapstructure:"jira-user"`
+	JiraToken            string `mapstructure:"jira-token"`
+	JiraStoryPointsField string `mapstructure:"jira-story-points-field"`
 
 	SQL sql.Options // inherited with sql.GetOptions()
 
+	Parse compute.ParseOptions `mapstructure:",squash"`
+
+	MaxAPICalls int `mapstructure:"max-api-calls"`
+
+	ProgressBar bool `mapstructure:"progress-bar"`
+
+	ScanComments bool `mapstructure:"scan-comments"`
+
+	FollowTransfers bool `mapstructure:"follow-transfers"`
+
+	NoCache bool `mapstructure:"no-cache"`
+	Full    bool `mapstructure:"full"`
+
+	FetchRetries      int  `mapstructure:"fetch-retries"`
+	FailOnFetchErrors bool `mapstructure:"fail-on-fetch-errors"`
+
+	Concurrency int `mapstructure:"concurrency"` // targets fetched in parallel, runtime.NumCPU() if <= 0
+
+	IncludeArchived bool `mapstructure:"include-archived"` // when expanding the owner targets
+	IncludeForks    bool `mapstructure:"include-forks"`
+
 	Targets []multipmuri.Entity `mapstructure:"targets"` // parsed from Args
 }
 
@@ -30,9 +75,24 @@ func (opts Options) String() string {
 
 func (opts Options) Validate() error {
 	// FIXME: verify github/gitlab?
+	if _, err := parseGithubTokens(opts.GithubTokens); err != nil {
+		return err
+	}
 	return opts.SQL.Validate()
 }
 
+func (opts Options) bitbucketConfig() bitbucket.Config {
+	return bitbucket.Config{User: opts.BitbucketUser, Token: opts.BitbucketToken}
+}
+
+func (opts Options) jiraConfig() jira.Config {
+	return jira.Config{
+		User:             opts.JiraUser,
+		Token:            opts.JiraToken,
+		StoryPointsField: opts.JiraStoryPointsField,
+	}
+}
+
 func Pull(opts *Options) error {
 	zap.L().Debug("pull", zap.Stringer("opts", *opts))
 
@@ -52,23 +112,111 @@ func Pull(opts *Options) error {
 func pull(opts *Options, db *gorm.DB) error {
 	// FIXME: handle the special '@me' target
 	var (
-		wg        sync.WaitGroup
-		allIssues []*model.Issue
-		out       = make(chan []*model.Issue, 101) // chan should always be bigger than the biggest paginate possible
+		budget     = newBudgetTransport(opts.MaxAPICalls)
+		httpClient = &http.Client{Transport: budget}
+		fetchOpts  = model.FetchOptions{
+			HTTPClient:   httpClient,
+			Retries:      opts.FetchRetries,
+			Failures:     &model.FetchFailures{},
+			ScanComments: opts.ScanComments,
+
+			GithubBaseURL: opts.GithubBaseURL,
+			NoCache:       opts.NoCache,
+			Full:          opts.Full,
+			Synced:        &model.SyncedRepos{},
+		}
 	)
 
-	// parallel fetches
-	wg.Add(len(opts.Targets))
-	for _, target := range opts.Targets {
+	expanded, err := expandTargets(opts, fetchOpts)
+	if err != nil {
+		return err
+	}
+	bar := newProgressBar(opts.ProgressBar, len(expanded))
+
+	// the targets with a failed request are fetched again at the end of the
+	// crawl, at most --fetch-retries times
+	fetcher := providerFetcher(opts, db)
+	allIssues, failed, failures := crawl(opts, expanded, fetchOpts, bar, fetcher)
+	bar.finish()
+	for attempt := 1; len(failed) > 0 && attempt <= opts.FetchRetries && !budget.exhausted(); attempt++ {
+		time.Sleep(time.Duration(attempt) * time.Second)
+		zap.L().Info("retrying the failed targets", zap.Int("attempt", attempt), zap.Int("targets", len(failed)))
+		var issues []*model.Issue
+		issues, failed, failures = crawl(opts, failed, fetchOpts, newProgressBar(false, len(failed)), fetcher)
+		allIssues = append(allIssues, issues...)
+	}
+	fetchOpts.Failures = failures
+	allIssues = uniqueIssues(allIssues)
+	return pullSave(opts, db, allIssues, fetchOpts, budget)
+}
+
+// fetchFunc fetches the issues of a target, sends them to out and calls
+// wg.Done, the failed requests are recorded in fetchOpts.Failures.
+type fetchFunc func(target multipmuri.Entity, wg *sync.WaitGroup, fetchOpts model.FetchOptions, out chan<- []*model.Issue)
+
+// providerFetcher returns the fetchers of the providers of the targets.
+func providerFetcher(opts *Options, db *gorm.DB) func(target multipmuri.Entity) fetchFunc {
+	return func(target multipmuri.Entity) fetchFunc {
 		switch target.Provider() {
 		case multipmuri.GitHubProvider:
-			go github.Pull(target, &wg, opts.GithubToken, db, out)
+			switch model.HostDriver(model.EntityHost(target)) {
+			case model.GiteaDriver:
+				return func(target multipmuri.Entity, wg *sync.WaitGroup, fetchOpts model.FetchOptions, out chan<- []*model.Issue) {
+					gitea.Pull(target, wg, opts.GiteaToken, fetchOpts, db, out)
+				}
+			case model.JiraDriver:
+				return func(target multipmuri.Entity, wg *sync.WaitGroup, fetchOpts model.FetchOptions, out chan<- []*model.Issue) {
+					jira.Pull(target, wg, opts.jiraConfig(), fetchOpts, db, out)
+				}
+			case model.BitbucketDriver:
+				return func(target multipmuri.Entity, wg *sync.WaitGroup, fetchOpts model.FetchOptions, out chan<- []*model.Issue) {
+					bitbucket.Pull(target, wg, opts.bitbucketConfig(), fetchOpts, db, out)
+				}
+			}
+			return func(target multipmuri.Entity, wg *sync.WaitGroup, fetchOpts model.FetchOptions, out chan<- []*model.Issue) {
+				github.Pull(target, wg, opts.githubToken(target), fetchOpts, db, out)
+			}
 		case multipmuri.GitLabProvider:
-			go gitlab.Pull(target, &wg, opts.GitlabToken, db, out)
+			return func(target multipmuri.Entity, wg *sync.WaitGroup, fetchOpts model.FetchOptions, out chan<- []*model.Issue) {
+				gitlab.Pull(target, wg, opts.GitlabToken, fetchOpts, db, out)
+			}
 		default:
 			panic("should not happen")
 		}
 	}
+}
+
+// crawl fetches the targets in parallel, at most --concurrency at a time,
+// without retrying the failed requests. It returns the fetched issues, the
+// targets with a failed request and their failures.
+func crawl(opts *Options, targets []multipmuri.Entity, fetchOpts model.FetchOptions, bar *progressBar, fetcher func(target multipmuri.Entity) fetchFunc) ([]*model.Issue, []multipmuri.Entity, *model.FetchFailures) {
+	var (
+		wg             sync.WaitGroup
+		allIssues      []*model.Issue
+		out            = make(chan []*model.Issue, 101) // chan should always be bigger than the biggest paginate possible
+		targetFailures = make([]*model.FetchFailures, len(targets))
+	)
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	slots := make(chan struct{}, concurrency)
+	wg.Add(len(targets))
+	for i, target := range targets {
+		targetFailures[i] = &model.FetchFailures{}
+		targetOpts := fetchOpts
+		targetOpts.Retries = 0
+		targetOpts.Failures = targetFailures[i]
+
+		fetch := fetcher(target)
+		go func(target multipmuri.Entity, fetchOpts model.FetchOptions) {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			fetch(target, &wg, fetchOpts, out)
+			bar.repoDone(target.String())
+		}(target, targetOpts)
+	}
 	go func() {
 		wg.Wait()
 		close(out)
@@ -76,15 +224,149 @@ func pull(opts *Options, db *gorm.DB) error {
 
 	for issues := range out {
 		allIssues = append(allIssues, issues...)
+		bar.addIssues(len(issues))
 	}
 
-	// save
+	var failed []multipmuri.Entity
+	failures := &model.FetchFailures{}
+	for i, target := range targets {
+		list := targetFailures[i].List()
+		if len(list) == 0 {
+			continue
+		}
+		failed = append(failed, target)
+		for _, failure := range list {
+			failures.Add(failure)
+		}
+	}
+	return allIssues, failed, failures
+}
+
+// uniqueIssues returns the issues sorted by URL, the issues fetched again by a
+// retry replace the previous ones.
+func uniqueIssues(issues []*model.Issue) []*model.Issue {
+	byURL := map[string]*model.Issue{}
+	for _, issue := range issues {
+		byURL[issue.URL] = issue
+	}
+	unique := make([]*model.Issue, 0, len(byURL))
+	for _, issue := range byURL {
+		unique = append(unique, issue)
+	}
+	sort.Slice(unique, func(i, j int) bool { return unique[i].URL < unique[j].URL })
+	return unique
+}
+
+// pullSave saves the fetched issues and their links, records the syncs and
+// follows the transfers.
+func pullSave(opts *Options, db *gorm.DB, allIssues []*model.Issue, fetchOpts model.FetchOptions, budget *budgetTransport) error {
+	failures := fetchOpts.Failures
+	zap.L().Debug("provider API calls", zap.Int64("calls", budget.calls()))
+	if budget.exhausted() {
+		zap.L().Warn("API call budget reached, saving partial results, the database may be incomplete",
+			zap.Int("max-api-calls", opts.MaxAPICalls),
+			zap.Int("fetched-issues", len(allIssues)),
+		)
+	}
+
+	// per-owner summary
+	perOwner := map[string]int{}
+	for _, issue := range allIssues {
+		perOwner[issue.RepositoryOwnerID]++
+	}
+	for owner, count := range perOwner {
+		zap.L().Info("fetched issues", zap.String("owner", owner), zap.Int("issues", count))
+	}
+
+	// resolve links and save
+	targets := map[string]bool{}
+	repos := map[string]bool{}
 	for _, issue := range allIssues {
-		if err := db.Save(issue).Error; err != nil {
+		if issue.Repository != nil {
+			repos[issue.Repository.ID] = true
+		}
+		links, err := SaveIssue(db, issue, opts.Parse, opts.ScanComments)
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			targets[link.TargetID] = true
+		}
+	}
+
+	if err := saveSyncs(db, fetchOpts, allIssues, budget.exhausted()); err != nil {
+		return err
+	}
+
+	if opts.FollowTransfers {
+		if err := followTransfers(opts, db, targets, repos, fetchOpts); err != nil {
 			return err
 		}
 	}
 
+	// report the failures once the partial results are saved
+	if list := failures.List(); len(list) > 0 {
+		for _, failure := range list {
+			zap.L().Warn("failed to fetch", zap.Stringer("item", failure))
+		}
+		if opts.FailOnFetchErrors {
+			return fmt.Errorf("%d fetch(es) failed after %d retries", len(list), opts.FetchRetries)
+		}
+	}
+
 	//return Compute(db)
 	return nil
 }
+
+// SaveIssue upserts an issue and replaces the links parsed from its body.
+// When the comments were not fetched, the links found in them previously are
+// kept.
+func SaveIssue(db *gorm.DB, issue *model.Issue, parseOpts compute.ParseOptions, withComments bool) ([]*model.Link, error) {
+	links, errs := compute.ParseLinks(issue, parseOpts)
+	for _, err := range errs {
+		zap.L().Debug("failed to parse issue body", zap.String("issue", issue.URL), zap.Error(err))
+	}
+	// the estimates are only set by 'airtable sync', the first assignment by
+	// the webhook
+	if err := db.Omit("estimate", "airtable_estimate", "assigned_at").Save(issue).Error; err != nil {
+		return nil, err
+	}
+	keep := []string{}
+	if !withComments {
+		keep = append(keep, "comment")
+	}
+	if err := sql.SaveLinks(db, issue.ID, links, keep...); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// saveSyncs records the repositories successfully pulled, once their issues
+// are saved, so the next pull starts from there. Nothing is recorded for the
+// repositories with a failed request or when the API call budget was reached.
+func saveSyncs(db *gorm.DB, fetchOpts model.FetchOptions, allIssues []*model.Issue, exhausted bool) error {
+	if exhausted {
+		return nil
+	}
+	failed := map[string]bool{}
+	for _, failure := range fetchOpts.Failures.List() {
+		failed[strings.SplitN(failure.Repo, "#", 2)[0]] = true
+	}
+	fetchedIDs := map[string][]string{}
+	for _, issue := range allIssues {
+		repoID := issue.RepositoryID
+		if issue.Repository != nil {
+			repoID = issue.Repository.ID
+		}
+		fetchedIDs[repoID] = append(fetchedIDs[repoID], issue.ID)
+	}
+	for _, repo := range fetchOpts.Synced.List() {
+		if failed[repo.ID] {
+			continue
+		}
+		if err := sql.SaveSync(db, repo, fetchedIDs[repo.ID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
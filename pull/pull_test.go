@@ -0,0 +1,88 @@
+package pull
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"moul.io/depviz/model"
+	"moul.io/multipmuri"
+)
+
+// fakeFetcher returns a provider fetching two issues per target after a
+// delay, the targets of failing have a failed request.
+func fakeFetcher(delay time.Duration, failing map[string]bool) func(target multipmuri.Entity) fetchFunc {
+	return func(multipmuri.Entity) fetchFunc {
+		return func(target multipmuri.Entity, wg *sync.WaitGroup, fetchOpts model.FetchOptions, out chan<- []*model.Issue) {
+			defer wg.Done()
+			time.Sleep(delay)
+			if failing[target.String()] {
+				fetchOpts.Failures.Add(model.FetchFailure{Provider: "fake", Repo: target.String(), Err: errors.New("500 Internal Server Error")})
+				return
+			}
+			issues := []*model.Issue{}
+			for number := 2; number > 0; number-- {
+				url := fmt.Sprintf("%s/issues/%d", target.String(), number)
+				issues = append(issues, &model.Issue{Base: model.Base{ID: url, URL: url}})
+			}
+			out <- issues
+		}
+	}
+}
+
+func testTargets(t *testing.T, count int) []multipmuri.Entity {
+	args := []string{}
+	for i := count; i > 0; i-- {
+		args = append(args, fmt.Sprintf("moul/repo%d", i))
+	}
+	targets, err := model.ParseTargets(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return targets
+}
+
+func TestCrawlConcurrency(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	targets := testTargets(t, 8)
+	elapsed := func(concurrency int) time.Duration {
+		start := time.Now()
+		issues, failed, _ := crawl(&Options{Concurrency: concurrency}, targets, model.FetchOptions{}, newProgressBar(false, len(targets)), fakeFetcher(delay, nil))
+		if len(issues) != 2*len(targets) || len(failed) != 0 {
+			t.Fatalf("expected %d issues and no failure, got %d issues and %d failures", 2*len(targets), len(issues), len(failed))
+		}
+		return time.Since(start)
+	}
+	sequential, parallel := elapsed(1), elapsed(len(targets))
+	if sequential < time.Duration(len(targets))*delay {
+		t.Errorf("expected --concurrency=1 to fetch the targets one by one, took %v", sequential)
+	}
+	if parallel > sequential/2 {
+		t.Errorf("expected the parallel fetches to be faster: %v in parallel, %v sequentially", parallel, sequential)
+	}
+}
+
+func TestCrawlOrderAndFailures(t *testing.T) {
+	targets := testTargets(t, 4)
+	failing := map[string]bool{targets[1].String(): true}
+	issues, failed, failures := crawl(&Options{Concurrency: 4}, targets, model.FetchOptions{}, newProgressBar(false, len(targets)), fakeFetcher(time.Millisecond, failing))
+
+	if len(failed) != 1 || failed[0].String() != targets[1].String() {
+		t.Errorf("expected %q to be reported as failed, got %v", targets[1], failed)
+	}
+	if list := failures.List(); len(list) != 1 {
+		t.Errorf("expected 1 failure, got %v", list)
+	}
+
+	issues = uniqueIssues(append(issues, issues...))
+	if len(issues) != 6 {
+		t.Fatalf("expected 6 unique issues, got %d", len(issues))
+	}
+	for i := 1; i < len(issues); i++ {
+		if issues[i-1].URL >= issues[i].URL {
+			t.Errorf("expected the issues to be sorted by URL, got %q before %q", issues[i-1].URL, issues[i].URL)
+		}
+	}
+}
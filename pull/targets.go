@@ -0,0 +1,111 @@
+package pull
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+	"moul.io/depviz/github"
+	"moul.io/depviz/model"
+	"moul.io/multipmuri"
+)
+
+// listRepos returns the repository URLs of a GitHub owner, replaced in the
+// tests.
+var listRepos = github.ListRepos
+
+type multipmuriOwner interface {
+	OwnerEntity() multipmuri.Entity
+}
+
+type multipmuriRepo interface {
+	Repo() *multipmuri.GitHubRepo
+}
+
+// ownerLogin returns the owner of an owner target (i.e., "moul" for
+// "github.com/moul"), or "" if the target is not an owner.
+func ownerLogin(target multipmuri.Entity) string {
+	if _, isRepo := target.(multipmuriRepo); isRepo {
+		return ""
+	}
+	withOwner, ok := target.(multipmuriOwner)
+	if !ok {
+		return ""
+	}
+	u, err := url.Parse(withOwner.OwnerEntity().String())
+	if err != nil {
+		return ""
+	}
+	return strings.Trim(u.Path, "/")
+}
+
+// expandTargets replaces the GitHub organizations and users by their
+// repositories (see --include-archived and --include-forks), so they are
+// fetched individually, the patterns (see model.GlobTarget) by the matching
+// ones, then the exclusions are removed. The other targets are kept as is.
+func expandTargets(opts *Options, fetchOpts model.FetchOptions) ([]multipmuri.Entity, error) {
+	targets := []multipmuri.Entity{}
+	seen := map[string]bool{}
+	add := func(target multipmuri.Entity) {
+		if !seen[target.String()] {
+			seen[target.String()] = true
+			targets = append(targets, target)
+		}
+	}
+	included, excluded := model.SplitTargets(opts.Targets)
+	for _, target := range included {
+		glob, isGlob := target.(*model.GlobTarget)
+		owner := target
+		if isGlob {
+			owner = glob.Entity
+		}
+		login := ownerLogin(owner)
+		driver := model.HostDriver(model.EntityHost(owner))
+		if login == "" || target.Provider() != multipmuri.GitHubProvider || driver == model.GiteaDriver || driver == model.JiraDriver || driver == model.BitbucketDriver {
+			if isGlob {
+				return nil, fmt.Errorf("invalid target %q: the patterns are only supported for the GitHub repositories", target)
+			}
+			add(target)
+			continue
+		}
+		urls, err := listRepos(login, opts.githubToken(owner), fetchOpts, opts.IncludeArchived, opts.IncludeForks)
+		if err != nil {
+			return nil, err
+		}
+		if isGlob {
+			matching := []string{}
+			for _, u := range urls {
+				if glob.MatchRepo(u) {
+					matching = append(matching, u)
+				}
+			}
+			if len(matching) == 0 {
+				return nil, fmt.Errorf("no repository of %q matches %q", owner, glob.Pattern)
+			}
+			urls = matching
+		}
+		zap.L().Info("expanded target", zap.Stringer("target", target), zap.Int("repos", len(urls)))
+		for _, u := range urls {
+			repo, err := model.ParseTarget(u)
+			if err != nil {
+				return nil, err
+			}
+			add(repo)
+		}
+	}
+
+	// the exclusions apply to the expanded targets
+	kept := []multipmuri.Entity{}
+	for _, target := range targets {
+		if model.IsExcluded(target, excluded) {
+			zap.L().Debug("excluded target", zap.Stringer("target", target))
+			continue
+		}
+		kept = append(kept, target)
+	}
+	if len(kept) == 0 && len(excluded) > 0 {
+		return nil, fmt.Errorf("the exclusions remove every target")
+	}
+	return kept, nil
+}
@@ -0,0 +1,129 @@
+package pull
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"moul.io/depviz/model"
+)
+
+// fakeListRepos returns the repositories of the "myorg" owner.
+func fakeListRepos(owner string, token string, fetchOpts model.FetchOptions, includeArchived, includeForks bool) ([]string, error) {
+	if owner != "myorg" {
+		return nil, nil
+	}
+	return []string{
+		"https://github.com/myorg/service-auth",
+		"https://github.com/myorg/service-billing",
+		"https://github.com/myorg/Service-Search",
+		"https://github.com/myorg/service-a",
+		"https://github.com/myorg/website",
+	}, nil
+}
+
+func TestExpandGlobTargets(t *testing.T) {
+	original := listRepos
+	defer func() { listRepos = original }()
+	listRepos = fakeListRepos
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{"star", []string{"https://github.com/myorg/service-*"}, []string{
+			"https://github.com/myorg/service-auth",
+			"https://github.com/myorg/service-billing",
+			"https://github.com/myorg/Service-Search",
+			"https://github.com/myorg/service-a",
+		}},
+		{"question mark", []string{"myorg/service-?"}, []string{"https://github.com/myorg/service-a"}},
+		{"exclusion", []string{"myorg/service-*", "-myorg/service-billing"}, []string{
+			"https://github.com/myorg/service-auth",
+			"https://github.com/myorg/Service-Search",
+			"https://github.com/myorg/service-a",
+		}},
+		{"overlapping patterns", []string{"myorg/service-a*", "myorg/*auth"}, []string{
+			"https://github.com/myorg/service-auth",
+			"https://github.com/myorg/service-a",
+		}},
+		{"plain repository", []string{"moul/depviz"}, []string{"https://github.com/moul/depviz"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			targets, err := model.ParseTargets(test.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expanded, err := expandTargets(&Options{Targets: targets}, model.FetchOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, target := range expanded {
+				got = append(got, target.String())
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestExpandGlobTargetsUnmatched(t *testing.T) {
+	original := listRepos
+	defer func() { listRepos = original }()
+	listRepos = fakeListRepos
+
+	targets, err := model.ParseTargets([]string{"myorg/worker-*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = expandTargets(&Options{Targets: targets}, model.FetchOptions{})
+	if err == nil || !strings.Contains(err.Error(), "no repository") {
+		t.Errorf("expected an unmatched pattern error, got %v", err)
+	}
+}
+
+func TestParseGlobTargetErrors(t *testing.T) {
+	for _, arg := range []string{"myorg/service-[ab]", "my*/service", "service-*"} {
+		if _, err := model.ParseTargets([]string{arg}); err == nil {
+			t.Errorf("ParseTargets(%q): expected an error", arg)
+		}
+	}
+}
+
+func TestExpandTargetsExclusions(t *testing.T) {
+	original := listRepos
+	defer func() { listRepos = original }()
+	listRepos = fakeListRepos
+
+	// exclusions of exact repositories, applied after the glob and owner
+	// expansion
+	targets, err := model.ParseTargets([]string{"-myorg/service-auth", "myorg/service-*", "-https://github.com/myorg/service-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expanded, err := expandTargets(&Options{Targets: targets}, model.FetchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, target := range expanded {
+		got = append(got, target.String())
+	}
+	expected := []string{"https://github.com/myorg/service-billing", "https://github.com/myorg/Service-Search"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	// the expansion is checked again: the exclusions remove every match
+	targets, err = model.ParseTargets([]string{"myorg/service-?", "-myorg/service-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := expandTargets(&Options{Targets: targets}, model.FetchOptions{}); err == nil || !strings.Contains(err.Error(), "remove every target") {
+		t.Errorf("expected the exclusions to remove every target, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package pull
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"moul.io/multipmuri"
+)
+
+type githubTokenEntry struct {
+	pattern string
+	token   string
+}
+
+// parseGithubTokens parses the --github-tokens entries ("pattern=token"), in
+// order.
+func parseGithubTokens(entries []string) ([]githubTokenEntry, error) {
+	tokens := []githubTokenEntry{}
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid github token entry, expected 'pattern=token'")
+		}
+		if _, err := path.Match(parts[0], ""); err != nil {
+			return nil, fmt.Errorf("invalid github token pattern %q: %v", parts[0], err)
+		}
+		tokens = append(tokens, githubTokenEntry{pattern: parts[0], token: parts[1]})
+	}
+	return tokens, nil
+}
+
+// githubToken returns the token to use for a target, the most specific
+// --github-tokens pattern matching the "owner/repo" of the target wins, a
+// pattern without "/" matches an owner (i.e., "moul" or "moul-*").
+// --github-token is used when no pattern matches. The owner targets only match
+// the patterns without "/". Between patterns as specific, the first one wins.
+func (opts Options) githubToken(target multipmuri.Entity) string {
+	var owner, fullName string // fullName is empty for the owner targets
+	if withRepo, ok := target.(multipmuriRepo); ok {
+		repo := withRepo.Repo()
+		owner = repo.OwnerID()
+		fullName = repo.OwnerID() + "/" + repo.RepoID()
+	} else if owner = ownerLogin(target); owner == "" {
+		return opts.GithubToken
+	}
+
+	// parsed in Validate
+	tokens, _ := parseGithubTokens(opts.GithubTokens)
+	token := opts.GithubToken
+	best := ""
+	for _, entry := range tokens {
+		pattern := entry.pattern
+		name := fullName
+		if !strings.Contains(pattern, "/") {
+			name = owner
+		} else if fullName == "" {
+			continue
+		}
+		matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(name))
+		if !matched || len(pattern) <= len(best) {
+			continue
+		}
+		best = pattern
+		token = entry.token
+	}
+	return token
+}
@@ -0,0 +1,80 @@
+package pull
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"go.uber.org/zap"
+	"moul.io/depviz/github"
+	"moul.io/depviz/model"
+	"moul.io/depviz/sql"
+	"moul.io/multipmuri"
+)
+
+// transferChunkSize is the number of link targets looked up per query.
+const transferChunkSize = 500
+
+// followTransfers looks for the link targets that are not in the database
+// because they were transferred to another repository, and records their new
+// location.
+// Only the targets of the fetched repos are checked, the other ones are
+// expected to be missing.
+func followTransfers(opts *Options, db *gorm.DB, targets map[string]bool, repos map[string]bool, fetchOpts model.FetchOptions) error {
+	if len(targets) == 0 {
+		return nil
+	}
+	ids := []string{}
+	for id := range targets {
+		ids = append(ids, id)
+	}
+	// chunked, SQLite limits the number of variables of a query
+	var known []string
+	for start := 0; start < len(ids); start += transferChunkSize {
+		end := start + transferChunkSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		var chunk []string
+		if err := db.Model(model.Issue{}).Where("id IN (?)", ids[start:end]).Pluck("id", &chunk).Error; err != nil {
+			return err
+		}
+		known = append(known, chunk...)
+	}
+	transfers, err := sql.LoadTransfers(db)
+	if err != nil {
+		return err
+	}
+	for _, id := range known {
+		delete(targets, id)
+	}
+
+	for id := range targets {
+		if _, found := transfers[id]; found {
+			continue
+		}
+		entity, err := model.ParseTarget(id)
+		if err != nil || entity.Provider() != multipmuri.GitHubProvider {
+			continue // FIXME: support GitLab moved issues
+		}
+		if driver := model.HostDriver(model.EntityHost(entity)); driver == model.GiteaDriver || driver == model.JiraDriver || driver == model.BitbucketDriver {
+			continue // Gitea, Jira and Bitbucket do not support transfers
+		}
+		if !repos[multipmuri.RepoEntity(entity).String()] {
+			continue
+		}
+		newID, err := github.ResolveTransfer(id, opts.githubToken(entity), fetchOpts)
+		if err != nil {
+			zap.L().Debug("failed to resolve a missing link target", zap.String("target", id), zap.Error(err))
+			continue
+		}
+		if newID == "" {
+			continue
+		}
+		zap.L().Info("issue was transferred", zap.String("old", id), zap.String("new", newID))
+		transfer := model.IssueTransfer{ID: id, NewID: newID, CreatedAt: time.Now()}
+		if err := db.Save(&transfer).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
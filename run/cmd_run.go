@@ -1,8 +1,6 @@
 package run // import "moul.io/depviz/run"
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -47,6 +45,9 @@ func (cmd *runCommand) CobraCommand(commands cli.Commands) *cobra.Command {
 		Short: "'pull' + 'graph' in a unique command",
 		Args: func(c *cobra.Command, args []string) error {
 			// FIXME: if no args, then run the whole database
+			if targetsFile := c.Flags().Lookup("targets-file"); targetsFile != nil && targetsFile.Value.String() != "" {
+				return nil
+			}
 			if err := cobra.MinimumNArgs(1)(c, args); err != nil {
 				return err
 			}
@@ -58,7 +59,19 @@ func (cmd *runCommand) CobraCommand(commands cli.Commands) *cobra.Command {
 			opts.Graph = graph.GetOptions(commands)
 			opts.Pull.SQL = sql.GetOptions(commands)
 			opts.Graph.SQL = opts.Pull.SQL
-			targets, err := model.ParseTargets(args)
+			if opts.Pull.GithubBaseURL == "" { // the flag is shared, and bound to the graph options
+				opts.Pull.GithubBaseURL = opts.Graph.GithubBaseURL
+			}
+			if len(opts.Pull.GiteaHosts) == 0 {
+				opts.Pull.GiteaHosts = opts.Graph.GiteaHosts
+			}
+			if opts.Pull.JiraBaseURL == "" {
+				opts.Pull.JiraBaseURL = opts.Graph.JiraBaseURL
+			}
+			if err := model.RegisterHosts(opts.Pull.GithubBaseURL, opts.Pull.GiteaHosts, opts.Pull.JiraBaseURL); err != nil {
+				return err
+			}
+			targets, err := model.ParseTargetsWithFile(args, opts.Graph.TargetsFile) // the flag is bound to the graph options
 			if err != nil {
 				return err
 			}
@@ -91,10 +104,5 @@ func Run(opts *Options) error {
 	if err := pull.Pull(&opts.Pull); err != nil {
 		return err
 	}
-	graph, err := graph.Graph(&opts.Graph)
-	if err != nil {
-		return err
-	}
-	fmt.Println(graph)
-	return nil
+	return graph.PrintGraph(&opts.Graph)
 }
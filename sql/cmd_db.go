@@ -0,0 +1,109 @@
+package sql
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"moul.io/depviz/cli"
+)
+
+type dbCommand struct{}
+
+func (cmd *dbCommand) LoadDefaultOptions() error { return nil }
+
+func (cmd *dbCommand) ParseFlags(flags *pflag.FlagSet) {}
+
+func (cmd *dbCommand) CobraCommand(commands cli.Commands) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "db",
+		Short: "Manage the database schema",
+	}
+	command.AddCommand(commands["db migrate"].CobraCommand(commands))
+	return command
+}
+
+type migrateOptions struct {
+	sql    Options `mapstructure:"sql"`
+	DryRun bool    `mapstructure:"dry-run"`
+}
+
+func (opts migrateOptions) Validate() error {
+	return opts.sql.Validate()
+}
+
+type migrateCommand struct{ opts migrateOptions }
+
+func (cmd *migrateCommand) CobraCommand(commands cli.Commands) *cobra.Command {
+	cc := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply the pending schema migrations and print the schema version",
+		Long: `Apply the pending schema migrations and print the schema version.
+
+The migrations are also applied when a command opens the database, use
+--dry-run to list what an upgrade would change first.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, args []string) error {
+			opts := cmd.opts
+			opts.sql = GetOptions(commands)
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return runMigrate(&opts)
+		},
+	}
+	cmd.ParseFlags(cc.Flags())
+	commands["sql"].ParseFlags(cc.Flags())
+	return cc
+}
+
+func (cmd *migrateCommand) LoadDefaultOptions() error { return viper.Unmarshal(&cmd.opts) }
+
+func (cmd *migrateCommand) ParseFlags(flags *pflag.FlagSet) {
+	flags.BoolVarP(&cmd.opts.DryRun, "dry-run", "", false, "only list the pending migrations")
+	if err := viper.BindPFlags(flags); err != nil {
+		zap.L().Warn("failed to bind viper flags", zap.Error(err))
+	}
+}
+
+func runMigrate(opts *migrateOptions) error {
+	db, err := open(&opts.sql)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	pending, err := PendingMigrations(db)
+	if err != nil {
+		return err
+	}
+	versions := []int{}
+	for v := range pending {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	fmt.Printf("schema version: %d\n", version)
+	for _, v := range versions {
+		if opts.DryRun {
+			fmt.Printf("pending: %d %s\n", v, pending[v])
+		} else {
+			fmt.Printf("applying: %d %s\n", v, pending[v])
+		}
+	}
+	if opts.DryRun || len(versions) == 0 {
+		return nil
+	}
+
+	if version, err = Migrate(db); err != nil {
+		return err
+	}
+	fmt.Printf("schema version: %d\n", version)
+	return nil
+}
@@ -2,6 +2,7 @@ package sql
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -12,10 +13,24 @@ import (
 
 type Options struct {
 	Config  string `mapstructure:"config"`
+	Driver  string `mapstructure:"db-driver"` // overrides Config
+	DSN     string `mapstructure:"db-dsn"`
 	Verbose bool   `mapstructure:"verbose"`
 }
 
 func (opts Options) Validate() error {
+	switch opts.Driver {
+	case "":
+		if opts.DSN != "" {
+			return fmt.Errorf("--db-dsn requires --db-driver")
+		}
+	case "sqlite", "sqlite3":
+		if opts.DSN == "" {
+			return fmt.Errorf("--db-driver requires --db-dsn (i.e., './depviz.db' or ':memory:')")
+		}
+	default:
+		return fmt.Errorf("invalid --db-driver value: %q (supported: sqlite)", opts.Driver)
+	}
 	return nil
 }
 
@@ -30,10 +45,15 @@ func GetOptions(commands cli.Commands) Options {
 
 func Commands() cli.Commands {
 	return cli.Commands{
-		"sql":      &sqlCommand{},
-		"sql dump": &dumpCommand{},
-		"sql info": &infoCommand{},
+		"sql":        &sqlCommand{},
+		"sql dump":   &dumpCommand{},
+		"sql info":   &infoCommand{},
+		"sql stats":  &statsCommand{},
+		"sql export": &exportCommand{},
+		// "sql import" is registered by the pull package, it resolves the links
 		// FIXME: "sql flush"
+		"db":         &dbCommand{},
+		"db migrate": &migrateCommand{},
 	}
 }
 
@@ -43,6 +63,8 @@ func (cmd *sqlCommand) LoadDefaultOptions() error { return viper.Unmarshal(&cmd.
 
 func (cmd *sqlCommand) ParseFlags(flags *pflag.FlagSet) {
 	flags.StringVarP(&cmd.opts.Config, "sql-config", "", "sqlite://$HOME/.depviz.db", "sql connection string")
+	flags.StringVarP(&cmd.opts.Driver, "db-driver", "", "", "database driver, overrides --sql-config (supported: sqlite)")
+	flags.StringVarP(&cmd.opts.DSN, "db-dsn", "", "", "data source name of --db-driver, i.e., the SQLite file path or ':memory:'")
 	if err := viper.BindPFlags(flags); err != nil {
 		zap.L().Warn("failed to bind viper flags", zap.Error(err))
 	}
@@ -55,5 +77,8 @@ func (cmd *sqlCommand) CobraCommand(commands cli.Commands) *cobra.Command {
 	}
 	command.AddCommand(commands["sql dump"].CobraCommand(commands))
 	command.AddCommand(commands["sql info"].CobraCommand(commands))
+	command.AddCommand(commands["sql stats"].CobraCommand(commands))
+	command.AddCommand(commands["sql export"].CobraCommand(commands))
+	command.AddCommand(commands["sql import"].CobraCommand(commands))
 	return command
 }
@@ -1,24 +1,111 @@
 package sql
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/jinzhu/gorm"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"moul.io/depviz/cli"
+	"moul.io/depviz/model"
+	"moul.io/multipmuri"
 )
 
 type dumpOptions struct {
-	sql Options `mapstructure:"sql"`
+	sql    Options  `mapstructure:"sql"`
+	NDJSON bool     `mapstructure:"ndjson"`
+	Format string   `mapstructure:"dump-format"`
+	Repos  []string `mapstructure:"repo"`
+	State  string   `mapstructure:"state"`
+	Since  string   `mapstructure:"since"`
+	Links  bool     `mapstructure:"links"`
 	// FIXME: add --anonymize
 }
 
 func (opts *dumpOptions) Validate() error {
-	return opts.sql.Validate()
+	if err := opts.sql.Validate(); err != nil {
+		return err
+	}
+	switch opts.Format {
+	case "json":
+	case "csv":
+		if opts.NDJSON {
+			return fmt.Errorf("--ndjson is only supported by the json format")
+		}
+	default:
+		return fmt.Errorf("invalid --dump-format value: %q (supported: json, csv)", opts.Format)
+	}
+	switch opts.State {
+	case "", "open", "closed":
+	default:
+		return fmt.Errorf("invalid --state value: %q (supported: open, closed)", opts.State)
+	}
+	if _, err := opts.repoIDs(); err != nil {
+		return err
+	}
+	if _, err := opts.since(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// repoIDs normalizes the --repo values ("moul/depviz" or URLs) to repository
+// IDs.
+func (opts *dumpOptions) repoIDs() ([]string, error) {
+	type multipmuriRepo interface {
+		RepoEntity() multipmuri.Entity
+	}
+	ids := []string{}
+	for _, input := range opts.Repos {
+		entity, err := model.ParseTarget(input)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --repo value %q: %v", input, err)
+		}
+		repo, ok := entity.(multipmuriRepo)
+		if !ok {
+			return nil, fmt.Errorf("invalid --repo value %q: not a repository", input)
+		}
+		ids = append(ids, repo.RepoEntity().String())
+	}
+	return ids, nil
+}
+
+func (opts *dumpOptions) since() (time.Time, error) {
+	if opts.Since == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, opts.Since); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value %q, expected RFC3339 or YYYY-MM-DD", opts.Since)
+}
+
+// filter restricts the query with the --repo, --state and --since flags, so
+// the other issues are not loaded at all.
+func (opts *dumpOptions) filter(db *gorm.DB) *gorm.DB {
+	if ids, _ := opts.repoIDs(); len(ids) > 0 {
+		db = db.Where("repository_id IN (?)", ids)
+	}
+	if opts.State != "" {
+		db = db.Where("state = ?", opts.State)
+	}
+	if since, _ := opts.since(); !since.IsZero() {
+		db = db.Where("updated_at >= ?", since)
+	}
+	return db
 }
 
 type dumpCommand struct{ opts dumpOptions }
@@ -26,7 +113,7 @@ type dumpCommand struct{ opts dumpOptions }
 func (cmd *dumpCommand) CobraCommand(commands cli.Commands) *cobra.Command {
 	cc := &cobra.Command{
 		Use:   "dump",
-		Short: "Print all issues stored in the database, formatted as JSON",
+		Short: "Print the issues or the links stored in the database, formatted as JSON or CSV",
 		RunE: func(_ *cobra.Command, args []string) error {
 			opts := cmd.opts
 			opts.sql = GetOptions(commands)
@@ -44,6 +131,12 @@ func (cmd *dumpCommand) CobraCommand(commands cli.Commands) *cobra.Command {
 func (cmd *dumpCommand) LoadDefaultOptions() error { return viper.Unmarshal(&cmd.opts) }
 
 func (cmd *dumpCommand) ParseFlags(flags *pflag.FlagSet) {
+	flags.BoolVarP(&cmd.opts.NDJSON, "ndjson", "", false, "print one issue per line (newline-delimited JSON), readable by 'sql import'")
+	flags.StringVarP(&cmd.opts.Format, "dump-format", "", "json", "output format (json, csv)")
+	flags.StringSliceVarP(&cmd.opts.Repos, "repo", "", []string{}, "only dump the issues of these repositories (i.e., moul/depviz)")
+	flags.StringVarP(&cmd.opts.State, "state", "", "", "only dump the issues in this state (open, closed)")
+	flags.StringVarP(&cmd.opts.Since, "since", "", "", "only dump the issues updated since this date (RFC3339 or YYYY-MM-DD)")
+	flags.BoolVarP(&cmd.opts.Links, "links", "", false, "dump the links stored at pull time instead, the filters apply to their source issue")
 	if err := viper.BindPFlags(flags); err != nil {
 		zap.L().Warn("failed to bind viper flags", zap.Error(err))
 	}
@@ -55,15 +148,165 @@ func runDump(opts *dumpOptions) error {
 		return err
 	}
 
-	issues, err := LoadAllIssues(db)
+	// the issues are streamed, so the memory usage does not depend on the
+	// size of the database
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	if opts.Links {
+		return dumpLinks(db, opts, w)
+	}
+	if opts.Format == "csv" {
+		return dumpCSV(db, opts.filter(db), w)
+	}
+	if opts.NDJSON {
+		enc := json.NewEncoder(w)
+		return ForEachIssue(opts.filter(db), func(issue *model.Issue) error {
+			return enc.Encode(issue)
+		})
+	}
+
+	array := newJSONArrayWriter(w)
+	err = ForEachIssue(opts.filter(db), func(issue *model.Issue) error {
+		return array.write(issue)
+	})
 	if err != nil {
 		return err
 	}
+	return array.close()
+}
 
-	out, err := json.MarshalIndent(issues, "", "  ")
+// dumpCSV writes the issues as a flat CSV, one line per issue. The relations
+// are loaded issue by issue to keep the memory usage flat.
+func dumpCSV(db *gorm.DB, query *gorm.DB, w io.Writer) error {
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"id", "repo", "title", "state", "author", "assignees", "labels", "milestone", "created-at", "updated-at"})
+	err := ForEachIssue(query, func(issue *model.Issue) error {
+		var (
+			author    model.Account
+			milestone model.Milestone
+			assignees []string
+			labels    []string
+		)
+		if issue.AuthorID != "" {
+			if err := db.Where("id = ?", issue.AuthorID).First(&author).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+				return err
+			}
+		}
+		if issue.MilestoneID != "" {
+			if err := db.Where("id = ?", issue.MilestoneID).First(&milestone).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+				return err
+			}
+		}
+		if err := db.Model(issue).Association("Assignees").Find(&issue.Assignees).Error; err != nil {
+			return err
+		}
+		for _, assignee := range issue.Assignees {
+			assignees = append(assignees, assignee.Login)
+		}
+		if err := db.Model(issue).Association("Labels").Find(&issue.Labels).Error; err != nil {
+			return err
+		}
+		for _, label := range issue.Labels {
+			labels = append(labels, label.Name)
+		}
+		return out.Write([]string{
+			issue.ID,
+			issue.RepositoryID,
+			issue.Title,
+			issue.State,
+			author.Login,
+			strings.Join(assignees, ", "),
+			strings.Join(labels, ", "),
+			milestone.Title,
+			issue.CreatedAt.Format(time.RFC3339),
+			issue.UpdatedAt.Format(time.RFC3339),
+		})
+	})
 	if err != nil {
 		return err
 	}
-	fmt.Println(string(out))
-	return nil
+	out.Flush()
+	return out.Error()
+}
+
+// dumpLinks writes the links declared by the issues matching the filters, the
+// links are small so they are loaded at once.
+func dumpLinks(db *gorm.DB, opts *dumpOptions, w io.Writer) error {
+	query := db.Model(model.Link{}).Order("source_id, id")
+	if len(opts.Repos) > 0 || opts.State != "" || opts.Since != "" {
+		sources := opts.filter(db.Model(model.Issue{}).Select("id"))
+		query = query.Where("source_id IN (?)", sources.QueryExpr())
+	}
+	var links []*model.Link
+	if err := query.Find(&links).Error; err != nil {
+		return err
+	}
+
+	switch {
+	case opts.Format == "csv":
+		out := csv.NewWriter(w)
+		_ = out.Write([]string{"source-id", "kind", "target-id", "provenance", "created-at"})
+		for _, link := range links {
+			_ = out.Write([]string{link.SourceID, string(link.Kind), link.TargetID, link.Provenance, link.CreatedAt.Format(time.RFC3339)})
+		}
+		out.Flush()
+		return out.Error()
+	case opts.NDJSON:
+		enc := json.NewEncoder(w)
+		for _, link := range links {
+			if err := enc.Encode(link); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	array := newJSONArrayWriter(w)
+	for _, link := range links {
+		if err := array.write(link); err != nil {
+			return err
+		}
+	}
+	return array.close()
+}
+
+// jsonArrayWriter writes the values one by one as an indented JSON array,
+// like json.MarshalIndent(values, "", "  ") would.
+type jsonArrayWriter struct {
+	w     io.Writer
+	buf   bytes.Buffer
+	enc   *json.Encoder
+	count int
+}
+
+func newJSONArrayWriter(w io.Writer) *jsonArrayWriter {
+	a := &jsonArrayWriter{w: w}
+	a.enc = json.NewEncoder(&a.buf)
+	a.enc.SetIndent("  ", "  ")
+	return a
+}
+
+func (a *jsonArrayWriter) write(value interface{}) error {
+	a.buf.Reset()
+	if err := a.enc.Encode(value); err != nil {
+		return err
+	}
+	separator := ",\n  "
+	if a.count == 0 {
+		separator = "[\n  "
+	}
+	a.count++
+	if _, err := io.WriteString(a.w, separator); err != nil {
+		return err
+	}
+	_, err := a.w.Write(bytes.TrimRight(a.buf.Bytes(), "\n"))
+	return err
+}
+
+func (a *jsonArrayWriter) close() error {
+	end := "\n]\n"
+	if a.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(a.w, end)
+	return err
 }
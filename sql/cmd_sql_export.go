@@ -0,0 +1,135 @@
+package sql
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"moul.io/depviz/cli"
+	"moul.io/depviz/model"
+)
+
+type exportOptions struct {
+	sql      Options `mapstructure:"sql"`
+	Path     string  `mapstructure:"path"` // parsed from Args
+	Force    bool    `mapstructure:"export-force"`
+	Writable bool    `mapstructure:"export-writable"`
+}
+
+func (opts *exportOptions) Validate() error {
+	if opts.Path == "" {
+		return fmt.Errorf("missing export path")
+	}
+	return opts.sql.Validate()
+}
+
+type exportCommand struct{ opts exportOptions }
+
+func (cmd *exportCommand) CobraCommand(commands cli.Commands) *cobra.Command {
+	cc := &cobra.Command{
+		Use:   "export <file.sqlite>",
+		Short: "Export the database content into a standalone read-only SQLite file",
+		Long: `Export the database content into a standalone read-only SQLite file.
+
+The generated file can be queried with any SQLite client, without depviz.
+It contains one table per model, using singular names:
+
+  provider     code hosting services (id, url, driver)
+  account      users and organizations (id, url, login, fullname, email, provider_id, ...)
+  repository   repositories (id, url, title, description, owner_id, provider_id, ...)
+  milestone    milestones (id, url, title, due_on, closed_at, repository_id, ...)
+  label        labels (id, url, name, color, description)
+  issue        issues and pull requests (id, url, title, state, body, is_pr, repository_id, milestone_id, author_id, ...)
+  link            relationships declared by issues (id, source_id, target_id, kind, provenance)
+  issue_transfer  issues moved to another repository (id, new_id)
+
+and the following join tables:
+
+  issue_labels      (issue_id, label_id)
+  issue_assignees   (issue_id, account_id)`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			opts := cmd.opts
+			opts.sql = GetOptions(commands)
+			opts.Path = args[0]
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return runExport(&opts)
+		},
+	}
+	cmd.ParseFlags(cc.Flags())
+	commands["sql"].ParseFlags(cc.Flags())
+	return cc
+}
+
+func (cmd *exportCommand) LoadDefaultOptions() error { return viper.Unmarshal(&cmd.opts) }
+
+func (cmd *exportCommand) ParseFlags(flags *pflag.FlagSet) {
+	flags.BoolVarP(&cmd.opts.Force, "export-force", "", false, "overwrite the destination file if it already exists")
+	flags.BoolVarP(&cmd.opts.Writable, "export-writable", "", false, "do not make the exported file read-only")
+	if err := viper.BindPFlags(flags); err != nil {
+		zap.L().Warn("failed to bind viper flags", zap.Error(err))
+	}
+}
+
+func runExport(opts *exportOptions) error {
+	if _, err := os.Stat(opts.Path); err == nil {
+		if !opts.Force {
+			return fmt.Errorf("%q already exists, use --export-force to overwrite it", opts.Path)
+		}
+		if err := os.Remove(opts.Path); err != nil {
+			return err
+		}
+	}
+
+	src, err := FromOpts(&opts.sql)
+	if err != nil {
+		return err
+	}
+
+	dst, err := gorm.Open("sqlite3", opts.Path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = dst.Close() }()
+	if dst, err = configureDB(dst, opts.sql.Verbose); err != nil {
+		return err
+	}
+	if _, err := Migrate(dst); err != nil {
+		return err
+	}
+
+	// copy every table, the join tables are populated when saving the issues
+	tx := dst.Begin()
+	for _, m := range model.AllModels {
+		rows := reflect.New(reflect.SliceOf(reflect.PtrTo(reflect.TypeOf(m))))
+		if err := src.Model(m).Find(rows.Interface()).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		for i := 0; i < rows.Elem().Len(); i++ {
+			if err := tx.Save(rows.Elem().Index(i).Interface()).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		zap.L().Debug("exported table", zap.String("table", src.NewScope(m).TableName()), zap.Int("rows", rows.Elem().Len()))
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	if !opts.Writable {
+		if err := os.Chmod(opts.Path, 0444); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("exported database to %q\n", opts.Path)
+	return nil
+}
@@ -0,0 +1,193 @@
+package sql
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/jinzhu/gorm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"moul.io/depviz/cli"
+	"moul.io/depviz/model"
+)
+
+type statsOptions struct {
+	sql    Options `mapstructure:"sql"`
+	Format string  `mapstructure:"stats-format"`
+}
+
+func (opts statsOptions) Validate() error {
+	if err := opts.sql.Validate(); err != nil {
+		return err
+	}
+	switch opts.Format {
+	case "text", "json":
+	default:
+		return fmt.Errorf("invalid --stats-format value: %q (supported: text, json)", opts.Format)
+	}
+	return nil
+}
+
+type statsCommand struct{ opts statsOptions }
+
+func (cmd *statsCommand) CobraCommand(commands cli.Commands) *cobra.Command {
+	cc := &cobra.Command{
+		Use:   "stats",
+		Short: "Print an overview of the issues stored in the database",
+		RunE: func(_ *cobra.Command, args []string) error {
+			opts := cmd.opts
+			opts.sql = GetOptions(commands)
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return runStats(&opts)
+		},
+	}
+	cmd.ParseFlags(cc.Flags())
+	commands["sql"].ParseFlags(cc.Flags())
+	return cc
+}
+
+func (cmd *statsCommand) LoadDefaultOptions() error { return viper.Unmarshal(&cmd.opts) }
+
+func (cmd *statsCommand) ParseFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&cmd.opts.Format, "stats-format", "", "text", "output format (text, json)")
+	if err := viper.BindPFlags(flags); err != nil {
+		zap.L().Warn("failed to bind viper flags", zap.Error(err))
+	}
+}
+
+// Stats is an overview of the issues stored in the database.
+type Stats struct {
+	Issues        int            `json:"issues"`
+	PRs           int            `json:"prs"`
+	Open          int            `json:"open"`
+	Closed        int            `json:"closed"`
+	ByRepo        map[string]int `json:"by-repo"`
+	ByLabel       map[string]int `json:"by-label"`
+	LastUpdatedAt *time.Time     `json:"last-updated-at,omitempty"`
+}
+
+// LoadStats computes the stats with GROUP BY queries, the issues are not
+// loaded.
+func LoadStats(db *gorm.DB) (*Stats, error) {
+	stats := Stats{
+		ByRepo:  map[string]int{},
+		ByLabel: map[string]int{},
+	}
+	type group struct {
+		GroupKey   string
+		GroupCount int
+	}
+	issues := db.NewScope(model.Issue{}).TableName()
+	labels := db.NewScope(model.Label{}).TableName()
+	groupBy := func(query *gorm.DB, key string) ([]group, error) {
+		groups := []group{}
+		err := query.Select(key + " AS group_key, COUNT(*) AS group_count").Group(key).Scan(&groups).Error
+		return groups, err
+	}
+
+	byType, err := groupBy(db.Table(issues), "is_pr")
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range byType {
+		switch g.GroupKey {
+		case "1", "true", "t":
+			stats.PRs += g.GroupCount
+		default:
+			stats.Issues += g.GroupCount
+		}
+	}
+
+	byState, err := groupBy(db.Table(issues), "state")
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range byState {
+		switch g.GroupKey {
+		case "closed":
+			stats.Closed += g.GroupCount
+		default:
+			stats.Open += g.GroupCount
+		}
+	}
+
+	byRepo, err := groupBy(db.Table(issues), "repository_id")
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range byRepo {
+		stats.ByRepo[g.GroupKey] = g.GroupCount
+	}
+
+	byLabel, err := groupBy(
+		db.Table("issue_labels").Joins(fmt.Sprintf("JOIN %s ON %s.id = issue_labels.label_id", labels, labels)),
+		labels+".name",
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range byLabel {
+		stats.ByLabel[g.GroupKey] = g.GroupCount
+	}
+
+	var latest model.Issue
+	switch err := db.Select("updated_at").Order("updated_at desc").First(&latest).Error; {
+	case gorm.IsRecordNotFoundError(err):
+	case err != nil:
+		return nil, err
+	default:
+		stats.LastUpdatedAt = &latest.UpdatedAt
+	}
+	return &stats, nil
+}
+
+func runStats(opts *statsOptions) error {
+	db, err := FromOpts(&opts.sql)
+	if err != nil {
+		return err
+	}
+	stats, err := LoadStats(db)
+	if err != nil {
+		return err
+	}
+
+	if opts.Format == "json" {
+		out, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	fmt.Printf("issues: %5d\n", stats.Issues)
+	fmt.Printf("prs:    %5d\n", stats.PRs)
+	fmt.Printf("open:   %5d\n", stats.Open)
+	fmt.Printf("closed: %5d\n", stats.Closed)
+	if stats.LastUpdatedAt != nil {
+		fmt.Printf("last update: %s\n", stats.LastUpdatedAt.Format(time.RFC3339))
+	}
+	for _, key := range sortedKeys(stats.ByRepo) {
+		fmt.Printf("repo:  %-50s %5d\n", key, stats.ByRepo[key])
+	}
+	for _, key := range sortedKeys(stats.ByLabel) {
+		fmt.Printf("label: %-50s %5d\n", key, stats.ByLabel[key])
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
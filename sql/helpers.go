@@ -1,6 +1,8 @@
 package sql
 
 import (
+	"time"
+
 	"github.com/jinzhu/gorm"
 	"go.uber.org/zap"
 	"moul.io/depviz/model"
@@ -23,3 +25,165 @@ func LoadAllIssues(db *gorm.DB) (model.Issues, error) {
 	zap.L().Debug("fetched issues", zap.Int("quantity", len(allIssues)))
 	return allIssues, nil
 }
+
+// ForEachIssue calls fn with each issue, in the same order as LoadAllIssues,
+// with its relations. The issues are loaded by pages of perPage, so only the
+// current page is kept in memory.
+func ForEachIssue(db *gorm.DB, fn func(issue *model.Issue) error) error {
+	query := db.Model(model.Issue{}).Order("created_at").Order("id")
+	perPage := 100
+	for page := 0; ; page++ {
+		var issues []*model.Issue
+		if err := query.Limit(perPage).Offset(perPage * page).Find(&issues).Error; err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			if err := fn(issue); err != nil {
+				return err
+			}
+		}
+		if len(issues) < perPage {
+			return nil
+		}
+	}
+}
+
+// PullSince returns the date from which the issues of a repository should be
+// fetched: the last successful sync, or the last update of its issues for the
+// databases populated before the syncs were recorded. It is zero when the
+// repository is unknown or when full is true.
+func PullSince(db *gorm.DB, repoID string, full bool) time.Time {
+	if full {
+		return time.Time{}
+	}
+	var sync model.RepositorySync
+	if err := db.Where("id = ?", repoID).First(&sync).Error; err == nil {
+		return sync.LastSyncedAt
+	}
+	var lastEntry model.Issue
+	if err := db.Where("repository_id = ?", repoID).Order("updated_at desc").First(&lastEntry).Error; err == nil {
+		return lastEntry.UpdatedAt
+	}
+	return time.Time{}
+}
+
+// deleteChunkSize is the number of issues deleted per query, SQLite limits
+// the number of variables of a query.
+const deleteChunkSize = 500
+
+// SaveSync records a successful pull, the issues of the repository that were
+// not returned by a full pull are deleted with their links.
+func SaveSync(db *gorm.DB, repo model.SyncedRepo, fetchedIDs []string) error {
+	if repo.Full {
+		var storedIDs []string
+		if err := db.Model(model.Issue{}).Where("repository_id = ?", repo.ID).Pluck("id", &storedIDs).Error; err != nil {
+			return err
+		}
+		fetched := make(map[string]bool, len(fetchedIDs))
+		for _, id := range fetchedIDs {
+			fetched[id] = true
+		}
+		stale := []string{}
+		for _, id := range storedIDs {
+			if !fetched[id] {
+				zap.L().Info("deleting issue missing from a full pull", zap.String("issue", id))
+				stale = append(stale, id)
+			}
+		}
+		for start := 0; start < len(stale); start += deleteChunkSize {
+			end := start + deleteChunkSize
+			if end > len(stale) {
+				end = len(stale)
+			}
+			chunk := stale[start:end]
+			if err := db.Where("source_id IN (?)", chunk).Delete(model.Link{}).Error; err != nil {
+				return err
+			}
+			if err := db.Where("id IN (?)", chunk).Delete(model.Issue{}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return db.Save(&model.RepositorySync{ID: repo.ID, LastSyncedAt: repo.StartedAt}).Error
+}
+
+// LoadAllLinks loads the links, the targets that were transferred to another
+// repository are resolved to their new URL.
+func LoadAllLinks(db *gorm.DB) ([]*model.Link, error) {
+	var links []*model.Link
+	if err := db.Model(model.Link{}).Find(&links).Error; err != nil {
+		return nil, err
+	}
+	transfers, err := LoadTransfers(db)
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		if newID, found := transfers[link.TargetID]; found {
+			link.TargetID = newID
+		}
+	}
+	zap.L().Debug("fetched links", zap.Int("quantity", len(links)))
+	return links, nil
+}
+
+// SaveLinks replaces the links declared by an issue, except the existing links
+// with one of the keepProvenances (i.e., "comment" when comments were not fetched).
+func SaveLinks(db *gorm.DB, sourceID string, links []*model.Link, keepProvenances ...string) error {
+	tx := db.Begin()
+	var existing []*model.Link
+	if err := tx.Where("source_id = ?", sourceID).Find(&existing).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	createdAt := map[string]time.Time{}
+	for _, link := range existing {
+		createdAt[link.ID] = link.CreatedAt
+	}
+	query := tx.Where("source_id = ?", sourceID)
+	if len(keepProvenances) > 0 {
+		query = query.Where("provenance NOT IN (?)", keepProvenances)
+	}
+	if err := query.Delete(model.Link{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, link := range links {
+		// keep the date of the first time the link was seen
+		if t, found := createdAt[link.ID]; found {
+			link.CreatedAt = t
+		} else if link.CreatedAt.IsZero() {
+			link.CreatedAt = time.Now()
+		}
+		if err := tx.Save(link).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+// LoadTransfers returns the new URL of the transferred issues, keyed by their
+// old URL. Chained transfers are resolved to the last location.
+func LoadTransfers(db *gorm.DB) (map[string]string, error) {
+	var transfers []*model.IssueTransfer
+	if err := db.Model(model.IssueTransfer{}).Find(&transfers).Error; err != nil {
+		return nil, err
+	}
+	mapping := map[string]string{}
+	for _, transfer := range transfers {
+		mapping[transfer.ID] = transfer.NewID
+	}
+	for oldID := range mapping {
+		newID := mapping[oldID]
+		for hops := 0; hops < len(mapping); hops++ {
+			next, found := mapping[newID]
+			if !found {
+				break
+			}
+			newID = next
+		}
+		mapping[oldID] = newID
+	}
+	return mapping, nil
+}
@@ -0,0 +1,190 @@
+package sql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/mattn/go-sqlite3" // required by gorm
+	"moul.io/depviz/model"
+)
+
+func testDB(t testing.TB) *gorm.DB {
+	db, err := FromOpts(&Options{Driver: "sqlite", DSN: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// saveTestIssues saves count issues of the same repository, with an author
+// and a label, created one second apart.
+func saveTestIssues(t testing.TB, db *gorm.DB, count int) {
+	author := &model.Account{Base: model.Base{ID: "https://github.com/moul", URL: "https://github.com/moul"}, Login: "moul"}
+	label := &model.Label{Base: model.Base{ID: "https://github.com/moul/depviz/labels/bug", URL: "https://github.com/moul/depviz/labels/bug"}, Name: "bug"}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tx := db.Begin()
+	for i := 1; i <= count; i++ {
+		url := fmt.Sprintf("https://github.com/moul/depviz/issues/%d", i)
+		issue := &model.Issue{
+			Base:     model.Base{ID: url, URL: url, CreatedAt: start.Add(time.Duration(i) * time.Second)},
+			Title:    fmt.Sprintf("Issue %d", i),
+			State:    "open",
+			Author:   author,
+			AuthorID: author.ID,
+			Labels:   []*model.Label{label},
+		}
+		if err := tx.Save(issue).Error; err != nil {
+			tx.Rollback()
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestForEachIssue(t *testing.T) {
+	db := testDB(t)
+	saveTestIssues(t, db, 250) // several pages
+
+	count := 0
+	err := ForEachIssue(db, func(issue *model.Issue) error {
+		count++
+		if expected := fmt.Sprintf("Issue %d", count); issue.Title != expected {
+			t.Fatalf("expected %q, got %q", expected, issue.Title)
+		}
+		if issue.Author == nil || issue.Author.Login != "moul" {
+			t.Fatalf("expected the author of %q to be preloaded, got %v", issue.ID, issue.Author)
+		}
+		if len(issue.Labels) != 1 || issue.Labels[0].Name != "bug" {
+			t.Fatalf("expected the labels of %q to be preloaded, got %v", issue.ID, issue.Labels)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 250 {
+		t.Errorf("expected 250 issues, got %d", count)
+	}
+}
+
+func TestForEachIssueError(t *testing.T) {
+	db := testDB(t)
+	saveTestIssues(t, db, 3)
+
+	count := 0
+	stop := fmt.Errorf("stop")
+	err := ForEachIssue(db, func(issue *model.Issue) error {
+		count++
+		return stop
+	})
+	if err != stop || count != 1 {
+		t.Errorf("expected to stop on the first error, got %v after %d issue(s)", err, count)
+	}
+}
+
+// BenchmarkForEachIssue streams the issues to a JSON encoder, the peak heap
+// should not depend on the number of issues.
+func BenchmarkForEachIssue(b *testing.B) {
+	for _, size := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("%d", size), func(b *testing.B) {
+			db := testDB(b)
+			saveTestIssues(b, db, size)
+			enc := json.NewEncoder(ioutil.Discard)
+			var (
+				stats runtime.MemStats
+				peak  uint64
+			)
+			runtime.GC()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				count := 0
+				err := ForEachIssue(db, func(issue *model.Issue) error {
+					count++
+					if count%1000 == 0 {
+						runtime.ReadMemStats(&stats)
+						if stats.HeapInuse > peak {
+							peak = stats.HeapInuse
+						}
+					}
+					return enc.Encode(issue)
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+			b.Logf("%d issues, peak heap in use: %d KiB", size, peak/1024)
+		})
+	}
+}
+
+func TestSaveSyncDeletesStaleIssues(t *testing.T) {
+	db := testDB(t)
+	saveTestIssues(t, db, 1200) // more stale issues than the SQLite variables limit
+	repoID := "https://github.com/moul/depviz"
+	if err := db.Model(model.Issue{}).Where("repository_id = ?", "").UpdateColumn("repository_id", repoID).Error; err != nil {
+		t.Fatal(err)
+	}
+	staleID := "https://github.com/moul/depviz/issues/1200"
+	if err := db.Save(model.NewLink(staleID, model.DependsOnLink, "https://github.com/moul/depviz/issues/1", "body")).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	fetchedIDs := []string{}
+	for i := 1; i <= 100; i++ {
+		fetchedIDs = append(fetchedIDs, fmt.Sprintf("https://github.com/moul/depviz/issues/%d", i))
+	}
+	startedAt := time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+	if err := SaveSync(db, model.SyncedRepo{ID: repoID, StartedAt: startedAt, Full: true}, fetchedIDs); err != nil {
+		t.Fatal(err)
+	}
+
+	var remaining int
+	if err := db.Model(model.Issue{}).Count(&remaining).Error; err != nil {
+		t.Fatal(err)
+	}
+	if remaining != 100 {
+		t.Errorf("expected 100 remaining issues, got %d", remaining)
+	}
+	var links int
+	if err := db.Model(model.Link{}).Where("source_id = ?", staleID).Count(&links).Error; err != nil {
+		t.Fatal(err)
+	}
+	if links != 0 {
+		t.Errorf("expected the links of the deleted issue to be removed, got %d", links)
+	}
+	var sync model.RepositorySync
+	if err := db.Where("id = ?", repoID).First(&sync).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !sync.LastSyncedAt.Equal(startedAt) {
+		t.Errorf("expected the sync date %s, got %s", startedAt, sync.LastSyncedAt)
+	}
+}
+
+func TestSaveSyncIncrementalKeepsIssues(t *testing.T) {
+	db := testDB(t)
+	saveTestIssues(t, db, 10)
+	repoID := "https://github.com/moul/depviz"
+	if err := db.Model(model.Issue{}).Where("repository_id = ?", "").UpdateColumn("repository_id", repoID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveSync(db, model.SyncedRepo{ID: repoID, StartedAt: time.Now(), Full: false}, nil); err != nil {
+		t.Fatal(err)
+	}
+	var remaining int
+	if err := db.Model(model.Issue{}).Count(&remaining).Error; err != nil {
+		t.Fatal(err)
+	}
+	if remaining != 10 {
+		t.Errorf("expected an incremental pull to keep the issues, got %d", remaining)
+	}
+}
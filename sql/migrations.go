@@ -0,0 +1,112 @@
+package sql
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"go.uber.org/zap"
+	"moul.io/depviz/model"
+)
+
+// SchemaMigration is a migration applied to the database, the current schema
+// version is the highest one.
+type SchemaMigration struct {
+	Version     int       `gorm:"primary_key;auto_increment:false"`
+	Description string    `json:"description"`
+	AppliedAt   time.Time `json:"applied-at"`
+}
+
+func (SchemaMigration) TableName() string { return "schema_migrations" }
+
+type migration struct {
+	version     int
+	description string
+	migrate     func(db *gorm.DB) error
+}
+
+// migrations are applied in order on open, and with 'db migrate'. A schema
+// change (new model, column type change, dropped column, ...) is a new
+// migration appended to this list, the applied ones must not be changed.
+//
+// The first one creates the tables of the current models and adds their
+// missing columns, it also upgrades the databases created before the
+// migrations were versioned.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create the tables of the models",
+		migrate: func(db *gorm.DB) error {
+			return db.AutoMigrate(model.AllModels...).Error
+		},
+	},
+	{
+		version:     2,
+		description: "add the profile page URL of the accounts",
+		migrate: func(db *gorm.DB) error {
+			return db.AutoMigrate(&model.Account{}).Error
+		},
+	},
+}
+
+// SchemaVersion returns the version of the last migration applied to the
+// database, 0 if none.
+func SchemaVersion(db *gorm.DB) (int, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}).Error; err != nil {
+		return 0, err
+	}
+	var last SchemaMigration
+	switch err := db.Order("version desc").First(&last).Error; {
+	case gorm.IsRecordNotFoundError(err):
+		return 0, nil
+	case err != nil:
+		return 0, err
+	}
+	return last.Version, nil
+}
+
+// PendingMigrations returns the descriptions of the migrations not applied yet,
+// by version.
+func PendingMigrations(db *gorm.DB) (map[int]string, error) {
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	pending := map[int]string{}
+	for _, m := range migrations {
+		if m.version > version {
+			pending[m.version] = m.description
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies the pending migrations in order, each one in a transaction
+// with its record in the schema_migrations table, and returns the new schema
+// version.
+func Migrate(db *gorm.DB) (int, error) {
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		tx := db.Begin()
+		if err := m.migrate(tx); err != nil {
+			tx.Rollback()
+			return version, err
+		}
+		record := SchemaMigration{Version: m.version, Description: m.description, AppliedAt: time.Now()}
+		if err := tx.Create(&record).Error; err != nil {
+			tx.Rollback()
+			return version, err
+		}
+		if err := tx.Commit().Error; err != nil {
+			return version, err
+		}
+		version = m.version
+		zap.L().Debug("migration applied", zap.Int("version", m.version), zap.String("description", m.description))
+	}
+	return version, nil
+}
@@ -8,11 +8,23 @@ import (
 
 	"github.com/jinzhu/gorm"
 	"go.uber.org/zap"
-	"moul.io/depviz/model"
 	"moul.io/zapgorm"
 )
 
+// FromOpts opens the database and applies the pending migrations.
 func FromOpts(opts *Options) (*gorm.DB, error) {
+	db, err := open(opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := Migrate(db); err != nil {
+		return nil, fmt.Errorf("cannot migrate the database: %v", err)
+	}
+	return db, nil
+}
+
+// open opens the database, without applying the migrations.
+func open(opts *Options) (*gorm.DB, error) {
 	if os.Getenv("DEPVIZ_DEBUG") == "1" {
 		opts.Verbose = true
 	}
@@ -22,6 +34,10 @@ func FromOpts(opts *Options) (*gorm.DB, error) {
 		err error
 	)
 	switch {
+	case opts.Driver == "sqlite" || opts.Driver == "sqlite3":
+		db, err = gorm.Open("sqlite3", os.ExpandEnv(opts.DSN))
+	case opts.Driver != "":
+		return nil, fmt.Errorf("unsupported sql driver: %q", opts.Driver)
 	case strings.HasPrefix(opts.Config, "sqlite://"):
 		dbPath := os.ExpandEnv(opts.Config[len("sqlite://"):])
 		db, err = gorm.Open("sqlite3", dbPath)
@@ -31,6 +47,13 @@ func FromOpts(opts *Options) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	if opts.DSN == ":memory:" { // each connection has its own in-memory database
+		db.DB().SetMaxOpenConns(1)
+	}
+	return configureDB(db, opts.Verbose)
+}
+
+func configureDB(db *gorm.DB, verbose bool) (*gorm.DB, error) {
 	db.LogMode(true)
 	log.SetOutput(ioutil.Discard)
 	db.Callback().Create().Remove("gorm:update_time_stamp")
@@ -41,10 +64,6 @@ func FromOpts(opts *Options) (*gorm.DB, error) {
 	db = db.Set("gorm:association_autoupdate", true)
 	db.BlockGlobalUpdate(true)
 	db.SingularTable(true)
-	db.LogMode(opts.Verbose)
-	if err := db.AutoMigrate(model.AllModels...).Error; err != nil {
-		return nil, err
-	}
-
+	db.LogMode(verbose)
 	return db, nil
 }
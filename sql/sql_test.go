@@ -0,0 +1,111 @@
+package sql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"moul.io/depviz/model"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  Options
+		valid bool
+	}{
+		{"config only", Options{Config: "sqlite://depviz.db"}, true},
+		{"sqlite", Options{Driver: "sqlite", DSN: ":memory:"}, true},
+		{"sqlite3", Options{Driver: "sqlite3", DSN: "./depviz.db"}, true},
+		{"missing dsn", Options{Driver: "sqlite"}, false},
+		{"missing driver", Options{DSN: ":memory:"}, false},
+		{"unsupported driver", Options{Driver: "postgres", DSN: "host=localhost"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.opts.Validate(); test.valid != (err == nil) {
+				t.Errorf("unexpected validation result: %v", err)
+			}
+		})
+	}
+}
+
+func TestIssueRoundTrip(t *testing.T) {
+	db := testDB(t)
+
+	service := &model.Provider{Base: model.Base{ID: "https://github.com", URL: "https://github.com"}, Driver: string(model.GithubDriver)}
+	owner := &model.Account{Base: model.Base{ID: "https://github.com/moul", URL: "https://github.com/moul"}, Login: "moul", FullName: "Manfred Touron"}
+	repo := &model.Repository{Base: model.Base{ID: "https://github.com/moul/depviz", URL: "https://github.com/moul/depviz"}, Title: "depviz", Owner: owner}
+	milestone := &model.Milestone{Base: model.Base{ID: "https://github.com/moul/depviz/milestone/1", URL: "https://github.com/moul/depviz/milestone/1"}, Title: "v1", Repository: repo}
+	createdAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	input := &model.Issue{
+		Base:       model.Base{ID: "https://github.com/moul/depviz/issues/42", URL: "https://github.com/moul/depviz/issues/42", CreatedAt: createdAt, UpdatedAt: createdAt.Add(time.Hour)},
+		Title:      "Round trip",
+		State:      "open",
+		Body:       "Depends on #41",
+		IsPR:       true,
+		Estimate:   "2d",
+		TasksDone:  1,
+		TasksTotal: 3,
+		Repository: repo,
+		Service:    service,
+		Milestone:  milestone,
+		Author:     owner,
+		Labels: []*model.Label{
+			{Base: model.Base{ID: "https://github.com/moul/depviz/labels/bug", URL: "https://github.com/moul/depviz/labels/bug"}, Name: "bug", Color: "ff0000"},
+		},
+		Assignees: []*model.Account{owner},
+	}
+	if err := db.Save(input).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := LoadAllIssues(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %d", len(issues))
+	}
+	output := issues[0]
+	checks := []struct {
+		field            string
+		expected, actual interface{}
+	}{
+		{"id", input.ID, output.ID},
+		{"title", input.Title, output.Title},
+		{"state", input.State, output.State},
+		{"body", input.Body, output.Body},
+		{"is-pr", input.IsPR, output.IsPR},
+		{"estimate", input.Estimate, output.Estimate},
+		{"tasks", []int{1, 3}, []int{output.TasksDone, output.TasksTotal}},
+		{"created-at", true, output.CreatedAt.Equal(input.CreatedAt)},
+		{"updated-at", true, output.UpdatedAt.Equal(input.UpdatedAt)},
+		{"repository", repo.ID, output.RepositoryID},
+		{"service", service.ID, output.ServiceID},
+		{"milestone", milestone.ID, output.MilestoneID},
+		{"author", owner.ID, output.AuthorID},
+	}
+	for _, check := range checks {
+		if !reflect.DeepEqual(check.expected, check.actual) {
+			t.Errorf("%s: expected %v, got %v", check.field, check.expected, check.actual)
+		}
+	}
+
+	// the relations are preloaded
+	if output.Author == nil || output.Author.FullName != "Manfred Touron" {
+		t.Errorf("expected the author to be preloaded, got %v", output.Author)
+	}
+	if output.Milestone == nil || output.Milestone.Title != "v1" {
+		t.Errorf("expected the milestone to be preloaded, got %v", output.Milestone)
+	}
+	if output.Repository == nil || output.Repository.Title != "depviz" {
+		t.Errorf("expected the repository to be preloaded, got %v", output.Repository)
+	}
+	if len(output.Labels) != 1 || output.Labels[0].Name != "bug" || output.Labels[0].Color != "ff0000" {
+		t.Errorf("expected the bug label, got %v", output.Labels)
+	}
+	if len(output.Assignees) != 1 || output.Assignees[0].Login != "moul" {
+		t.Errorf("expected the moul assignee, got %v", output.Assignees)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"moul.io/depviz/cli"
+	"moul.io/depviz/graph"
+	"moul.io/depviz/pull"
 	"moul.io/depviz/sql"
 )
 
@@ -23,12 +25,18 @@ type webCommand struct {
 
 func (cmd *webCommand) CobraCommand(commands cli.Commands) *cobra.Command {
 	cc := &cobra.Command{
-		Use:   "web",
-		Short: "Output web of relationships between all issues stored in database",
-		Args:  cobra.MaximumNArgs(0),
+		Use:     "web",
+		Aliases: []string{"serve"},
+		Short:   "Serve the issues and the graphs stored in database over HTTP",
+		Args:    cobra.MaximumNArgs(0),
 		RunE: func(_ *cobra.Command, args []string) error {
 			opts := cmd.opts
 			opts.SQL = sql.GetOptions(commands)
+			opts.Graph = graph.GetOptions(commands)
+			opts.Parse = pull.GetOptions(commands).Parse
+			if err := opts.Validate(); err != nil {
+				return err
+			}
 			return Web(&opts)
 		},
 	}
@@ -43,6 +51,9 @@ func (cmd *webCommand) LoadDefaultOptions() error {
 
 func (cmd *webCommand) ParseFlags(flags *pflag.FlagSet) {
 	flags.StringVarP(&cmd.opts.Bind, "bind", "b", ":2020", "HTTP server bind address")
+	flags.BoolVarP(&cmd.opts.Webhook, "webhook", "", false, "receive the GitHub issues events on POST /webhook")
+	flags.StringVarP(&cmd.opts.WebhookSecret, "webhook-secret", "", "", "secret of the GitHub webhook, used to verify the X-Hub-Signature-256 header")
+	flags.StringVarP(&cmd.opts.CORSOrigin, "cors-origin", "", "", `origin allowed to call /api/graph from a browser, i.e., "https://dashboard.example.com" or "*"`)
 	flags.BoolVarP(&cmd.opts.GenDoc, "gendoc", "", false, "generate Markdown documentation and exit")
 	if err := viper.BindPFlags(flags); err != nil {
 		zap.L().Warn("failed to bind viper flags", zap.Error(err))
@@ -0,0 +1,79 @@
+package web
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// decodeQuery sets the fields of an options struct from the query parameters
+// named like their mapstructure tags, i.e., like the command-line flags:
+// "?show-closed&format=svg&filter-label=bug,security". The underscores are
+// accepted in place of the dashes ("?show_closed"). The unsupported fields
+// (maps, nested structs, targets) and the ignored names are skipped.
+func decodeQuery(query url.Values, opts interface{}, ignored ...string) error {
+	for name, values := range query {
+		if dashed := strings.Replace(name, "_", "-", -1); dashed != name {
+			query[dashed] = append(query[dashed], values...)
+			delete(query, name)
+		}
+	}
+	for _, name := range ignored {
+		query.Del(name)
+	}
+	return decodeQueryValue(query, reflect.ValueOf(opts).Elem())
+}
+
+func decodeQueryValue(query url.Values, v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag := strings.Split(field.Tag.Get("mapstructure"), ",")
+		if len(tag) > 1 && tag[1] == "squash" && field.Type.Kind() == reflect.Struct {
+			if err := decodeQueryValue(query, v.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		name := tag[0]
+		values, found := query[name]
+		if name == "" || !found || !v.Field(i).CanSet() {
+			continue
+		}
+		value := ""
+		if len(values) > 0 {
+			value = values[len(values)-1]
+		}
+		switch kind := field.Type.Kind(); {
+		case kind == reflect.Bool:
+			if value == "" { // "?show-closed"
+				value = "true"
+			}
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid %q parameter: %v", name, err)
+			}
+			v.Field(i).SetBool(b)
+		case kind == reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid %q parameter: %v", name, err)
+			}
+			v.Field(i).SetInt(int64(n))
+		case kind == reflect.String:
+			v.Field(i).SetString(value)
+		case kind == reflect.Slice && field.Type.Elem().Kind() == reflect.String:
+			items := []string{}
+			for _, value := range values {
+				for _, item := range strings.Split(value, ",") {
+					if item = strings.TrimSpace(item); item != "" {
+						items = append(items, item)
+					}
+				}
+			}
+			v.Field(i).Set(reflect.ValueOf(items))
+		}
+	}
+	return nil
+}
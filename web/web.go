@@ -15,6 +15,7 @@ import (
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/docgen"
 	"github.com/go-chi/render"
+	"moul.io/depviz/compute"
 	"moul.io/depviz/graph"
 	"moul.io/depviz/model"
 	"moul.io/depviz/sql"
@@ -24,9 +25,23 @@ type Options struct {
 	SQL    sql.Options `mapstructure:"sql"` // inherited with sql.GetOptions()
 	Bind   string      `mapstructure:"bind"`
 	GenDoc bool        `mapstructure:"gendoc"`
+
+	Webhook       bool   `mapstructure:"webhook"`
+	WebhookSecret string `mapstructure:"webhook-secret"`
+	CORSOrigin    string `mapstructure:"cors-origin"`
+
+	Graph graph.Options        `mapstructure:"-"` // inherited with graph.GetOptions(), the defaults of /graph
+	Parse compute.ParseOptions `mapstructure:"-"` // inherited with pull.GetOptions(), used by /webhook
 	// Targets []multipmuri.Entity `mapstructure:"targets"` // parsed from Args
 }
 
+func (opts Options) Validate() error {
+	if opts.Webhook && opts.WebhookSecret == "" {
+		return fmt.Errorf("--webhook requires --webhook-secret")
+	}
+	return nil
+}
+
 func Web(opts *Options) error {
 	r := chi.NewRouter()
 
@@ -44,9 +59,20 @@ func Web(opts *Options) error {
 			r.Use(render.SetContentType(render.ContentTypeJSON))
 			r.Get("/issues.json", h.webListIssues)
 		})
+		r.Group(func(r chi.Router) {
+			r.Use(h.cors, middleware.Compress(5, "application/json"))
+			r.Get("/graph", h.webAPIGraph)
+			r.Options("/graph", h.webPreflight)
+		})
 		r.Get("/graph/dot", h.webDotIssues)
 		r.Get("/graph/image", h.webImageIssues)
+		r.Post("/graph/invalidate-cache", h.webInvalidateCache)
 	})
+	r.Get("/graph", h.webGraph)
+	r.Get("/healthz", h.webHealthz)
+	if opts.Webhook {
+		r.Post("/webhook", h.webWebhook)
+	}
 
 	workDir, _ := os.Getwd()
 	filesDir := filepath.Join(workDir, "static")
@@ -108,13 +134,136 @@ func (h *handler) webGraphviz(r *http.Request) (string, error) {
 		return "", err
 	}
 	opts := graph.Options{
-		SQL:     h.opts.SQL,
-		Targets: targets,
+		SQL:      h.opts.SQL,
+		Targets:  targets,
+		MaxDepth: -1,
+		Parse:    compute.DefaultParseOptions(),
 		// FIXME: add more options
 	}
 	return graph.Graph(&opts)
 }
 
+// graphOptions returns the graph options of the request: the defaults of the
+// 'graph' command overridden by the query parameters named like its flags, i.e.,
+// "/graph?targets=moul/depviz&format=svg&show-closed". A non-empty format
+// overrides the 'format' parameter.
+func (h *handler) graphOptions(r *http.Request, format string) (*graph.Options, error) {
+	opts := h.opts.Graph
+	opts.SQL = h.opts.SQL
+	opts.Open = false
+	opts.Progress = false
+	opts.Output = "-"
+	query := r.URL.Query()
+	targets, err := model.ParseTargets(strings.Split(query.Get("targets"), ","))
+	if err != nil {
+		return nil, err
+	}
+	opts.Targets = targets
+	// the credentials and the local files are server-side only
+	if err := decodeQuery(query, &opts, "targets", "targets-file", "github-token", "gitlab-token", "open", "progress", "output"); err != nil {
+		return nil, err
+	}
+	if format != "" {
+		opts.Format = format
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return &opts, nil
+}
+
+// webGraph renders the graph in the requested format, like 'depviz graph'.
+func (h *handler) webGraph(w http.ResponseWriter, r *http.Request) {
+	opts, err := h.graphOptions(r, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	out, err := graph.Graph(opts)
+	if err != nil {
+		_ = render.Render(w, r, ErrRender(err))
+		return
+	}
+	w.Header().Set("Content-Type", graphContentType(opts.Format))
+	_, _ = w.Write([]byte(out))
+}
+
+// webAPIGraph returns the {nodes, edges} JSON of the graph for the front-ends,
+// the query parameters are the same as /graph, except 'format'.
+func (h *handler) webAPIGraph(w http.ResponseWriter, r *http.Request) {
+	opts, err := h.graphOptions(r, "json")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	out, err := graph.Graph(opts)
+	if err != nil {
+		_ = render.Render(w, r, ErrRender(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write([]byte(out))
+}
+
+// cors allows the --cors-origin origin ("*" for any) to call the API from a
+// browser. Nothing is sent if it is not set.
+func (h *handler) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := h.opts.CORSOrigin; origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Accept, Accept-Encoding, Content-Type")
+			if origin != "*" {
+				w.Header().Add("Vary", "Origin")
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// webPreflight answers the CORS preflight requests, the headers are set by cors.
+func (h *handler) webPreflight(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func graphContentType(format string) string {
+	switch format {
+	case "svg":
+		return "image/svg+xml"
+	case "json", "gv-json", "cytoscape":
+		return "application/json"
+	case "graphml":
+		return "application/xml"
+	case "xlsx":
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case "gantt-csv":
+		return "text/csv; charset=utf-8"
+	case "dot":
+		return "text/vnd.graphviz; charset=utf-8"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// webHealthz is the liveness probe, it checks the database connection.
+func (h *handler) webHealthz(w http.ResponseWriter, r *http.Request) {
+	db, err := sql.FromOpts(&h.opts.SQL)
+	if err == nil {
+		defer db.Close()
+		err = db.DB().Ping()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// webInvalidateCache flushes the cached PERT renderings.
+func (h *handler) webInvalidateCache(w http.ResponseWriter, r *http.Request) {
+	graph.InvalidatePertCache()
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *handler) webDotIssues(w http.ResponseWriter, r *http.Request) {
 	out, err := h.webGraphviz(r)
 	if err != nil {
@@ -0,0 +1,146 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/render"
+	gh "github.com/google/go-github/github"
+	"github.com/jinzhu/gorm"
+	"go.uber.org/zap"
+	"moul.io/depviz/github"
+	"moul.io/depviz/model"
+	"moul.io/depviz/pull"
+	"moul.io/depviz/sql"
+)
+
+const maxWebhookPayloadBytes = 5 << 20 // GitHub caps the payloads at 25MB, the issues events are far smaller
+
+// webWebhook receives the GitHub 'issues', 'pull_request' and 'issue_comment'
+// events and upserts the issue, so the database stays fresh between pulls. The
+// first 'assigned' action of an issue is recorded, see 'metrics delivery'.
+// The payloads must be signed with --webhook-secret.
+//
+// See https://docs.github.com/en/webhooks/using-webhooks/validating-webhook-deliveries
+func (h *handler) webWebhook(w http.ResponseWriter, r *http.Request) {
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayloadBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("cannot read the payload: %v", err), http.StatusBadRequest)
+		return
+	}
+	if !validSignature(payload, r.Header.Get("X-Hub-Signature-256"), h.opts.WebhookSecret) {
+		http.Error(w, "missing or invalid X-Hub-Signature-256 header", http.StatusUnauthorized)
+		return
+	}
+
+	event := r.Header.Get("X-GitHub-Event")
+	issue, err := webhookIssue(event, payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if issue == nil { // ping and the other events
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	db, err := sql.FromOpts(&h.opts.SQL)
+	if err != nil {
+		_ = render.Render(w, r, ErrRender(err))
+		return
+	}
+	defer db.Close()
+	if _, err := pull.SaveIssue(db, issue, h.opts.Parse, false); err != nil {
+		_ = render.Render(w, r, ErrRender(err))
+		return
+	}
+	if event == "issues" && webhookAction(payload) == "assigned" {
+		if err := saveFirstAssignment(db, issue); err != nil {
+			_ = render.Render(w, r, ErrRender(err))
+			return
+		}
+	}
+	zap.L().Debug("webhook: issue saved", zap.String("event", event), zap.String("issue", issue.URL))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validSignature checks the "sha256=<hex>" HMAC of the payload.
+func validSignature(payload []byte, signature string, secret string) bool {
+	if secret == "" || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// webhookIssue returns the issue of the payload, or nil if the event is not
+// supported.
+func webhookIssue(event string, payload []byte) (*model.Issue, error) {
+	switch event {
+	case "issues", "issue_comment":
+		var body struct {
+			Issue *gh.Issue `json:"issue"`
+		}
+		if err := json.Unmarshal(payload, &body); err != nil {
+			return nil, fmt.Errorf("invalid %q payload: %v", event, err)
+		}
+		if body.Issue == nil || body.Issue.GetHTMLURL() == "" {
+			return nil, fmt.Errorf("invalid %q payload: no issue", event)
+		}
+		return fromWebhook(func() *model.Issue { return github.FromIssue(body.Issue) })
+	case "pull_request":
+		var body struct {
+			PullRequest *gh.PullRequest `json:"pull_request"`
+		}
+		if err := json.Unmarshal(payload, &body); err != nil {
+			return nil, fmt.Errorf("invalid %q payload: %v", event, err)
+		}
+		if body.PullRequest == nil || body.PullRequest.GetHTMLURL() == "" {
+			return nil, fmt.Errorf("invalid %q payload: no pull request", event)
+		}
+		return fromWebhook(func() *model.Issue { return github.FromPullRequest(body.PullRequest) })
+	}
+	return nil, nil
+}
+
+// fromWebhook recovers the panics of the converters on unexpected URLs.
+func fromWebhook(convert func() *model.Issue) (issue *model.Issue, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid payload: %v", r)
+		}
+	}()
+	return convert(), nil
+}
+
+func webhookAction(payload []byte) string {
+	var body struct {
+		Action string `json:"action"`
+	}
+	_ = json.Unmarshal(payload, &body)
+	return body.Action
+}
+
+// saveFirstAssignment records the first assignment of an issue, for the cycle
+// time of 'metrics delivery'. The issue was updated by the assignment.
+func saveFirstAssignment(db *gorm.DB, issue *model.Issue) error {
+	var stored model.Issue
+	if err := db.Select("assigned_at").Where("id = ?", issue.ID).First(&stored).Error; err != nil {
+		return err
+	}
+	if !stored.AssignedAt.IsZero() {
+		return nil
+	}
+	return db.Model(&model.Issue{}).Where("id = ?", issue.ID).Update("assigned_at", issue.UpdatedAt).Error
+}
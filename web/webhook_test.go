@@ -0,0 +1,156 @@
+package web
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"moul.io/depviz/compute"
+	"moul.io/depviz/model"
+	"moul.io/depviz/sql"
+)
+
+const testWebhookSecret = "s3cr3t"
+
+func testHandler(t *testing.T) (*handler, func()) {
+	dir, err := ioutil.TempDir("", "depviz-webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &Options{
+		SQL:           sql.Options{Driver: "sqlite", DSN: filepath.Join(dir, "depviz.db")},
+		Webhook:       true,
+		WebhookSecret: testWebhookSecret,
+		Parse:         compute.DefaultParseOptions(),
+	}
+	return &handler{opts: opts}, func() { os.RemoveAll(dir) }
+}
+
+func sign(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// replay sends a payload of testdata to the webhook handler.
+func replay(t *testing.T, h *handler, event string, payload []byte, signature string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(payload))
+	req.Header.Set("X-GitHub-Event", event)
+	if signature != "" {
+		req.Header.Set("X-Hub-Signature-256", signature)
+	}
+	rec := httptest.NewRecorder()
+	h.webWebhook(rec, req)
+	return rec
+}
+
+func loadPayload(t *testing.T, name string) []byte {
+	payload, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func TestWebhookReplay(t *testing.T) {
+	h, cleanup := testHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		event    string
+		file     string
+		htmlURL  string
+		state    string
+		isPR     bool
+		linkKind model.LinkKind
+	}{
+		{"issues", "webhook_issues.json", "https://github.com/moul/depviz/issues/42", "open", false, model.DependsOnLink},
+		{"issue_comment", "webhook_issue_comment.json", "https://github.com/moul/depviz/issues/43", "closed", false, model.PartOfLink},
+		{"pull_request", "webhook_pull_request.json", "https://github.com/moul/depviz/pull/44", "open", true, model.FixesLink},
+	}
+	for _, test := range tests {
+		t.Run(test.event, func(t *testing.T) {
+			target, err := model.ParseTarget(test.htmlURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			issueID := target.String()
+			payload := loadPayload(t, test.file)
+			rec := replay(t, h, test.event, payload, sign(payload, testWebhookSecret))
+			if rec.Code != http.StatusNoContent {
+				t.Fatalf("expected 204, got %d: %s", rec.Code, rec.Body.String())
+			}
+
+			db, err := sql.FromOpts(&h.opts.SQL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			var issue model.Issue
+			if err := db.Where("id = ?", issueID).First(&issue).Error; err != nil {
+				t.Fatalf("issue not saved: %v", err)
+			}
+			if issue.State != test.state || issue.IsPR != test.isPR {
+				t.Errorf("expected state=%s pr=%v, got state=%s pr=%v", test.state, test.isPR, issue.State, issue.IsPR)
+			}
+			var links []model.Link
+			if err := db.Where("source_id = ?", issueID).Find(&links).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(links) != 1 || links[0].Kind != test.linkKind {
+				t.Errorf("expected one %q link, got %v", test.linkKind, links)
+			}
+		})
+	}
+
+	// the 'assigned' action of the issues event records the first assignment
+	db, err := sql.FromOpts(&h.opts.SQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var assigned model.Issue
+	if err := db.Where("id = ?", "https://github.com/moul/depviz/issues/42").First(&assigned).Error; err != nil {
+		t.Fatal(err)
+	}
+	if assigned.AssignedAt.IsZero() {
+		t.Error("expected the first assignment to be recorded")
+	}
+}
+
+func TestWebhookRejections(t *testing.T) {
+	h, cleanup := testHandler(t)
+	defer cleanup()
+	payload := loadPayload(t, "webhook_issues.json")
+
+	tests := []struct {
+		name      string
+		event     string
+		payload   []byte
+		signature string
+		expected  int
+	}{
+		{"unsigned", "issues", payload, "", http.StatusUnauthorized},
+		{"wrong secret", "issues", payload, sign(payload, "other"), http.StatusUnauthorized},
+		{"not hex", "issues", payload, "sha256=zz", http.StatusUnauthorized},
+		{"tampered", "issues", append([]byte(" "), payload...), sign(payload, testWebhookSecret), http.StatusUnauthorized},
+		{"malformed", "issues", []byte("{"), sign([]byte("{"), testWebhookSecret), http.StatusBadRequest},
+		{"no issue", "issues", []byte(`{"action":"opened"}`), sign([]byte(`{"action":"opened"}`), testWebhookSecret), http.StatusBadRequest},
+		{"ping", "ping", loadPayload(t, "webhook_ping.json"), sign(loadPayload(t, "webhook_ping.json"), testWebhookSecret), http.StatusNoContent},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := replay(t, h, test.event, test.payload, test.signature)
+			if rec.Code != test.expected {
+				t.Errorf("expected %d, got %d: %s", test.expected, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}